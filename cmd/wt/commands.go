@@ -0,0 +1,552 @@
+package main
+
+import (
+	"github.com/tobiase/worktree-utils/internal/cli"
+	"github.com/tobiase/worktree-utils/internal/completion"
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/update"
+)
+
+// commands is the registry driving dispatch, --help, completion and
+// unknown-command suggestions. It is built in init because several handlers
+// refer back to it.
+var commands *cli.Registry
+
+func init() {
+	commands = newCommandRegistry()
+}
+
+// Headings of the command listing in 'wt --help'; commands without a group
+// are listed last, under "Other commands"
+const (
+	groupSmart   = "Smart commands (with fuzzy branch matching)"
+	groupUtility = "Utility commands"
+	groupSetup   = "Setup commands"
+	groupOther   = "Other commands"
+)
+
+// withoutConfig adapts a handler that doesn't use the config manager
+func withoutConfig(run func(args []string)) func([]string, *config.Manager) {
+	return func(args []string, _ *config.Manager) {
+		run(args)
+	}
+}
+
+func newCommandRegistry() *cli.Registry {
+	return cli.NewRegistry(
+		&cli.Command{
+			Name:        listCmd,
+			Group:       groupSmart,
+			Aliases:     []string{"ls"},
+			Usage:       "wt list",
+			Summary:     "List all worktrees",
+			Description: "List all worktrees with their index, branch name, and path",
+			Examples: []string{
+				"wt list              # Show all worktrees",
+				"wt ls                # Same as above (alias)",
+			},
+			SeeAlso: []string{"wt go", "wt new"},
+			Run:     withoutConfig(handleListCommand),
+		},
+		&cli.Command{
+			Name:        "recent",
+			Group:       groupSmart,
+			Usage:       "wt recent [index] [options]",
+			Summary:     "Show and navigate to recently active branches",
+			Description: "Show and navigate to your recently active branches (default: only your branches, multi-line format)",
+			Flags: []cli.Flag{
+				{Name: "--all", Description: "Show all branches regardless of author", Example: "wt recent --all"},
+				{Name: "--others", Description: "Show only branches authored by other users", Example: "wt recent --others"},
+				{Name: "-n", Description: "Number of branches to show (default: 10)", Example: "wt recent -n 20", HasValue: true},
+				{Name: "--verbose", Short: "-v", Description: "Show detailed information about skipped branches", Example: "wt recent --verbose"},
+				{Name: "--compact", Short: "-c", Description: "Use compact single-line format instead of multi-line", Example: "wt recent --compact"},
+				{Name: "--interactive", Short: "-i", Description: "Select a branch in the fuzzy finder; creates a worktree if it has none", Example: "wt recent -i"},
+				{Name: "--since", Description: "Only branches with a commit after this time (12h, 3d, 2w, 6mo, 1y or YYYY-MM-DD)", Example: "wt recent --since 2w", HasValue: true},
+				{Name: "--until", Description: "Only branches whose last commit is before this time", Example: "wt recent --until 2024-01-31", HasValue: true},
+				{Name: "--grep", Description: "Only branches whose name matches a regular expression", Example: "wt recent --grep feature", HasValue: true},
+				{Name: "--merged", Description: "Only branches merged into the default branch", Example: "wt recent --merged"},
+				{Name: "--unmerged", Description: "Only branches not yet merged into the default branch", Example: "wt recent --unmerged"},
+				{Name: "--with-worktree", Description: "Only branches that have a worktree", Example: "wt recent --with-worktree"},
+				{Name: "--without-worktree", Description: "Only branches without a worktree", Example: "wt recent --without-worktree"},
+				{Name: "--remote", Description: "Include remote-tracking branches (refs/remotes/*)", Example: "wt recent --remote"},
+				{Name: "--no-cache", Description: "Ignore the on-disk commit cache for merge-tip branches", Example: "wt recent --no-cache"},
+			},
+			Args: []cli.Argument{
+				{Name: "index", Description: "Branch index to navigate to (optional)", Type: cli.ArgString},
+			},
+			Examples: []string{
+				"wt recent                    # List your 10 most recent branches",
+				"wt recent -n 20              # List your 20 most recent branches",
+				"wt recent --all              # Show all branches regardless of author",
+				"wt recent --others           # Show only other users' branches",
+				"wt recent 2                  # Navigate to your branch at index 2",
+				"wt recent --all 2           # Navigate to branch at index 2 (all branches)",
+				"wt recent -i                 # Pick a branch in the fuzzy finder with preview",
+				"wt recent --since 2w         # Only branches with commits in the last two weeks",
+				"wt recent --grep '^fix/' -c  # Branches matching a pattern, compact format",
+				"wt recent --unmerged --without-worktree  # Unmerged branches without a worktree",
+				"wt recent --remote --all     # Include remote-tracking branches",
+			},
+			SeeAlso: []string{"wt list", "wt go", "wt new"},
+			Run:     handleRecentCommand,
+		},
+		&cli.Command{
+			Name:        "go",
+			Group:       groupSmart,
+			Aliases:     []string{"switch", "s"},
+			Usage:       "wt go [branch|index] [options]",
+			Summary:     "Switch to a worktree",
+			Description: "Switch to a worktree by branch name or index. Supports fuzzy matching. Exports WT_BRANCH, WT_WORKTREE, WT_PROJECT and the project's env block into the shell, and runs the project's on_leave/on_enter hooks.",
+			Flags: []cli.Flag{
+				{Name: fuzzyFlag, Short: fuzzyFlagShort, Description: "Force interactive selection even for unique matches", Example: "wt go main -f"},
+			},
+			Args: []cli.Argument{
+				{Name: "branch", Description: "Worktree branch name (optional)", Type: cli.ArgWorktreeBranch},
+			},
+			Examples: []string{
+				"wt go                # Go to repository root",
+				"wt go main           # Switch to main branch worktree",
+				"wt go mai            # Fuzzy match to 'main'",
+				"wt go 0              # Switch to first worktree by index",
+				"wt go feat --fuzzy   # Force interactive selection for 'feat'",
+				"wt s feat            # Short alias with fuzzy matching",
+			},
+			SeeAlso: []string{"wt list", "wt new"},
+			Run:     handleGoCommand,
+		},
+		&cli.Command{
+			Name:        "new",
+			Group:       groupSmart,
+			Usage:       "wt new <branch> [options]",
+			Summary:     "Create and switch to a new worktree",
+			Description: "Smart worktree creation that handles all branch states intelligently",
+			Flags: []cli.Flag{
+				{Name: "--base", Description: "Base branch for new branch creation", Example: "wt new feature --base develop",
+					HasValue: true, ValueName: "branch", Value: cli.ArgBranch},
+				{Name: "--no-switch", Description: "Create worktree without switching to it", Example: "wt new feature --no-switch"},
+			},
+			Args: []cli.Argument{
+				{Name: "branch", Description: "New branch name", Type: cli.ArgString},
+			},
+			Examples: []string{
+				"wt new feature               # Create new branch + worktree",
+				"wt new existing-branch       # Create worktree for existing branch",
+				"wt new feature --base main   # Create new branch from main",
+				"wt new feature --no-switch   # Create worktree without switching to it",
+			},
+			SeeAlso: []string{"wt go", "wt rm"},
+			Run:     handleNewCommand,
+		},
+		&cli.Command{
+			Name:        "rm",
+			Group:       groupSmart,
+			Usage:       "wt rm [branch] [options]",
+			Summary:     "Remove a worktree",
+			Description: "Remove a worktree. Supports fuzzy matching for branch names.",
+			Flags: []cli.Flag{
+				{Name: fuzzyFlag, Short: fuzzyFlagShort, Description: "Force interactive selection for worktree to remove", Example: "wt rm -f"},
+				{Name: "--branch", Description: "Remove the associated Git branch once the worktree is gone", Example: "wt rm feature --branch"},
+				{Name: "--force", Description: "Allow branch deletion even if it is not merged (only with --branch)", Example: "wt rm feature --branch --force"},
+			},
+			Args: []cli.Argument{
+				{Name: "branch", Description: "Worktree branch name", Type: cli.ArgWorktreeBranch},
+			},
+			Examples: []string{
+				"wt rm feature        # Remove worktree for 'feature' branch",
+				"wt rm feat           # Fuzzy match to remove 'feature' branch",
+				"wt rm --fuzzy        # Interactive selection of worktree to remove",
+			},
+			SeeAlso: []string{"wt list", "wt new", "wt integrate"},
+			Run:     withoutConfig(handleRemoveCommand),
+		},
+		&cli.Command{
+			Name:        "integrate",
+			Group:       groupSmart,
+			Usage:       "wt integrate [branch] [options]",
+			Summary:     "Integrate a worktree branch back into main and clean up",
+			Description: "Rebase a worktree branch onto main, fast-forward merge it, then remove the worktree and branch.",
+			Flags: []cli.Flag{
+				{Name: fuzzyFlag, Short: fuzzyFlagShort, Description: "Select the worktree interactively", Example: "wt integrate --fuzzy"},
+			},
+			Args: []cli.Argument{
+				{Name: "branch", Description: "Worktree branch name", Type: cli.ArgWorktreeBranch},
+			},
+			Examples: []string{
+				"wt integrate feature-auth          # Rebase, merge, and clean up",
+				"wt integrate feat --fuzzy          # Interactive selection",
+			},
+			SeeAlso: []string{"wt rm", "wt list", "wt new"},
+			Run:     withoutConfig(handleIntegrateCommand),
+		},
+		&cli.Command{
+			Name:        "sync",
+			Group:       groupSmart,
+			Usage:       "wt sync [--all | branch...] [options]",
+			Summary:     "Fetch and rebase worktrees onto the default branch",
+			Description: "Fetch once, then rebase (or merge) clean worktrees onto the updated default branch. Dirty worktrees are skipped, and the first conflict is aborted and stops the run with a summary. Without arguments the current worktree is synced.",
+			Flags: []cli.Flag{
+				{Name: "--all", Short: "-a", Description: "Sync every worktree", Example: "wt sync --all"},
+				{Name: "--merge", Description: "Merge the default branch instead of rebasing (or set settings.sync_strategy: merge)", Example: "wt sync --merge"},
+				{Name: "--rebase", Description: "Rebase onto the default branch (default)", Example: "wt sync --rebase"},
+				{Name: "--upstream", Description: "Sync each branch onto its upstream tracking branch", Example: "wt sync --all --upstream"},
+			},
+			Args: []cli.Argument{
+				{Name: "branch", Description: "Worktree branch name", Type: cli.ArgWorktreeBranch},
+			},
+			Examples: []string{
+				"wt sync                      # Sync the current worktree",
+				"wt sync --all                # Sync every worktree",
+				"wt sync feature-a feature-b  # Sync specific worktrees",
+				"wt sync --all --merge        # Merge instead of rebase",
+				"wt sync --upstream           # Sync onto the branch's upstream",
+			},
+			SeeAlso: []string{"wt integrate", "wt stack", "wt list"},
+			Run:     handleSyncCommand,
+		},
+		&cli.Command{
+			Name:        "stack",
+			Group:       groupSmart,
+			Usage:       "wt stack [subcommand] [branch]",
+			Summary:     "Show and restack dependent branches",
+			Description: "Show and maintain stacked branches. A branch created with 'wt new <branch> --base <parent>' records its parent, so dependent worktrees can be rebased together when the parent changes.",
+			Subcommands: []*cli.Command{
+				{
+					Name:    "restack",
+					Usage:   "wt stack restack [branch]",
+					Summary: "Rebase every descendant of a branch (or all stacks) onto its parent",
+					Args: []cli.Argument{
+						{Name: "branch", Description: "Stack to restack (optional)", Type: cli.ArgWorktreeBranch},
+					},
+				},
+			},
+			Examples: []string{
+				"wt new feature-b --base feature-a  # Stack feature-b on feature-a",
+				"wt stack                           # Show the stack tree",
+				"wt stack restack                   # Rebase all stacked worktrees",
+				"wt stack restack feature-a         # Rebase only branches stacked on feature-a",
+			},
+			SeeAlso: []string{"wt new", "wt integrate"},
+			Run:     withoutConfig(handleStackCommand),
+		},
+		&cli.Command{
+			Name:        "env-copy",
+			Group:       groupUtility,
+			Usage:       "wt env-copy [branch] [options]",
+			Summary:     "Copy .env files to another worktree",
+			Description: "Copy .env files from current directory to target worktree",
+			Flags: []cli.Flag{
+				{Name: "--recursive", Description: "Copy all .env* files recursively", Example: "wt env-copy feature --recursive"},
+				{Name: fuzzyFlag, Short: fuzzyFlagShort, Description: "Force interactive selection of target worktree", Example: "wt env-copy -f"},
+			},
+			Args: []cli.Argument{
+				{Name: "branch", Description: "Target worktree branch", Type: cli.ArgWorktreeBranch},
+			},
+			Examples: []string{
+				"wt env-copy feature          # Copy .env to feature branch worktree",
+				"wt env-copy feat --recursive # Copy all .env* files recursively",
+				"wt env-copy --fuzzy          # Interactive selection of target",
+			},
+			SeeAlso: []string{"wt env", "wt go", "wt list"},
+			Run:     withoutConfig(handleEnvCopyCommand),
+		},
+		&cli.Command{
+			Name:        "env",
+			Group:       groupUtility,
+			Usage:       "wt env <subcommand> [options]",
+			Summary:     "Manage .env files across worktrees",
+			Description: "Unified environment file management across worktrees",
+			Subcommands: []*cli.Command{
+				{
+					Name:    "sync",
+					Usage:   "wt env sync [--all | branch] [options]",
+					Summary: "Copy .env files to target worktree(s)",
+					Flags: []cli.Flag{
+						{Name: "--all", Description: "Apply operation to all worktrees (sync only)", Example: "wt env sync --all"},
+						{Name: "--recursive", Description: "Include all .env* files recursively", Example: "wt env sync feature --recursive"},
+						{Name: fuzzyFlag, Short: fuzzyFlagShort, Description: "Force interactive selection of target worktree", Example: "wt env sync -f"},
+					},
+					Args: []cli.Argument{
+						{Name: "branch", Description: "Target worktree branch", Type: cli.ArgWorktreeBranch},
+					},
+				},
+				{
+					Name:    "diff",
+					Usage:   "wt env diff [branch] [options]",
+					Summary: "Show differences between .env files",
+					Flags: []cli.Flag{
+						{Name: fuzzyFlag, Short: fuzzyFlagShort, Description: "Force interactive selection of target worktree", Example: "wt env diff -f"},
+					},
+					Args: []cli.Argument{
+						{Name: "branch", Description: "Worktree branch to compare with", Type: cli.ArgWorktreeBranch},
+					},
+				},
+				{
+					Name:    "list",
+					Usage:   "wt env list",
+					Summary: "List all .env files across worktrees",
+				},
+			},
+			Examples: []string{
+				"wt env sync feature          # Copy .env files to feature worktree",
+				"wt env sync --all            # Sync .env to all other worktrees",
+				"wt env diff main             # Show differences with main worktree",
+				"wt env list                  # List all .env files across worktrees",
+				"wt env                       # Interactive environment operations",
+			},
+			SeeAlso: []string{"wt env-copy", "wt go", "wt list"},
+			Run:     withoutConfig(handleEnvCommand),
+		},
+		&cli.Command{
+			Name:        "venv",
+			Group:       groupUtility,
+			Usage:       "wt venv gc [options]",
+			Summary:     "Manage shared virtualenvs",
+			Description: "Manage shared virtualenvs. With 'virtualenv.shared: true', 'wt mkvenv' links the worktree's virtualenv to one cached by the hash of its lockfiles (requirements*.txt, uv.lock, poetry.lock), so worktrees with identical dependencies share it. Without a subcommand, 'wt venv' activates the project's virtualenv.",
+			Subcommands: []*cli.Command{
+				{
+					Name:    "gc",
+					Usage:   "wt venv gc [options]",
+					Summary: "Delete shared virtualenvs no worktree links to",
+					Flags: []cli.Flag{
+						{Name: "--dry-run", Short: "-n", Description: "List unused virtualenvs without deleting them", Example: "wt venv gc -n"},
+					},
+				},
+			},
+			Examples: []string{
+				"wt venv gc            # Delete unused shared virtualenvs",
+				"wt venv gc --dry-run  # List what would be deleted",
+			},
+			SeeAlso: []string{"wt project setup"},
+			Run:     handleVenvCommand,
+		},
+		&cli.Command{
+			Name:        "project",
+			Group:       groupUtility,
+			Usage:       "wt project <subcommand> [options]",
+			Summary:     "Project configuration commands",
+			Description: "Manage project configuration for custom commands and settings. Personal configs live in ~/.config/wt/projects; a repository can also commit a .wt.yaml, read from its primary worktree, which personal configs override. Configs can build on shared profiles in ~/.config/wt/profiles with extends and include. Shell commands from a .wt.yaml only run once trusted with 'wt project trust' or at the prompt, and changing them asks again.",
+			Subcommands: []*cli.Command{
+				{
+					Name:    "init",
+					Usage:   "wt project init <name>",
+					Summary: "Initialize project configuration",
+					Args: []cli.Argument{
+						{Name: "name", Description: "Project name", Type: cli.ArgString},
+					},
+				},
+				{Name: "list", Usage: "wt project list", Summary: "List project configs and mark the one for the current directory"},
+				{
+					Name:    "show",
+					Usage:   "wt project show [name] [--resolved]",
+					Summary: "Print a project config (default: the current project)",
+					Flags: []cli.Flag{
+						{Name: "--resolved", Description: "Print it with its profiles and the repository's .wt.yaml merged in"},
+					},
+					Args: []cli.Argument{
+						{Name: "name", Description: "Project name", Type: cli.ArgString},
+					},
+				},
+				{
+					Name:    "edit",
+					Usage:   "wt project edit [name]",
+					Summary: "Edit a project config in $EDITOR and validate it before saving",
+					Args: []cli.Argument{
+						{Name: "name", Description: "Project name", Type: cli.ArgString},
+					},
+				},
+				{
+					Name:    "rm",
+					Usage:   "wt project rm <name> [--yes]",
+					Summary: "Remove a project config",
+					Flags: []cli.Flag{
+						{Name: "--yes", Short: "-y", Description: "Remove without asking"},
+					},
+					Args: []cli.Argument{
+						{Name: "name", Description: "Project name", Type: cli.ArgString},
+					},
+				},
+				{
+					Name:    "which",
+					Usage:   "wt project which [--explain]",
+					Summary: "Show which project config applies to the current directory",
+					Flags: []cli.Flag{
+						{Name: "--explain", Description: "Show how each path and remote pattern was evaluated"},
+					},
+				},
+				{
+					Name:    "trust",
+					Usage:   "wt project trust [--yes]",
+					Summary: "Allow the shell commands in the repository's .wt.yaml",
+					Flags: []cli.Flag{
+						{Name: "--yes", Short: "-y", Description: "Trust without asking"},
+					},
+				},
+				{
+					Name:    "setup",
+					Usage:   "wt project setup <run|show>",
+					Summary: "Manage worktree setup automation",
+					Subcommands: []*cli.Command{
+						{Name: "run", Usage: "wt project setup run", Summary: "Run the setup steps in the current worktree"},
+						{Name: "show", Usage: "wt project setup show", Summary: "Show the configured setup steps"},
+					},
+				},
+				{
+					Name:    "validate",
+					Usage:   "wt project validate [file]",
+					Summary: "Check project configs for unknown fields, wrong types and unsafe paths",
+					Args: []cli.Argument{
+						{Name: "file", Description: "Project config file (default: all configs)", Type: cli.ArgFile},
+					},
+				},
+			},
+			Examples: []string{
+				"wt project init myproject    # Initialize project configuration",
+				"wt project list              # List project configs",
+				"wt project edit myproject    # Edit and validate a project config",
+				"wt project show --resolved   # Show the merged config for this directory",
+				"wt project which --explain   # Debug why a project is (not) detected",
+				"wt project trust             # Allow the commands in the repository's .wt.yaml",
+				"wt project setup run         # Run setup automation for current worktree",
+				"wt project setup show        # Show configured setup steps",
+				"wt project validate          # Validate all project configs",
+				"wt project validate app.yaml # Validate one file",
+			},
+			SeeAlso: []string{"wt new"},
+			Run:     handleProjectCommand,
+		},
+		&cli.Command{
+			Name:        "setup",
+			Group:       groupSetup,
+			Usage:       "wt setup [options]",
+			Summary:     "Install wt to ~/.local/bin with shell completion",
+			Description: "Install wt with shell integration and completion",
+			Flags: []cli.Flag{
+				{Name: "--completion", Description: "Install completion for specified shell (auto|bash|zsh|fish|none)", Example: "wt setup --completion zsh",
+					HasValue: true, ValueName: "shell", Value: cli.ArgChoice, Values: []string{"auto", "bash", "zsh", "fish", completionNone}},
+				{Name: "--no-completion", Description: "Skip completion installation"},
+				{Name: "--check", Description: "Check installation status without installing"},
+				{Name: "--uninstall", Description: "Remove wt from system"},
+			},
+			Examples: []string{
+				"wt setup                     # Install with auto-detected completion",
+				"wt setup --completion bash   # Install with bash completion",
+				"wt setup --no-completion     # Install without completion",
+				"wt setup --check             # Check installation status",
+				"wt setup --uninstall         # Remove wt from system",
+			},
+			SeeAlso: []string{"wt completion", "wt doctor"},
+			Run:     withoutConfig(handleSetupCommand),
+		},
+		&cli.Command{
+			Name:        "doctor",
+			Group:       groupSetup,
+			Usage:       "wt doctor [--fix]",
+			Summary:     "Diagnose the installation, configs and worktrees",
+			Description: "Check the installation, config.yaml and project configs (with line numbers), shell configs that initialize wt more than once, the git version, and, inside a repository, worktrees whose directory is gone or that are checked out outside the <repo>-worktrees/<branch> layout. Every problem comes with a suggested fix.\n\nWith --fix the safe repairs are applied: regenerating stale init.sh and completion files, removing duplicate initialization lines (the original is kept as <file>.wt-backup) and pruning missing worktrees. Moving worktrees and editing configs are left to you. The exit status is 1 while problems remain.",
+			Flags: []cli.Flag{
+				{Name: "--fix", Description: "Apply safe repairs"},
+			},
+			Examples: []string{
+				"wt doctor                    # Report problems and how to fix them",
+				"wt doctor --fix              # Also apply the safe repairs",
+			},
+			SeeAlso: []string{"wt setup"},
+			Run:     handleDoctorCommand,
+		},
+		&cli.Command{
+			Name:        "update",
+			Group:       groupSetup,
+			Usage:       "wt update [options]",
+			Summary:     "Check and install updates",
+			Description: "Check for and install updates from GitHub releases. Versions are compared as semver, so an older release is only installed when pinned with --version. The downloaded archive must match the release's checksums.txt; nothing is installed if verification fails. The new binary must run 'version' and 'shell-init' before it atomically replaces the old one, which is kept for --rollback.\n\nWith --check the exit status is 0 when up to date, 2 when a newer release is available, 3 when the release pinned with --version is older and 1 when the check failed.",
+			Flags: []cli.Flag{
+				{Name: "--check", Description: "Check for updates without installing (exit 2 if one is available)"},
+				{Name: "--force", Description: "Force update even if already on latest version"},
+				{Name: "--channel", Description: "Release channel: stable, or beta to include pre-releases", Example: "--channel beta",
+					HasValue: true, ValueName: "channel", Value: cli.ArgChoice, Values: []string{update.ChannelStable, update.ChannelBeta}},
+				{Name: "--version", Description: "Install a specific release, even an older one", Example: "--version v1.4.2",
+					HasValue: true, ValueName: "tag"},
+				{Name: "--rollback", Description: "Restore the binary replaced by the last update"},
+			},
+			Examples: []string{
+				"wt update                    # Check and install latest version",
+				"wt update --check            # Check for updates without installing",
+				"wt update --force            # Force update even if already latest",
+				"wt update --channel beta     # Include pre-releases",
+				"wt update --version v1.4.2   # Pin or downgrade to a release",
+				"wt update --rollback         # Undo the last update",
+			},
+			SeeAlso: []string{"wt version"},
+			Run:     handleUpdateCommand,
+		},
+		&cli.Command{
+			Name:        "version",
+			Usage:       "wt version",
+			Summary:     "Show version information",
+			Description: "Show version information including build details",
+			Examples: []string{
+				"wt version           # Show current version",
+			},
+			SeeAlso: []string{"wt update"},
+			Run:     withoutConfig(handleVersionCommand),
+		},
+		&cli.Command{
+			Name:    helpCmd,
+			Usage:   "wt help",
+			Summary: "Show help information",
+			Examples: []string{
+				"wt help              # List all commands",
+				"wt go --help         # Show help for one command",
+			},
+			Run: func([]string, *config.Manager) { showUsage() },
+		},
+		&cli.Command{
+			Name:        "completion",
+			Usage:       "wt completion <shell>",
+			Summary:     "Generate shell completion scripts",
+			Description: "Generate shell completion scripts for bash, zsh or fish",
+			Args: []cli.Argument{
+				{Name: "shell", Description: "Shell type", Type: cli.ArgChoice, Values: []string{"bash", "zsh", "fish"}},
+			},
+			Examples: []string{
+				"wt completion bash >> ~/.bashrc     # Install bash completion",
+				"wt completion zsh >> ~/.zshrc       # Install zsh completion",
+				"wt completion fish | source         # Load fish completion in current session",
+				"eval \"$(wt completion bash)\"        # Load completion in current session",
+			},
+			SeeAlso: []string{"wt setup", "wt shell-init"},
+			Run:     handleCompletionCommand,
+		},
+		&cli.Command{
+			Name:        shellInitCmd,
+			Usage:       "wt shell-init [--shell <shell>]",
+			Summary:     "Output shell initialization code",
+			Description: "Output the shell function that lets wt change directories and activate virtualenvs (bash, zsh, fish, nu or POSIX sh; default bash/zsh)",
+			Flags: []cli.Flag{
+				{Name: "--shell", Description: "Shell to generate the wrapper for (bash|zsh|fish|nu|sh)", Example: "wt shell-init --shell sh",
+					HasValue: true, ValueName: "shell", Value: cli.ArgChoice, Values: []string{"bash", "zsh", "fish", "nu", "sh"}},
+			},
+			Args: []cli.Argument{
+				{Name: "shell", Description: "Shell type", Type: cli.ArgChoice, Values: []string{"bash", "zsh", "fish", "nu", "sh"}},
+			},
+			Examples: []string{
+				"source <(wt-bin shell-init)                     # Initialize wt in bash or zsh",
+				"wt-bin shell-init fish | source                 # Initialize wt in fish",
+				"wt-bin shell-init --shell nu | save -f ~/.config/nushell/wt.nu  # Then 'source ~/.config/nushell/wt.nu' in config.nu",
+				"eval \"$(wt-bin shell-init --shell sh)\"         # Initialize wt in dash, ash or busybox sh",
+			},
+			SeeAlso: []string{"wt setup", "wt completion"},
+			Run:     withoutConfig(handleShellInitCommand),
+		},
+		&cli.Command{
+			Name:   completion.CompleteCommand,
+			Hidden: true,
+			Run:    handleCompleteCommand,
+		},
+		&cli.Command{
+			Name:   updateCheckCmd,
+			Hidden: true,
+			Run:    handleUpdateCheckCommand,
+		},
+	)
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/internal/cli"
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/help"
+)
+
+func TestCommandRegistry(t *testing.T) {
+	expectedCommands := []string{
+		"list", "recent", "go", "new", "rm", "integrate", "sync", "stack",
+		"env-copy", "env", "venv", "project", "setup", "update", "version",
+		"help", "completion", "shell-init",
+	}
+	for _, name := range expectedCommands {
+		if commands.Lookup(name) == nil {
+			t.Errorf("Expected command %q to be registered", name)
+		}
+	}
+
+	expectedAliases := map[string]string{"ls": "list", "switch": "go", "s": "go"}
+	for alias, target := range expectedAliases {
+		if got := commands.Resolve(alias); got != target {
+			t.Errorf("Resolve(%q) = %q, want %q", alias, got, target)
+		}
+	}
+
+	if cmd := commands.Lookup("__complete"); cmd == nil || !cmd.Hidden {
+		t.Error("Expected __complete to be registered and hidden")
+	}
+
+	for _, cmd := range commands.Commands() {
+		if cmd.Run == nil {
+			t.Errorf("Command %q has no handler", cmd.Name)
+		}
+		if len(cmd.Examples) == 0 {
+			t.Errorf("Command %q is missing Examples", cmd.Name)
+		}
+	}
+}
+
+func TestCommandHelpCompleteness(t *testing.T) {
+	var check func(path string, cmd *cli.Command)
+	check = func(path string, cmd *cli.Command) {
+		if cmd.Usage == "" {
+			t.Errorf("%q has no Usage", path)
+		}
+		if cmd.Summary == "" {
+			t.Errorf("%q has no Summary", path)
+		}
+		for _, sub := range cmd.Subcommands {
+			if !sub.Hidden {
+				check(path+" "+sub.Name, sub)
+			}
+		}
+	}
+	for _, cmd := range commands.Commands() {
+		check("wt "+cmd.Name, cmd)
+	}
+}
+
+func TestCoreUsageListsRegistry(t *testing.T) {
+	usage := getCoreUsage()
+	// Options wrap across lines, so compare flags without line breaks
+	flat := strings.Join(strings.Fields(usage), " ")
+	for _, cmd := range commands.Commands() {
+		line := "  " + usageLabel(cmd) + " "
+		if !strings.Contains(usage, line) || !strings.Contains(usage, cmd.Summary) {
+			t.Errorf("usage is missing %q with its summary %q", usageLabel(cmd), cmd.Summary)
+		}
+		for _, flag := range cmd.Flags {
+			if !strings.Contains(flat, " "+flag.Name) {
+				t.Errorf("usage is missing flag %s of %q", flag.Name, cmd.Name)
+			}
+		}
+		for _, sub := range cmd.Subcommands {
+			if !strings.Contains(flat, " "+sub.Name) {
+				t.Errorf("usage is missing subcommand %s of %q", sub.Name, cmd.Name)
+			}
+		}
+	}
+	if !strings.Contains(flat, "Options: --check, --force, --channel <channel>, --version <tag>, --rollback") {
+		t.Errorf("update options are not listed from the registry:\n%s", usage)
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	tests := []struct {
+		command       string
+		expectedFlags []string
+	}{
+		{"new", []string{"--base", "--no-switch"}},
+		{"env-copy", []string{"--recursive", "--fuzzy"}},
+		{"setup", []string{"--check", "--uninstall", "--completion", "--no-completion"}},
+		{"update", []string{"--check", "--force"}},
+		{"recent", []string{"-n", "--since", "--verbose", "-v"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			cmd := commands.Lookup(tt.command)
+			if cmd == nil {
+				t.Fatalf("Command %q not found", tt.command)
+			}
+			for _, flag := range tt.expectedFlags {
+				if cmd.FindFlag(flag) == nil {
+					t.Errorf("Command %q missing expected flag %s", tt.command, flag)
+				}
+			}
+		})
+	}
+
+	if flag := commands.Lookup("new").FindFlag("--base"); !flag.HasValue || flag.Value != cli.ArgBranch {
+		t.Errorf("new --base should take a branch, got %+v", flag)
+	}
+}
+
+func TestCommandArguments(t *testing.T) {
+	for _, name := range []string{"rm", "go", "env-copy", "integrate"} {
+		cmd := commands.Lookup(name)
+		if cmd == nil {
+			t.Fatalf("Command %q not found", name)
+		}
+		if len(cmd.Args) == 0 || cmd.Args[0].Type != cli.ArgWorktreeBranch {
+			t.Errorf("Command %q should take a worktree branch, got %+v", name, cmd.Args)
+		}
+	}
+}
+
+func TestCommandHelpPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		wantContain []string
+		wantMissing []string
+	}{
+		{
+			name:    "list command help",
+			command: "list",
+			wantContain: []string{
+				"NAME",
+				"wt list",
+				"List all worktrees",
+				"USAGE",
+				"EXAMPLES",
+				"SEE ALSO",
+			},
+		},
+		{
+			name:    "new command help",
+			command: "new",
+			wantContain: []string{
+				"NAME",
+				"wt new",
+				"Smart worktree creation",
+				"USAGE",
+				"OPTIONS",
+				"EXAMPLES",
+			},
+		},
+		{
+			name:    "go command help",
+			command: "go",
+			wantContain: []string{
+				"NAME",
+				"wt go",
+				"Switch to a worktree",
+				"ALIASES",
+				"OPTIONS",
+				"--fuzzy",
+			},
+		},
+		{
+			name:    "rm command help",
+			command: "rm",
+			wantContain: []string{
+				"NAME",
+				"wt rm",
+				"Remove a worktree",
+				"OPTIONS",
+			},
+		},
+		{
+			name:    "integrate command help",
+			command: "integrate",
+			wantContain: []string{
+				"NAME",
+				"wt integrate",
+				"Rebase a worktree branch",
+				"OPTIONS",
+			},
+		},
+		{
+			name:    "env command help",
+			command: "env",
+			wantContain: []string{
+				"NAME",
+				"wt env",
+				"Unified environment file management",
+				"SUBCOMMANDS",
+				"sync",
+				"diff",
+				"list",
+			},
+		},
+		{
+			name:    "setup command help",
+			command: "setup",
+			wantContain: []string{
+				"NAME",
+				"wt setup",
+				"Install wt with shell integration",
+				"OPTIONS",
+			},
+		},
+		{
+			name:    "project command help",
+			command: "project",
+			wantContain: []string{
+				"NAME",
+				"wt project",
+				"Manage project configuration",
+				"SUBCOMMANDS",
+				"init",
+				"setup",
+			},
+		},
+		{
+			name:    "completion command help",
+			command: "completion",
+			wantContain: []string{
+				"NAME",
+				"wt completion",
+				"Generate shell completion",
+				"USAGE",
+				"bash",
+				"zsh",
+			},
+		},
+		{
+			name:    "update command help",
+			command: "update",
+			wantContain: []string{
+				"NAME",
+				"wt update",
+				"Check for and install updates",
+				"OPTIONS",
+			},
+		},
+		{
+			name:    "version command help",
+			command: "version",
+			wantContain: []string{
+				"NAME",
+				"wt version",
+				"Show version information",
+			},
+		},
+		// Unknown command test removed - it exits and can't be tested this way
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, _ := captureOutput(func() error {
+				help.ShowCommandHelp(tt.command)
+				return nil
+			})
+
+			for _, want := range tt.wantContain {
+				if !strings.Contains(output, want) {
+					t.Errorf("ShowCommandHelp(%q) output missing %q", tt.command, want)
+				}
+			}
+
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(output, missing) {
+					t.Errorf("ShowCommandHelp(%q) output should not contain %q", tt.command, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestSubcommandHelp(t *testing.T) {
+	envHelp := commands.Lookup("env").Help()
+
+	for _, sub := range []string{"sync", "diff", "list"} {
+		found := false
+		for _, line := range envHelp.Subcommands {
+			if strings.HasPrefix(line, sub+" ") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("env help missing subcommand %q in %q", sub, envHelp.Subcommands)
+		}
+	}
+
+	// Subcommand flags are listed on the parent's help page
+	flags := make(map[string]bool)
+	for _, flag := range envHelp.Flags {
+		flags[flag.Flag] = true
+	}
+	for _, flag := range []string{"--all", "--recursive", "--fuzzy"} {
+		if !flags[flag] {
+			t.Errorf("env help missing flag %s", flag)
+		}
+	}
+}
+
+func TestUnknownCommandSuggestions(t *testing.T) {
+	oldExit := osExit
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = oldExit
+	}()
+
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"lsit", "  wt list\n"},
+		{"swich", "  wt switch\n"},
+		{"proj", "  wt project\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			exitCode = -1
+			_, stderr, _ := captureOutput(func() error {
+				runCommand(tt.cmd, nil, &config.Manager{})
+				return nil
+			})
+
+			if exitCode != 1 {
+				t.Errorf("expected exit code 1, got %d", exitCode)
+			}
+			if !strings.Contains(stderr, "Did you mean:") || !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected suggestion %q, got:\n%s", tt.want, stderr)
+			}
+		})
+	}
+
+	_, stderr, _ := captureOutput(func() error {
+		runCommand("zzzzzz", nil, &config.Manager{})
+		return nil
+	})
+	if strings.Contains(stderr, "Did you mean") {
+		t.Errorf("expected no suggestions for an unrelated command, got:\n%s", stderr)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/doctor"
+	"github.com/tobiase/worktree-utils/internal/help"
+	"github.com/tobiase/worktree-utils/internal/worktree"
+)
+
+// handleDoctorCommand diagnoses the installation, configs and worktrees and
+// exits 1 while problems remain
+func handleDoctorCommand(args []string, configMgr *config.Manager) {
+	if help.HasHelpFlag(args, "doctor") {
+		return
+	}
+
+	opts := doctor.Options{
+		ConfigDir:    configMgr.GetConfigDir(),
+		Version:      version,
+		CoreCommands: commands.Names(),
+	}
+	for _, arg := range args {
+		switch arg {
+		case "--fix":
+			opts.Fix = true
+		default:
+			printErrorAndExit("unknown flag '%s' for wt doctor", arg)
+			return
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		printErrorAndExit("failed to get home directory: %v", err)
+		return
+	}
+	opts.HomeDir = homeDir
+
+	// Worktree checks only apply inside a repository
+	if repo, err := worktree.GetRepoRoot(); err == nil {
+		opts.RepoDir = repo
+		if project := configMgr.GetCurrentProject(); project != nil {
+			opts.WorktreeBase = project.Settings.WorktreeBase
+		}
+	}
+
+	if doctor.Run(opts, os.Stdout) > 0 {
+		osExit(1)
+	}
+}
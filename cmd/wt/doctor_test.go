@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	oldExit := osExit
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = oldExit
+	}()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	configMgr, err := config.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, _ := captureOutput(func() error {
+		handleDoctorCommand([]string{"--repair"}, configMgr)
+		return nil
+	})
+	if exitCode != 1 || !strings.Contains(stderr, "unknown flag '--repair' for wt doctor") {
+		t.Errorf("expected an unknown flag error, got exit %d and %q", exitCode, stderr)
+	}
+
+	// A fresh home has no installation, which doctor reports and exits 1 for
+	exitCode = -1
+	stdout, _, _ := captureOutput(func() error {
+		handleDoctorCommand(nil, configMgr)
+		return nil
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 with problems, got %d", exitCode)
+	}
+	for _, want := range []string{"Installation", "no executable wt-bin", "fix: run 'wt setup'"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("doctor output missing %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "Worktrees") {
+		t.Errorf("worktree checks should be skipped outside a repository:\n%s", stdout)
+	}
+}
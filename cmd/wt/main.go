@@ -1,19 +1,24 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/tobiase/worktree-utils/internal/cli"
 	"github.com/tobiase/worktree-utils/internal/completion"
 	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/directive"
 	"github.com/tobiase/worktree-utils/internal/git"
 	"github.com/tobiase/worktree-utils/internal/help"
 	"github.com/tobiase/worktree-utils/internal/interactive"
+	"github.com/tobiase/worktree-utils/internal/runtimes"
 	"github.com/tobiase/worktree-utils/internal/setup"
 	"github.com/tobiase/worktree-utils/internal/update"
 	"github.com/tobiase/worktree-utils/internal/worktree"
@@ -44,56 +49,182 @@ const (
 	helpCmd      = "help"
 )
 
-const shellWrapper = `# Shell function to handle CD: and EXEC: prefixes
+const shellWrapper = `# Shell function that applies wt's directives (cd, exec, setenv, unsetenv)
 wt() {
-  # Commands that need interactive terminal access (no output capture)
-  if [ $# -eq 0 ] || [[ "$*" == *"--fuzzy"* ]] || [[ "$*" == *"-f"* ]]; then
-    # Run interactively, then get CD path separately
-    "${WT_BIN:-wt-bin}" "$@"
-    exit_code=$?
-
-    # If successful and it's a 'go' command, try to get the CD path
-    if [ $exit_code -eq 0 ] && [[ "$1" == "go" || $# -eq 0 ]]; then
-      # Use a separate call to get just the CD path without interaction
-      cd_result=$("${WT_BIN:-wt-bin}" go "$2" 2>/dev/null)
-      if [[ "$cd_result" == "CD:"* ]]; then
-        cd "${cd_result#CD:}"
-      fi
-    fi
-    return $exit_code
-  fi
+  local directive_file exit_code line shell_name=bash
+  [ -n "$ZSH_VERSION" ] && shell_name=zsh
 
-  # Non-interactive commands use output capture
-  output=$("${WT_BIN:-wt-bin}" "$@" 2>&1)
+  # wt writes directives to this file, so its output can stream to the terminal
+  # Without it directives would be lost, so don't run wt at all
+  directive_file=$(mktemp "${TMPDIR:-/tmp}/wt-directive.XXXXXX" 2>/dev/null) || {
+    echo "wt: cannot create a directive file in ${TMPDIR:-/tmp}" >&2
+    return 1
+  }
+
+  WT_SHELL="$shell_name" WT_DIRECTIVE_FILE="$directive_file" "${WT_BIN:-wt-bin}" "$@"
   exit_code=$?
 
   if [ $exit_code -eq 0 ]; then
-    # Check for CD: or EXEC: commands in the output
-    cd_path=""
-    exec_cmd=""
-    while IFS= read -r line; do
-      if [[ "$line" == "CD:"* ]]; then
-        cd_path="${line#CD:}"
-      elif [[ "$line" == "EXEC:"* ]]; then
-        exec_cmd="${line#EXEC:}"
-      else
-        # Print non-command lines (including empty lines)
-        echo "$line"
-      fi
-    done <<< "$output"
-
-    # Execute CD or EXEC commands after printing other output
-    if [ -n "$cd_path" ]; then
-      cd "$cd_path"
-    elif [ -n "$exec_cmd" ]; then
-      # Security note: EXEC commands are only used for virtualenv activation
-      # and paths are quoted by the Go binary to prevent injection
-      eval "$exec_cmd"
-    fi
-  else
-    echo "$output" >&2
+    while IFS= read -r line || [ -n "$line" ]; do
+      case "$line" in
+        "cd "*)
+          cd "${line#cd }"
+          ;;
+        "exec "*)
+          # Security note: exec directives run project-configured commands
+          # (on_enter/on_leave hooks, runtimes, virtualenv activation) verbatim.
+          # Those from a repository's .wt.yaml only run once trusted with
+          # 'wt project trust'; paths wt adds itself are quoted by the Go binary.
+          eval "${line#exec }"
+          ;;
+        "setenv "*)
+          line="${line#setenv }"
+          export "${line%%=*}=${line#*=}"
+          ;;
+        "unsetenv "*)
+          unset "${line#unsetenv }"
+          ;;
+      esac
+    done < "$directive_file"
+  fi
+  rm -f "$directive_file"
+
+  return $exit_code
+}
+`
+
+const fishShellWrapper = `# Shell function that applies wt's directives (cd, exec, setenv, unsetenv)
+function wt --wraps wt-bin --description 'Git worktree management'
+    set -l wt_bin wt-bin
+    if set -q WT_BIN
+        set wt_bin $WT_BIN
+    end
+
+    # wt writes directives to this file, so its output can stream to the terminal
+    set -l tmpdir /tmp
+    if set -q TMPDIR
+        set tmpdir $TMPDIR
+    end
+    set -l directive_file (mktemp "$tmpdir/wt-directive.XXXXXX" 2>/dev/null)
+    # Without it directives would be lost, so don't run wt at all
+    if test -z "$directive_file"
+        echo "wt: cannot create a directive file in $tmpdir" >&2
+        return 1
+    end
+
+    WT_SHELL=fish WT_DIRECTIVE_FILE="$directive_file" $wt_bin $argv
+    set -l exit_code $status
+
+    if test $exit_code -eq 0
+        while read -l line
+            switch $line
+                case 'cd *'
+                    cd (string sub -s 4 -- $line)
+                case 'exec *'
+                    # Security note: exec directives run project-configured commands
+                    # (on_enter/on_leave hooks, runtimes, virtualenv activation) verbatim.
+                    # Those from a repository's .wt.yaml only run once trusted with
+                    # 'wt project trust'; paths wt adds itself are quoted by the Go binary.
+                    eval (string sub -s 6 -- $line)
+                case 'setenv *'
+                    set -l pair (string split -m 1 = -- (string sub -s 8 -- $line))
+                    set -gx $pair[1] $pair[2]
+                case 'unsetenv *'
+                    set -eg (string sub -s 10 -- $line)
+            end
+        end <$directive_file
+    end
+    rm -f $directive_file
+
     return $exit_code
+end
+`
+
+// shWrapper is the POSIX sh variant of shellWrapper for dash, ash and other
+// minimal shells: no [[ ]], here-strings or local variables
+const shWrapper = `# Shell function that applies wt's directives (cd, exec, setenv, unsetenv)
+wt() {
+  # wt writes directives to this file, so its output can stream to the terminal
+  # Without it directives would be lost, so don't run wt at all
+  _wt_file=$(mktemp "${TMPDIR:-/tmp}/wt-directive.XXXXXX" 2>/dev/null) || {
+    echo "wt: cannot create a directive file in ${TMPDIR:-/tmp}" >&2
+    return 1
+  }
+
+  WT_SHELL=sh WT_DIRECTIVE_FILE=$_wt_file "${WT_BIN:-wt-bin}" "$@"
+  _wt_status=$?
+
+  if [ $_wt_status -eq 0 ]; then
+    while IFS= read -r _wt_line || [ -n "$_wt_line" ]; do
+      case "$_wt_line" in
+        "cd "*)
+          cd "${_wt_line#cd }"
+          ;;
+        "exec "*)
+          # Security note: exec directives run project-configured commands
+          # (on_enter/on_leave hooks, runtimes, virtualenv activation) verbatim.
+          # Those from a repository's .wt.yaml only run once trusted with
+          # 'wt project trust'; paths wt adds itself are quoted by the Go binary.
+          eval "${_wt_line#exec }"
+          ;;
+        "setenv "*)
+          _wt_line=${_wt_line#setenv }
+          export "${_wt_line%%=*}=${_wt_line#*=}"
+          ;;
+        "unsetenv "*)
+          unset "${_wt_line#unsetenv }"
+          ;;
+      esac
+    done < "$_wt_file"
   fi
+  rm -f "$_wt_file"
+
+  return $_wt_status
+}
+`
+
+// nuWrapper defines wt as a nushell custom command. Nushell can't eval a
+// string, so exec directives are reported instead of run.
+const nuWrapper = `# Shell function that applies wt's directives (cd, exec, setenv, unsetenv)
+def --env --wrapped wt [...args] {
+    let wt_bin = ($env.WT_BIN? | default "wt-bin")
+
+    # wt writes directives to this file, so its output can stream to the terminal
+    let directive_file = (mktemp --tmpdir wt-directive.XXXXXX)
+
+    let exit_code = (with-env { WT_SHELL: "nu", WT_DIRECTIVE_FILE: $directive_file } {
+        do --ignore-errors { ^$wt_bin ...$args }
+        $env.LAST_EXIT_CODE
+    })
+
+    let directives = (open --raw $directive_file | lines)
+    rm --force $directive_file
+
+    if $exit_code != 0 {
+        error make --unspanned { msg: $"wt exited with status ($exit_code)" }
+    }
+
+    mut cd_path = ""
+    mut env_vars = {}
+    mut unset_vars = []
+    for line in $directives {
+        if ($line | str starts-with "cd ") {
+            $cd_path = ($line | str replace --regex '^cd ' '')
+        } else if ($line | str starts-with "setenv ") {
+            let pair = ($line | str replace --regex '^setenv ' '' | split row --number 2 "=")
+            $env_vars = ($env_vars | upsert $pair.0 ($pair | get --ignore-errors 1 | default ""))
+        } else if ($line | str starts-with "unsetenv ") {
+            $unset_vars = ($unset_vars | append ($line | str replace --regex '^unsetenv ' ''))
+        } else if ($line | str starts-with "exec ") {
+            print --stderr $"wt: nushell can't run '($line | str replace --regex '^exec ' '')'; run it from a POSIX shell"
+        }
+    }
+
+    hide-env --ignore-errors ...$unset_vars
+    load-env $env_vars
+    if $cd_path != "" {
+        cd $cd_path
+    }
 }
 `
 
@@ -115,8 +246,8 @@ func main() {
 
 	// Special handling for numeric commands (direct index access: wt 0, wt 1, etc.)
 	if isNumericCommand(cmd) {
-		handleGoCommand([]string{cmd})
-		return
+		args = []string{cmd}
+		cmd = "go"
 	}
 
 	// Special handling for setup command (doesn't need config)
@@ -129,18 +260,25 @@ func main() {
 	configMgr := initializeConfig()
 	loadProjectConfig(configMgr, cmd)
 	runCommand(cmd, args, configMgr)
+	notifyUpdate(cmd, configMgr)
 }
 
 func resolveCommandAlias(cmd string) string {
-	aliases := map[string]string{
-		"ls":     "list",
-		"switch": "go",
-		"s":      "go",
+	return commands.Resolve(cmd)
+}
+
+// changeDirectory asks the shell wrapper to cd into path
+func changeDirectory(path string) {
+	if err := directive.Cd(path); err != nil {
+		printErrorAndExit("%v", err)
 	}
-	if alias, ok := aliases[cmd]; ok {
-		return alias
+}
+
+// execInShell asks the shell wrapper to evaluate command in the calling shell
+func execInShell(command string) {
+	if err := directive.Exec(command); err != nil {
+		printErrorAndExit("%v", err)
 	}
-	return cmd
 }
 
 // printErrorAndExit prints an error message and exits with status 1
@@ -184,7 +322,9 @@ func initializeConfig() *config.Manager {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "wt: failed to initialize config: %v\n", err)
 		osExit(1)
+		return nil
 	}
+	configMgr.SetCoreCommands(commands.Names())
 	return configMgr
 }
 
@@ -192,43 +332,24 @@ func loadProjectConfig(configMgr *config.Manager, cmd string) {
 	if cmd != shellInitCmd {
 		cwd, _ := os.Getwd()
 		gitRemote, _ := worktree.GetGitRemote()
-		_ = configMgr.LoadProject(cwd, gitRemote)
+		err := configMgr.LoadProject(cwd, gitRemote)
+
+		// A project config with a typo would otherwise silently not apply.
+		// project and doctor report invalid configs themselves.
+		var configErr *config.ConfigError
+		if errors.As(err, &configErr) && !noticeFreeCommands[cmd] && cmd != "project" && cmd != "doctor" {
+			fmt.Fprintf(os.Stderr, "wt: ignoring invalid project config %v\n", err)
+			fmt.Fprintf(os.Stderr, "wt: run 'wt project validate %s' for details\n", configErr.Path)
+		}
 	}
 }
 
 func runCommand(cmd string, args []string, configMgr *config.Manager) {
-	switch cmd {
-	case shellInitCmd:
-		fmt.Print(shellWrapper)
-	case listCmd:
-		handleListCommand(args)
-	case "recent":
-		handleRecentCommand(args)
-	case "rm":
-		handleRemoveCommand(args)
-	case "integrate":
-		handleIntegrateCommand(args)
-	case "go":
-		handleGoCommand(args)
-	case "new":
-		handleNewCommand(args, configMgr)
-	case "env-copy":
-		handleEnvCopyCommand(args)
-	case "env":
-		handleEnvCommand(args)
-	case "project":
-		handleProjectCommand(args, configMgr)
-	case "completion":
-		handleCompletionCommand(args, configMgr)
-	case "version":
-		handleVersionCommand(args)
-	case "update":
-		handleUpdateCommand(args)
-	case helpCmd:
-		showUsage()
-	default:
-		handleCustomCommand(cmd, configMgr)
+	if command := commands.Lookup(cmd); command != nil {
+		command.Run(args, configMgr)
+		return
 	}
+	handleCustomCommand(cmd, configMgr)
 }
 
 // isNumericCommand checks if the command is a numeric index for direct access
@@ -247,7 +368,7 @@ func handleListCommand(args []string) {
 	}
 }
 
-func handleRecentCommand(args []string) {
+func handleRecentCommand(args []string, configMgr *config.Manager) {
 	if help.HasHelpFlag(args, "recent") {
 		return
 	}
@@ -272,14 +393,24 @@ func handleRecentCommand(args []string) {
 	}
 
 	// Collect branch information
-	branchResult := collectBranchInfo(gitClient)
+	var cache *recentCache
+	if !flags.noCache && configMgr != nil {
+		cache = openRecentCache(configMgr.GetConfigDir(), gitClient)
+	}
+	branchResult := collectBranchInfo(gitClient, flags.remote, cache)
+	_ = cache.save()
 	if len(branchResult.branches) == 0 {
 		displayNoBranchesMessage(branchResult.skipped, flags.verbose)
 		return
 	}
 
 	// Update worktree information
-	updateWorktreeInfo(branchResult.branches, gitClient)
+	worktreePaths := updateWorktreeInfo(branchResult.branches, gitClient)
+
+	// Merge status is only needed when filtering on it
+	if flags.merged || flags.unmerged {
+		updateMergedInfo(branchResult.branches, gitClient)
+	}
 
 	// Filter branches based on flags
 	branches := filterBranches(branchResult.branches, flags, currentUserName)
@@ -290,6 +421,12 @@ func handleRecentCommand(args []string) {
 		return
 	}
 
+	// Interactive picker over the filtered list
+	if flags.interactive {
+		selectRecentBranch(branches, worktreePaths, configMgr)
+		return
+	}
+
 	// Display branches
 	if flags.compact {
 		displayBranchesCompact(branches, flags.count)
@@ -401,7 +538,143 @@ func handleIntegrateCommand(args []string) {
 	}
 }
 
-func handleGoCommand(args []string) {
+func handleSyncCommand(args []string, configMgr *config.Manager) {
+	if help.HasHelpFlag(args, "sync") {
+		return
+	}
+
+	opts := worktree.SyncOptions{}
+	if project := configMgr.GetCurrentProject(); project != nil {
+		opts.Strategy = project.Settings.SyncStrategy
+	}
+
+	var targets []string
+	for _, arg := range args {
+		switch arg {
+		case "--all", "-a":
+			opts.All = true
+		case "--merge":
+			opts.Strategy = worktree.SyncMerge
+		case "--rebase":
+			opts.Strategy = worktree.SyncRebase
+		case "--upstream":
+			opts.Upstream = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				printErrorAndExit("unknown flag '%s' for wt sync", arg)
+			}
+			targets = append(targets, arg)
+		}
+	}
+
+	if opts.All && len(targets) > 0 {
+		printErrorAndExit("cannot combine --all with branch names")
+	}
+
+	if len(targets) > 0 {
+		branches, err := worktree.GetAvailableBranches()
+		if err != nil {
+			printErrorAndExit("%v", err)
+		}
+		for _, target := range targets {
+			resolved, err := worktree.ResolveBranchName(target, branches)
+			if err != nil {
+				printErrorAndExit("%v", err)
+			}
+			opts.Branches = append(opts.Branches, resolved)
+		}
+	}
+
+	if err := worktree.Sync(opts); err != nil {
+		printErrorAndExit("%v", err)
+	}
+}
+
+func handleStackCommand(args []string) {
+	if help.HasHelpFlag(args, "stack") {
+		return
+	}
+
+	if len(args) == 0 {
+		if err := worktree.ShowStacks(); err != nil {
+			printErrorAndExit("%v", err)
+		}
+		return
+	}
+
+	subcommand := args[0]
+	subargs := args[1:]
+
+	switch subcommand {
+	case "restack":
+		var target string
+		if len(subargs) > 0 {
+			branches, err := worktree.GetAvailableBranches()
+			if err != nil {
+				printErrorAndExit("%v", err)
+			}
+			target, err = worktree.ResolveBranchName(subargs[0], branches)
+			if err != nil {
+				printErrorAndExit("%v", err)
+			}
+		}
+		if err := worktree.Restack(target); err != nil {
+			printErrorAndExit("%v", err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "wt: unknown stack subcommand '%s'\n", subcommand)
+		fmt.Fprintf(os.Stderr, "Available subcommands: restack\n")
+		fmt.Fprintf(os.Stderr, "Use 'wt stack --help' for detailed help\n")
+		osExit(1)
+	}
+}
+
+// handleVenvCommand runs 'wt venv gc'; plain 'wt venv' stays the project's
+// virtualenv activation command
+func handleVenvCommand(args []string, configMgr *config.Manager) {
+	if len(args) == 0 || args[0] != "gc" {
+		if help.HasHelpFlag(args, "venv") {
+			return
+		}
+		handleCustomCommand("venv", configMgr)
+		return
+	}
+
+	subargs := args[1:]
+	if help.HasHelpFlag(subargs, "venv") {
+		return
+	}
+
+	dryRun := false
+	for _, arg := range subargs {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			printErrorAndExit("unknown flag '%s' for venv gc", arg)
+			return
+		}
+	}
+
+	removed, err := gcSharedVirtualenvs(configMgr.GetConfigDir(), dryRun)
+	if err != nil {
+		printErrorAndExit("%v", err)
+	}
+	if len(removed) == 0 {
+		fmt.Println("No unused shared virtualenvs")
+		return
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	for _, path := range removed {
+		fmt.Printf("%s %s\n", verb, path)
+	}
+}
+
+func handleGoCommand(args []string, configMgr *config.Manager) {
 	if help.HasHelpFlag(args, "go") {
 		return
 	}
@@ -413,7 +686,16 @@ func handleGoCommand(args []string) {
 		fmt.Fprintf(os.Stderr, "wt: %v\n", err)
 		osExit(1)
 	}
-	fmt.Printf("CD:%s", path)
+	from, _ := worktree.GetRepoRoot()
+	before, after := worktreeHooks(from, path, configMgr)
+	for _, command := range before {
+		execInShell(command)
+	}
+	exportWorktreeEnv(path, configMgr)
+	changeDirectory(path)
+	for _, command := range after {
+		execInShell(command)
+	}
 }
 
 // parseGoCommandArgs parses the arguments for the go command
@@ -501,6 +783,8 @@ func handleNewCommand(args []string, configMgr *config.Manager) {
 	}
 
 	branch, baseBranch, noSwitch := parseNewCommandArgs(args)
+	existed := worktree.WorktreeExists(branch)
+	trustRepoCommands(configMgr)
 
 	// Use smart worktree creation - handles all branch states intelligently
 	path, err := worktree.SmartNewWorktree(branch, baseBranch, configMgr)
@@ -512,7 +796,10 @@ func handleNewCommand(args []string, configMgr *config.Manager) {
 	if noSwitch {
 		fmt.Printf("Created worktree at %s\n", path)
 	} else {
-		fmt.Printf("CD:%s", path)
+		changeDirectory(path)
+	}
+	if !existed {
+		createWorktreeRuntimes(path, configMgr)
 	}
 }
 
@@ -761,13 +1048,23 @@ func handleVersionCommand(args []string) {
 
 func handleCustomCommand(cmd string, configMgr *config.Manager) {
 	if navCmd, exists := configMgr.GetCommand(cmd); exists {
-		if navCmd.Type == "virtualenv" {
+		switch navCmd.Type {
+		case "virtualenv":
 			handleVirtualenvCommand(navCmd, configMgr)
-		} else {
+		case "runtime":
+			handleRuntimeCommand(navCmd, configMgr)
+		default:
 			handleNavigationCommand(navCmd)
 		}
 	} else {
 		fmt.Fprintf(os.Stderr, "wt: unknown command '%s'\n", cmd)
+		if suggestions := commands.Suggest(cmd, configMgr.GetCommandNames()...); len(suggestions) > 0 {
+			fmt.Fprintf(os.Stderr, "\nDid you mean:\n")
+			for _, suggestion := range suggestions {
+				fmt.Fprintf(os.Stderr, "  wt %s\n", suggestion)
+			}
+			fmt.Fprintln(os.Stderr)
+		}
 		showUsage()
 		osExit(1)
 	}
@@ -786,7 +1083,7 @@ func handleNavigationCommand(navCmd *config.NavigationCommand) {
 		osExit(1)
 	}
 
-	fmt.Printf("CD:%s", targetPath)
+	changeDirectory(targetPath)
 }
 
 func handleVirtualenvCommand(navCmd *config.NavigationCommand, configMgr *config.Manager) {
@@ -803,38 +1100,26 @@ func handleVirtualenvCommand(navCmd *config.NavigationCommand, configMgr *config
 		return // This will never be reached, but satisfies the linter
 	}
 
-	// Default values
-	venvName := venvConfig.Name
-	if venvName == "" {
-		venvName = ".venv"
-	}
-
 	python := venvConfig.Python
 	if python == "" {
 		python = "python3"
 	}
 
-	venvPath := filepath.Join(repo, venvName)
-
-	// Validate that venvPath is within the repository
-	absRepo, _ := filepath.Abs(repo)
-	absVenvPath, _ := filepath.Abs(venvPath)
-	if !strings.HasPrefix(absVenvPath, absRepo) {
-		printErrorAndExit("invalid virtualenv path: must be within repository")
+	venvPath, err := virtualenvPath(repo, venvConfig)
+	if err != nil {
+		printErrorAndExit("%v", err)
 	}
 
 	switch navCmd.Target {
 	case "activate":
-		// Check if virtualenv exists
-		activateScript := filepath.Join(venvPath, "bin", "activate")
-		if _, err := os.Stat(activateScript); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "wt: virtualenv not found at %s\n", venvPath)
+		activateCmd, err := runtimes.ActivateVenv(venvPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wt: %v\n", err)
 			fmt.Fprintf(os.Stderr, "Run 'wt mkvenv' to create it\n")
 			osExit(1)
 		}
-		// Output EXEC command to activate virtualenv
-		// Use printf with %q to properly quote the path for shell safety
-		fmt.Printf("EXEC:source %q", activateScript)
+		// Ask the shell wrapper to activate the virtualenv
+		execInShell(activateCmd)
 
 	case "create":
 		// Check if virtualenv already exists
@@ -843,6 +1128,14 @@ func handleVirtualenvCommand(navCmd *config.NavigationCommand, configMgr *config
 			osExit(1)
 		}
 
+		if venvConfig.Shared {
+			if err := createSharedVirtualenv(venvPath, repo, python, configMgr.GetConfigDir()); err != nil {
+				printErrorAndExit("%v", err)
+			}
+			fmt.Printf("Linked %s to the shared virtualenv\n", venvPath)
+			return
+		}
+
 		// Create virtualenv
 		fmt.Printf("Creating virtualenv at %s...\n", venvPath)
 		if err := worktree.RunCommand(python, "-m", "venv", venvPath); err != nil {
@@ -852,6 +1145,15 @@ func handleVirtualenvCommand(navCmd *config.NavigationCommand, configMgr *config
 		fmt.Println("Virtualenv created successfully")
 
 	case "remove":
+		// Shared virtualenvs are only unlinked; 'wt venv gc' deletes them
+		if info, err := os.Lstat(venvPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(venvPath); err != nil {
+				printErrorAndExit("failed to unlink virtualenv: %v", err)
+			}
+			fmt.Println("Unlinked shared virtualenv (run 'wt venv gc' to delete unused ones)")
+			return
+		}
+
 		// Check if virtualenv exists
 		if _, err := os.Stat(venvPath); os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "wt: virtualenv not found at %s\n", venvPath)
@@ -872,13 +1174,23 @@ func handleVirtualenvCommand(navCmd *config.NavigationCommand, configMgr *config
 	}
 }
 
+// virtualenvPath returns the configured virtualenv directory inside root,
+// defaulting to .venv when venvConfig is nil or has no name
+func virtualenvPath(root string, venvConfig *config.VirtualenvConfig) (string, error) {
+	name := ""
+	if venvConfig != nil {
+		name = venvConfig.Name
+	}
+	return runtimes.EnvPath(root, name)
+}
+
 func handleProjectCommand(args []string, configMgr *config.Manager) {
 	if help.HasHelpFlag(args, "project") {
 		return
 	}
 
 	if len(args) == 0 {
-		fmt.Fprintf(os.Stderr, "Usage: wt project [init|setup]\n")
+		fmt.Fprintf(os.Stderr, "Usage: wt project [init|list|show|edit|rm|which|trust|setup|validate]\n")
 		fmt.Fprintf(os.Stderr, "Use 'wt project --help' for detailed help\n")
 		osExit(1)
 		return // Needed for testing when osExit is mocked
@@ -936,12 +1248,33 @@ func handleProjectCommand(args []string, configMgr *config.Manager) {
 		fmt.Printf("Project '%s' initialized at %s\n", projectName, cwd)
 		fmt.Printf("Config saved to: %s/projects/%s.yaml\n", configMgr.GetConfigDir(), projectName)
 
+	case "list":
+		handleProjectListCommand(subargs, configMgr)
+
+	case "show":
+		handleProjectShowCommand(subargs, configMgr)
+
+	case "edit":
+		handleProjectEditCommand(subargs, configMgr)
+
+	case "rm":
+		handleProjectRmCommand(subargs, configMgr)
+
+	case "which":
+		handleProjectWhichCommand(subargs, configMgr)
+
+	case "trust":
+		handleProjectTrustCommand(subargs, configMgr)
+
 	case "setup":
 		handleProjectSetupCommand(subargs, configMgr)
 
+	case "validate":
+		handleProjectValidateCommand(subargs, configMgr)
+
 	default:
 		fmt.Fprintf(os.Stderr, "wt: unknown project subcommand '%s'\n", subcmd)
-		fmt.Fprintf(os.Stderr, "Available subcommands: init, setup\n")
+		fmt.Fprintf(os.Stderr, "Available subcommands: init, list, show, edit, rm, which, trust, setup, validate\n")
 		fmt.Fprintf(os.Stderr, "Use 'wt project --help' for detailed help\n")
 		osExit(1)
 	}
@@ -971,8 +1304,67 @@ func handleProjectSetupCommand(args []string, configMgr *config.Manager) {
 	}
 }
 
+// handleProjectValidateCommand checks a project config file, or all of them,
+// printing problems as file:line: message and exiting 1 if there are any
+func handleProjectValidateCommand(args []string, configMgr *config.Manager) {
+	var files []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			printErrorAndExit("unknown flag '%s' for wt project validate", arg)
+			return
+		}
+		files = append(files, arg)
+	}
+	if len(files) > 1 {
+		printErrorAndExit("usage: wt project validate [file]")
+		return
+	}
+
+	if len(files) == 0 {
+		projectsDir := filepath.Join(configMgr.GetConfigDir(), "projects")
+		matches, _ := filepath.Glob(filepath.Join(projectsDir, "*.yaml"))
+		if len(matches) == 0 {
+			fmt.Printf("No project configs in %s\n", projectsDir)
+			return
+		}
+		profiles, _ := filepath.Glob(filepath.Join(configMgr.GetProfilesDir(), "*.yaml"))
+		files = append(matches, profiles...)
+	}
+
+	invalid := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			printErrorAndExit("%v", err)
+			return
+		}
+		errs := validateProjectFile(file, data, configMgr)
+		if len(errs) == 0 {
+			// Also check the profiles it extends and includes
+			if _, err := configMgr.LoadProjectFile(file); err != nil {
+				var configErr *config.ConfigError
+				if errors.As(err, &configErr) {
+					errs = configErr.Errors
+				} else {
+					errs = []config.ValidationError{{Message: err.Error()}}
+				}
+			}
+		}
+		if len(errs) == 0 {
+			fmt.Printf("✓ %s\n", file)
+			continue
+		}
+		invalid++
+		printValidationErrors(os.Stdout, file, errs)
+	}
+	if invalid > 0 {
+		osExit(1)
+	}
+}
+
 func handleProjectSetupRunCommand(args []string, configMgr *config.Manager) {
 	// Get current project configuration
+	trustRepoCommands(configMgr)
 	currentProject := configMgr.GetCurrentProject()
 	if currentProject == nil {
 		fmt.Fprintf(os.Stderr, "wt: no project configuration found for current directory\n")
@@ -1024,6 +1416,7 @@ func handleProjectSetupRunCommand(args []string, configMgr *config.Manager) {
 		fmt.Fprintf(os.Stderr, "wt: setup failed: %v\n", err)
 		osExit(1)
 	}
+	createWorktreeRuntimes(currentWorktreePath, configMgr)
 
 	fmt.Println("Setup completed successfully!")
 }
@@ -1070,6 +1463,14 @@ func handleProjectSetupShowCommand(args []string, configMgr *config.Manager) {
 		}
 		fmt.Println()
 	}
+
+	if currentProject.Setup.CreateRuntimes && len(currentProject.Runtimes) > 0 {
+		fmt.Println("Create runtimes:")
+		for _, rt := range currentProject.Runtimes {
+			fmt.Printf("  - %s\n", rt.Type)
+		}
+		fmt.Println()
+	}
 }
 
 const (
@@ -1132,17 +1533,17 @@ func handleSetupUninstall() {
 
 func handleCompletionOption(args []string, i int, opts *setup.CompletionOptions, installMode *bool) {
 	if i+1 >= len(args) {
-		fmt.Fprintf(os.Stderr, "Error: --completion requires a value (auto|bash|zsh|none)\n")
+		fmt.Fprintf(os.Stderr, "Error: --completion requires a value (auto|bash|zsh|fish|none)\n")
 		showSetupUsage()
 		osExit(1)
 	}
 	completionValue := args[i+1]
 	switch completionValue {
-	case "auto", "bash", "zsh", completionNone:
+	case "auto", "bash", "zsh", "fish", completionNone:
 		opts.Install = completionValue != completionNone
 		opts.Shell = completionValue
 	default:
-		fmt.Fprintf(os.Stderr, "Error: invalid completion option '%s'. Use auto|bash|zsh|none\n", completionValue)
+		fmt.Fprintf(os.Stderr, "Error: invalid completion option '%s'. Use auto|bash|zsh|fish|none\n", completionValue)
 		showSetupUsage()
 		osExit(1)
 	}
@@ -1175,7 +1576,7 @@ func performSetupInstallation(opts setup.CompletionOptions) {
 func showSetupUsage() {
 	fmt.Fprintf(os.Stderr, "Usage: wt setup [options]\n\n")
 	fmt.Fprintf(os.Stderr, "Options:\n")
-	fmt.Fprintf(os.Stderr, "  --completion <shell>   Install completion for specified shell (auto|bash|zsh|none)\n")
+	fmt.Fprintf(os.Stderr, "  --completion <shell>   Install completion for specified shell (auto|bash|zsh|fish|none)\n")
 	fmt.Fprintf(os.Stderr, "  --no-completion        Skip completion installation\n")
 	fmt.Fprintf(os.Stderr, "  --check                Check installation status\n")
 	fmt.Fprintf(os.Stderr, "  --uninstall            Remove wt from system\n\n")
@@ -1191,46 +1592,91 @@ func showUsage() {
 	printProjectCommands()
 }
 
+// getCoreUsage lists the registered commands by group with their summary,
+// flags and subcommands, so 'wt --help' can't fall behind the registry
 func getCoreUsage() string {
-	return `Usage: wt <command> [arguments]
+	var b strings.Builder
+	b.WriteString(`Usage: wt <command> [arguments]
 
 Quick access:
   wt 0, wt 1, wt 2    Quick switch to worktree by index (shortcut for 'wt go 0')
   --fuzzy, -f         Force interactive selection for branch/worktree arguments
+`)
+
+	groups := map[string][]*cli.Command{}
+	order := []string{groupSmart, groupUtility, groupSetup, groupOther}
+	width := len("wt 0, wt 1, wt 2  ") // Line up with the quick access entries
+	for _, cmd := range commands.Commands() {
+		group := cmd.Group
+		if group == "" {
+			group = groupOther
+		}
+		groups[group] = append(groups[group], cmd)
+		width = max(width, len(usageLabel(cmd)))
+	}
+
+	indent := strings.Repeat(" ", width+4)
+	for _, group := range order {
+		if len(groups[group]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", group)
+		for _, cmd := range groups[group] {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, usageLabel(cmd), cmd.Summary)
+
+			var flags []string
+			for _, flag := range cmd.Flags {
+				name := flag.Name
+				if flag.HasValue && flag.ValueName != "" {
+					name += " <" + flag.ValueName + ">"
+				}
+				flags = append(flags, name)
+				if flag.Short != "" {
+					flags = append(flags, flag.Short)
+				}
+			}
+			writeUsageList(&b, indent, "Options: ", flags)
+
+			var subcommands []string
+			for _, sub := range cmd.Subcommands {
+				subcommands = append(subcommands, sub.Name)
+			}
+			writeUsageList(&b, indent, "Subcommands: ", subcommands)
+		}
+	}
+	b.WriteString("\nRun 'wt <command> --help' for details on a command.\n")
+	return b.String()
+}
 
-Smart commands (with fuzzy branch matching):
-  list, ls            List all worktrees
-  recent              Show YOUR recently active branches (default: your branches only)
-                      Navigate directly: 'wt recent 2' → go to your 3rd recent branch
-                      Default: multi-line format for better readability
-                      Options: --all, --others, -n <count>, --compact, --verbose
-  new <branch>        Smart worktree creation - handles all branch states:
-                      • Branch doesn't exist → Create branch + worktree + switch
-                      • Branch exists, no worktree → Create worktree + switch
-                      • Branch + worktree exist → Just switch
-                      Options: --base <branch>, --no-switch
-  go, switch, s       Switch to a worktree (no args = repo root)
-                      Supports fuzzy matching: 'wt go mai' → switches to 'main'
-                      Options: --fuzzy, -f (force interactive selection)
-  rm <branch>         Remove a worktree (supports fuzzy matching)
-                      Options: --fuzzy, -f (force interactive selection)
-
-Utility commands:
-  env <subcommand>    Unified environment file management
-                      Subcommands: sync, diff, list
-                      Options: --all, --fuzzy, -f, --recursive
-  project init <name> Initialize project configuration
-
-Setup commands:
-  setup               Install wt to ~/.local/bin with shell completion
-                      Options: --completion <shell>, --no-completion, --check, --uninstall
-  update              Check and install updates
-                      Options: --check, --force
-
-Other commands:
-  ` + shellInitCmd + `          Output shell initialization code
-  completion <shell>  Generate shell completion scripts (bash|zsh)
-  version             Show version information`
+// usageLabel is a command's name followed by its aliases, e.g. "go, switch, s"
+func usageLabel(cmd *cli.Command) string {
+	return strings.Join(append([]string{cmd.Name}, cmd.Aliases...), ", ")
+}
+
+// writeUsageList writes items after label, comma separated and wrapped to 80
+// columns with continuation lines aligned under the first item
+func writeUsageList(b *strings.Builder, indent, label string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	const columns = 80
+	line := indent + label
+	continuation := indent + strings.Repeat(" ", len(label))
+	for i, item := range items {
+		if i < len(items)-1 {
+			item += ","
+		}
+		if i > 0 && len(line)+1+len(item) > columns {
+			b.WriteString(line + "\n")
+			line = continuation + item
+			continue
+		}
+		if i > 0 {
+			line += " "
+		}
+		line += item
+	}
+	b.WriteString(line + "\n")
 }
 
 func printProjectCommands() {
@@ -1238,6 +1684,7 @@ func printProjectCommands() {
 	if err != nil {
 		return
 	}
+	configMgr.SetCoreCommands(commands.Names())
 
 	cwd, _ := os.Getwd()
 	gitRemote, _ := worktree.GetGitRemote()
@@ -1256,7 +1703,7 @@ func categorizeProjectCommands(commands map[string]config.NavigationCommand) ([]
 	var navCommands, venvCommands []string
 
 	for name, cmd := range commands {
-		if cmd.Type == "virtualenv" {
+		if cmd.Type == "virtualenv" || cmd.Type == "runtime" {
 			venvCommands = append(venvCommands, name)
 		} else {
 			navCommands = append(navCommands, name)
@@ -1274,6 +1721,7 @@ func printCommandCategories(navCommands, venvCommands []string, projectName stri
 	if err != nil {
 		return
 	}
+	configMgr.SetCoreCommands(commands.Names())
 
 	cwd, _ := os.Getwd()
 	gitRemote, _ := worktree.GetGitRemote()
@@ -1295,7 +1743,7 @@ func printCommandCategories(navCommands, venvCommands []string, projectName stri
 
 	// Show virtualenv commands
 	if len(venvCommands) > 0 {
-		fmt.Fprintf(os.Stderr, "\n\nProject '%s' virtualenv:", projectName)
+		fmt.Fprintf(os.Stderr, "\n\nProject '%s' environment:", projectName)
 		for _, name := range venvCommands {
 			cmd := project.Commands[name]
 			fmt.Fprintf(os.Stderr, "\n  %-18s %s", name, cmd.Description)
@@ -1305,19 +1753,62 @@ func printCommandCategories(navCommands, venvCommands []string, projectName stri
 	fmt.Fprintln(os.Stderr)
 }
 
+func handleShellInitCommand(args []string) {
+	if help.HasHelpFlag(args, shellInitCmd) {
+		return
+	}
+
+	shell := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--shell":
+			if i+1 >= len(args) {
+				printErrorAndExit("--shell requires a value (bash|zsh|fish|nu|sh)")
+				return
+			}
+			i++
+			shell = args[i]
+		case strings.HasPrefix(args[i], "--shell="):
+			shell = strings.TrimPrefix(args[i], "--shell=")
+		case strings.HasPrefix(args[i], "-"):
+			printErrorAndExit("unknown flag '%s' for wt shell-init", args[i])
+			return
+		default:
+			shell = args[i]
+		}
+	}
+
+	switch shell {
+	case "", "bash", "zsh":
+		fmt.Print(shellWrapper)
+	case "fish":
+		fmt.Print(fishShellWrapper)
+	case "nu", "nushell":
+		fmt.Print(nuWrapper)
+	case "sh", "dash", "ash":
+		fmt.Printf("%s", shWrapper)
+	default:
+		fmt.Fprintf(os.Stderr, "wt: unsupported shell '%s'\n", shell)
+		fmt.Fprintf(os.Stderr, "Supported shells: bash, zsh, fish, nu, sh\n")
+		osExit(1)
+	}
+}
+
 func handleCompletionCommand(args []string, configMgr *config.Manager) {
 	if help.HasHelpFlag(args, "completion") {
 		return
 	}
 
 	if len(args) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: wt completion <bash|zsh>\n")
+		fmt.Fprintf(os.Stderr, "Usage: wt completion <bash|zsh|fish>\n")
 		fmt.Fprintf(os.Stderr, "\nGenerate shell completion scripts for wt.\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
 		fmt.Fprintf(os.Stderr, "  # Install bash completion\n")
 		fmt.Fprintf(os.Stderr, "  wt completion bash >> ~/.bashrc\n\n")
 		fmt.Fprintf(os.Stderr, "  # Install zsh completion\n")
 		fmt.Fprintf(os.Stderr, "  wt completion zsh >> ~/.zshrc\n\n")
+		fmt.Fprintf(os.Stderr, "  # Install fish completion\n")
+		fmt.Fprintf(os.Stderr, "  wt completion fish > ~/.config/fish/completions/wt.fish\n\n")
 		fmt.Fprintf(os.Stderr, "  # Or use with eval\n")
 		fmt.Fprintf(os.Stderr, "  eval \"$(wt completion bash)\"\n")
 		osExit(1)
@@ -1326,67 +1817,208 @@ func handleCompletionCommand(args []string, configMgr *config.Manager) {
 	shell := args[0]
 	switch shell {
 	case "bash":
-		fmt.Print(completion.GenerateBashCompletion(configMgr))
+		fmt.Print(completion.GenerateBashCompletion())
 	case "zsh":
-		fmt.Print(completion.GenerateZshCompletion(configMgr))
+		fmt.Print(completion.GenerateZshCompletion())
+	case "fish":
+		fmt.Print(completion.GenerateFishCompletion())
 	default:
 		fmt.Fprintf(os.Stderr, "wt: unsupported shell '%s'\n", shell)
-		fmt.Fprintf(os.Stderr, "Supported shells: bash, zsh\n")
+		fmt.Fprintf(os.Stderr, "Supported shells: bash, zsh, fish\n")
 		osExit(1)
 	}
 }
 
-func handleUpdateCommand(args []string) {
-	if help.HasHelpFlag(args, "update") {
-		return
+// handleCompleteCommand prints completion candidates for the words after 'wt',
+// one per line as value<TAB>description. It backs the generated shell
+// completion scripts and is not listed in help.
+func handleCompleteCommand(args []string, configMgr *config.Manager) {
+	data := completion.GetCompletionData(commands, configMgr)
+	for _, candidate := range data.Complete(args) {
+		fmt.Println(candidate.String())
 	}
+}
 
-	// Parse flags
-	checkOnly := false
-	force := false
+// Exit codes of "wt update --check" besides 0 for up to date, for scripts
+const (
+	updateCheckFailed = 1
+	updateCheckNewer  = 2
+	updateCheckOlder  = 3 // A --version pinned below the current version
+)
 
-	for _, arg := range args {
-		switch arg {
-		case "--check":
-			checkOnly = true
-		case forceFlag:
-			force = true
-		case helpFlag, helpFlagShort:
+// updateFlags holds the parsed flags of the update command
+type updateFlags struct {
+	checkOnly bool
+	force     bool
+	rollback  bool
+	channel   string
+	version   string
+}
+
+// parseUpdateFlags parses command line flags for the update command
+func parseUpdateFlags(args []string) updateFlags {
+	flags := updateFlags{channel: update.ChannelStable}
+
+	i := 0
+	for i < len(args) {
+		arg := args[i]
+		switch {
+		case arg == "--check":
+			flags.checkOnly = true
+			i++
+		case arg == forceFlag:
+			flags.force = true
+			i++
+		case arg == "--rollback":
+			flags.rollback = true
+			i++
+		case isValueFlag(arg, "--channel"), isValueFlag(arg, "--version"):
+			name, value, consumed := splitValueFlag(args, i)
+			if name == "--channel" {
+				flags.channel = value
+			} else {
+				flags.version = value
+			}
+			i += consumed
+		case arg == helpFlag, arg == helpFlagShort:
 			// Skip help flags - they're handled separately
-			continue
+			i++
+		default:
+			printErrorAndExit("unknown update option: %s", arg)
+			i++
 		}
 	}
 
-	fmt.Printf("Current version: %s\n", version)
+	if flags.channel != update.ChannelStable && flags.channel != update.ChannelBeta {
+		printErrorAndExit("invalid --channel %q (use %s or %s)", flags.channel, update.ChannelStable, update.ChannelBeta)
+	}
+	if flags.version != "" && hasFlag(args, "--channel") {
+		printErrorAndExit("cannot use --channel and --version together")
+	}
+	if flags.rollback && (flags.checkOnly || flags.force || flags.version != "" || hasFlag(args, "--channel")) {
+		printErrorAndExit("--rollback cannot be combined with other update options")
+	}
+
+	return flags
+}
 
-	// Check for updates
-	release, hasUpdate, err := update.CheckForUpdate(version)
+// hasFlag reports whether args contain the value flag name in either form
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if isValueFlag(arg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// configureUpdates applies the release source, proxy and CA bundle from the
+// global config to the update package
+func configureUpdates(configMgr *config.Manager) error {
+	cfg, err := configMgr.LoadGlobalConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "wt: failed to check for updates: %v\n", err)
-		osExit(1)
+		return err
+	}
+	settings := cfg.Update
+	return update.Configure(update.Options{
+		Source:   settings.Source.Type,
+		URL:      settings.Source.URL,
+		Repo:     settings.Source.Repo,
+		Proxy:    settings.Proxy,
+		CABundle: settings.CABundle,
+	})
+}
+
+func handleUpdateCommand(args []string, configMgr *config.Manager) {
+	if help.HasHelpFlag(args, "update") {
+		return
 	}
 
-	fmt.Printf("Latest version: %s\n", release.TagName)
+	flags := parseUpdateFlags(args)
 
-	if !hasUpdate && !force {
-		fmt.Println("\nYou are already on the latest version!")
+	if flags.rollback {
+		restored, err := update.Rollback()
+		if err != nil {
+			printErrorAndExit("rollback failed: %v", err)
+			return
+		}
+		fmt.Printf("✓ Rolled back to the previous binary (%s)\n", restored)
+		return
+	}
+
+	if err := configureUpdates(configMgr); err != nil {
+		printErrorAndExit("invalid update settings: %v", err)
 		return
 	}
 
-	if checkOnly {
-		if hasUpdate {
+	fmt.Printf("Current version: %s\n", version)
+
+	var release *update.Release
+	var hasUpdate bool
+	var err error
+	if flags.version != "" {
+		// A pinned version is installed whether it is newer or older
+		release, err = update.GetRelease(flags.version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wt: failed to find release %s: %v\n", flags.version, err)
+			osExit(updateCheckFailed)
+			return
+		}
+		hasUpdate = !sameVersion(release.TagName, version)
+		fmt.Printf("Requested version: %s\n", release.TagName)
+	} else {
+		release, hasUpdate, err = update.CheckChannel(version, flags.channel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wt: failed to check for updates: %v\n", err)
+			osExit(updateCheckFailed)
+			return
+		}
+		if flags.channel == update.ChannelStable {
+			fmt.Printf("Latest version: %s\n", release.TagName)
+		} else {
+			fmt.Printf("Latest %s version: %s\n", flags.channel, release.TagName)
+		}
+	}
+
+	upToDate := "\nYou are already on the latest version!"
+	if flags.version != "" {
+		upToDate = "\nAlready on " + release.TagName
+	}
+
+	if flags.checkOnly {
+		switch updateCheckStatus(release.TagName, version, hasUpdate) {
+		case 0:
+			fmt.Println(upToDate)
+		case updateCheckOlder:
+			fmt.Printf("\nDowngrade available: %s is older than %s\n", release.TagName, version)
+			osExit(updateCheckOlder)
+		default:
 			fmt.Printf("\nUpdate available: %s\n", release.TagName)
-			fmt.Println("\nChanges:")
-			fmt.Println(release.Body)
+			if release.Body != "" {
+				fmt.Println("\nChanges:")
+				fmt.Println(release.Body)
+			}
+			osExit(updateCheckNewer)
 		}
 		return
 	}
 
-	// Download and install update
-	fmt.Println("\nDownloading update...")
+	if !hasUpdate && !flags.force {
+		fmt.Println(upToDate)
+		return
+	}
+
+	if flags.version != "" && isOlderVersion(release.TagName, version) {
+		fmt.Printf("\nDowngrading to %s...\n", release.TagName)
+	} else {
+		fmt.Println("\nDownloading update...")
+	}
 
 	var lastProgress int
 	err = update.DownloadAndInstall(release, func(downloaded, total int64) {
+		if total <= 0 {
+			return // Size unknown
+		}
 		progress := int(float64(downloaded) / float64(total) * 100)
 		if progress != lastProgress && progress%10 == 0 {
 			fmt.Printf("\rProgress: %d%%", progress)
@@ -1409,6 +2041,37 @@ func handleUpdateCommand(args []string) {
 	}
 }
 
+// updateCheckStatus returns the exit status of 'wt update --check' for a
+// release differing from current when hasUpdate is set: 0 when it doesn't,
+// updateCheckOlder for an older pinned release and updateCheckNewer otherwise
+func updateCheckStatus(tag, current string, hasUpdate bool) int {
+	switch {
+	case !hasUpdate:
+		return 0
+	case isOlderVersion(tag, current):
+		return updateCheckOlder
+	default:
+		return updateCheckNewer
+	}
+}
+
+// sameVersion reports whether two version strings name the same release
+func sameVersion(a, b string) bool {
+	va, errA := update.ParseVersion(a)
+	vb, errB := update.ParseVersion(b)
+	if errA != nil || errB != nil {
+		return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+	}
+	return va.Compare(vb) == 0
+}
+
+// isOlderVersion reports whether tag is older than current
+func isOlderVersion(tag, current string) bool {
+	vt, errT := update.ParseVersion(tag)
+	vc, errC := update.ParseVersion(current)
+	return errT == nil && errC == nil && vt.Compare(vc) < 0
+}
+
 // recentFlags holds parsed flags for the recent command
 type recentFlags struct {
 	showOthers    bool
@@ -1417,6 +2080,19 @@ type recentFlags struct {
 	navigateIndex int
 	verbose       bool
 	compact       bool
+	interactive   bool
+
+	// Filters, all optional and composable
+	since           time.Time
+	until           time.Time
+	grep            *regexp.Regexp
+	merged          bool
+	unmerged        bool
+	withWorktree    bool
+	withoutWorktree bool
+	remote          bool
+
+	noCache bool
 }
 
 // parseRecentFlags parses command line flags for the recent command
@@ -1442,6 +2118,31 @@ func parseRecentFlags(args []string) recentFlags {
 		case arg == "--compact" || arg == "-c":
 			flags.compact = true
 			i++
+		case arg == "--interactive" || arg == "-i":
+			flags.interactive = true
+			i++
+		case arg == "--merged":
+			flags.merged = true
+			i++
+		case arg == "--unmerged":
+			flags.unmerged = true
+			i++
+		case arg == "--with-worktree":
+			flags.withWorktree = true
+			i++
+		case arg == "--without-worktree":
+			flags.withoutWorktree = true
+			i++
+		case arg == "--remote":
+			flags.remote = true
+			i++
+		case arg == "--no-cache":
+			flags.noCache = true
+			i++
+		case isValueFlag(arg, "--since"), isValueFlag(arg, "--until"), isValueFlag(arg, "--grep"):
+			name, value, consumed := splitValueFlag(args, i)
+			applyRecentValueFlag(&flags, name, value)
+			i += consumed
 		case arg == "-n" && i+1 < len(args):
 			flags.count = parseAndValidateCount(args[i+1])
 			i += 2
@@ -1462,10 +2163,93 @@ func parseRecentFlags(args []string) recentFlags {
 	if flags.showOthers && flags.showAll {
 		printErrorAndExit("cannot use --others and --all together")
 	}
+	if flags.interactive && flags.navigateIndex >= 0 {
+		printErrorAndExit("cannot use --interactive with a navigation index")
+	}
+	if flags.merged && flags.unmerged {
+		printErrorAndExit("cannot use --merged and --unmerged together")
+	}
+	if flags.withWorktree && flags.withoutWorktree {
+		printErrorAndExit("cannot use --with-worktree and --without-worktree together")
+	}
+	if !flags.since.IsZero() && !flags.until.IsZero() && flags.since.After(flags.until) {
+		printErrorAndExit("--since must be earlier than --until")
+	}
 
 	return flags
 }
 
+// isValueFlag reports whether arg is the given flag, in "--flag value" or "--flag=value" form
+func isValueFlag(arg, name string) bool {
+	return arg == name || strings.HasPrefix(arg, name+"=")
+}
+
+// splitValueFlag returns the flag name and value at args[i] and how many args it used
+func splitValueFlag(args []string, i int) (name, value string, consumed int) {
+	if name, value, found := strings.Cut(args[i], "="); found {
+		return name, value, 1
+	}
+	if i+1 >= len(args) {
+		printErrorAndExit("%s requires a value", args[i])
+		return args[i], "", 1
+	}
+	return args[i], args[i+1], 2
+}
+
+// applyRecentValueFlag parses the value of a filter flag into flags
+func applyRecentValueFlag(flags *recentFlags, name, value string) {
+	switch name {
+	case "--since", "--until":
+		t, err := parseTimeFilter(value, time.Now())
+		if err != nil {
+			printErrorAndExit("invalid %s value: %v", name, err)
+			return
+		}
+		if name == "--since" {
+			flags.since = t
+		} else {
+			flags.until = t
+		}
+	case "--grep":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			printErrorAndExit("invalid --grep pattern: %v", err)
+			return
+		}
+		flags.grep = re
+	}
+}
+
+var relativeTimePattern = regexp.MustCompile(`^(\d+)(h|d|w|mo|y)$`)
+
+// parseTimeFilter parses a relative age such as 12h, 3d, 2w, 6mo or 1y, or an
+// absolute date (2006-01-02 or RFC 3339) into a point in time
+func parseTimeFilter(value string, now time.Time) (time.Time, error) {
+	if m := relativeTimePattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "h":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, -n), nil
+		case "w":
+			return now.AddDate(0, 0, -7*n), nil
+		case "mo":
+			return now.AddDate(0, -n, 0), nil
+		case "y":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q (use e.g. 12h, 3d, 2w, 6mo, 1y or YYYY-MM-DD)", value)
+}
+
 // parseAndValidateCount parses and validates a count value
 func parseAndValidateCount(countStr string) int {
 	n, err := strconv.Atoi(countStr)
@@ -1495,6 +2279,9 @@ type branchCommitInfo struct {
 	author       string
 	timestamp    time.Time
 	hasWorktree  bool
+	worktreePath string
+	isRemote     bool
+	merged       bool
 }
 
 // skippedBranchInfo holds information about why a branch was skipped
@@ -1510,10 +2297,21 @@ type branchCollectionResult struct {
 	totalProcessed int
 }
 
-// collectBranchInfo collects commit information for all branches
-func collectBranchInfo(gitClient git.Client) branchCollectionResult {
-	// Get all branches first
-	branchesOutput, err := gitClient.ForEachRef("%(refname:short)", "refs/heads/")
+// branchRefFormat collects everything `wt recent` needs in one for-each-ref pass.
+// Fields are separated by the ASCII unit separator so subjects can contain any text.
+const branchRefFormat = "%(refname)%1f%(objectname)%1f%(parent)%1f%(committerdate:relative)%1f%(committerdate:unix)%1f%(subject)%1f%(authorname)"
+
+const branchRefFields = 7
+
+// collectBranchInfo collects commit information for all branches. Branch tips are read
+// in a single for-each-ref call; only branches whose tip is a merge commit need a
+// per-branch lookup of their last non-merge commit, which the cache (if any) remembers.
+func collectBranchInfo(gitClient git.Client, includeRemote bool, cache *recentCache) branchCollectionResult {
+	refPatterns := []string{"refs/heads/"}
+	if includeRemote {
+		refPatterns = append(refPatterns, "refs/remotes/")
+	}
+	refsOutput, err := gitClient.ForEachRef(branchRefFormat, refPatterns...)
 	if err != nil {
 		printErrorAndExit("failed to get branches: %v", err)
 	}
@@ -1523,71 +2321,54 @@ func collectBranchInfo(gitClient git.Client) branchCollectionResult {
 		skipped:  make([]skippedBranchInfo, 0),
 	}
 
-	if branchesOutput == "" {
+	if refsOutput == "" {
 		return result
 	}
 
-	// Parse branch names
-	branchNames := strings.Split(branchesOutput, "\n")
-	branchInfos := make([]branchCommitInfo, 0, len(branchNames))
-
-	// Format for the commit info - include unix timestamp for sorting
-	commitFormat := "%H|%cr|%s|%an|%ct"
+	refLines := strings.Split(refsOutput, "\n")
+	branchInfos := make([]branchCommitInfo, 0, len(refLines))
 
-	for _, branch := range branchNames {
-		branch = strings.TrimSpace(branch)
-		if branch == "" {
+	for _, line := range refLines {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != branchRefFields {
 			continue
 		}
 
-		result.totalProcessed++
-
-		// Get last non-merge commit info
-		commitInfo, err := gitClient.GetLastNonMergeCommit(branch, commitFormat)
-		if err != nil {
-			result.skipped = append(result.skipped, skippedBranchInfo{
-				branch: branch,
-				reason: fmt.Sprintf("git command failed: %v", err),
-			})
+		ref := fields[0]
+		if strings.HasSuffix(ref, "/HEAD") {
 			continue
 		}
+		isRemote := strings.HasPrefix(ref, "refs/remotes/")
+		branch := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/remotes/")
 
-		if commitInfo == "" {
-			result.skipped = append(result.skipped, skippedBranchInfo{
-				branch: branch,
-				reason: "no non-merge commits found",
-			})
-			continue
-		}
+		result.totalProcessed++
 
-		// Parse commit info
-		parts := strings.Split(commitInfo, "|")
-		if len(parts) != 5 {
-			result.skipped = append(result.skipped, skippedBranchInfo{
-				branch: branch,
-				reason: fmt.Sprintf("invalid commit info format: expected 5 parts, got %d", len(parts)),
-			})
-			continue
-		}
+		info := branchCommitInfo{branch: branch, isRemote: isRemote}
+		tip := fields[1]
 
-		// Parse unix timestamp
-		unixTime, err := strconv.ParseInt(parts[4], 10, 64)
-		if err != nil {
-			result.skipped = append(result.skipped, skippedBranchInfo{
-				branch: branch,
-				reason: fmt.Sprintf("invalid timestamp: %v", err),
-			})
-			continue
+		if len(strings.Fields(fields[2])) > 1 {
+			// Merge tip: look further back for the last non-merge commit
+			if err := fillFromLastNonMergeCommit(&info, gitClient, tip, cache); err != nil {
+				result.skipped = append(result.skipped, skippedBranchInfo{branch: branch, reason: err.Error()})
+				continue
+			}
+		} else {
+			unixTime, err := strconv.ParseInt(fields[4], 10, 64)
+			if err != nil {
+				result.skipped = append(result.skipped, skippedBranchInfo{
+					branch: branch,
+					reason: fmt.Sprintf("invalid timestamp: %v", err),
+				})
+				continue
+			}
+			info.commitHash = tip
+			info.relativeDate = fields[3]
+			info.subject = fields[5]
+			info.author = fields[6]
+			info.timestamp = time.Unix(unixTime, 0)
 		}
 
-		branchInfos = append(branchInfos, branchCommitInfo{
-			branch:       branch,
-			commitHash:   parts[0],
-			relativeDate: parts[1],
-			subject:      parts[2],
-			author:       parts[3],
-			timestamp:    time.Unix(unixTime, 0),
-		})
+		branchInfos = append(branchInfos, info)
 	}
 
 	// Sort by commit timestamp (most recent first)
@@ -1599,30 +2380,124 @@ func collectBranchInfo(gitClient git.Client) branchCollectionResult {
 	return result
 }
 
-// updateWorktreeInfo updates branch info with worktree status
-func updateWorktreeInfo(branchInfos []branchCommitInfo, gitClient git.Client) {
-	// Get worktree list to check which branches have worktrees
+// fillFromLastNonMergeCommit fills info from the last non-merge commit reachable from tip
+func fillFromLastNonMergeCommit(info *branchCommitInfo, gitClient git.Client, tip string, cache *recentCache) error {
+	if entry, ok := cache.get(tip); ok {
+		entry.apply(info, time.Now())
+		return nil
+	}
+
+	commitInfo, err := gitClient.GetLastNonMergeCommit(tip, "%H|%ct|%an|%s")
+	if err != nil {
+		return fmt.Errorf("git command failed: %v", err)
+	}
+	if commitInfo == "" {
+		return fmt.Errorf("no non-merge commits found")
+	}
+
+	// Subject goes last so it may contain the separator
+	parts := strings.SplitN(commitInfo, "|", 4)
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid commit info format: expected 4 parts, got %d", len(parts))
+	}
+	unixTime, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %v", err)
+	}
+
+	entry := recentCacheEntry{Hash: parts[0], Timestamp: unixTime, Author: parts[2], Subject: parts[3]}
+	cache.put(tip, entry)
+	entry.apply(info, time.Now())
+	return nil
+}
+
+// updateWorktreeInfo updates branch info with worktree status and returns the
+// worktree path of every checked out branch
+func updateWorktreeInfo(branchInfos []branchCommitInfo, gitClient git.Client) map[string]string {
+	// Get worktree list once to check which branches have worktrees
 	worktrees, err := gitClient.WorktreeList()
 	if err != nil {
 		printErrorAndExit("failed to get worktrees: %v", err)
 	}
 
-	// Create a map of branches that have worktrees
-	worktreeBranches := make(map[string]bool)
+	worktreePaths := make(map[string]string, len(worktrees))
 	for _, wt := range worktrees {
-		worktreeBranches[wt.Branch] = true
+		if wt.Branch != "" {
+			worktreePaths[wt.Branch] = wt.Path
+		}
+	}
+
+	// Update worktree fields
+	for i := range branchInfos {
+		if branchInfos[i].isRemote {
+			continue
+		}
+		path, ok := worktreePaths[branchInfos[i].branch]
+		branchInfos[i].hasWorktree = ok
+		branchInfos[i].worktreePath = path
+	}
+
+	return worktreePaths
+}
+
+// updateMergedInfo marks branches already merged into the default branch
+func updateMergedInfo(branchInfos []branchCommitInfo, gitClient git.Client) {
+	defaultBranch, err := worktree.GetDefaultBranch()
+	if err != nil {
+		printErrorAndExit("failed to determine default branch: %v", err)
+	}
+
+	mergedOutput, err := gitClient.ForEachRef("%(refname)", "--merged", defaultBranch, "refs/heads/", "refs/remotes/")
+	if err != nil {
+		printErrorAndExit("failed to get merged branches: %v", err)
+	}
+
+	merged := make(map[string]bool)
+	for _, ref := range strings.Split(mergedOutput, "\n") {
+		ref = strings.TrimSpace(ref)
+		if strings.HasPrefix(ref, "refs/remotes/") {
+			merged["remote:"+strings.TrimPrefix(ref, "refs/remotes/")] = true
+		} else if ref != "" {
+			merged[strings.TrimPrefix(ref, "refs/heads/")] = true
+		}
 	}
 
-	// Update hasWorktree field
 	for i := range branchInfos {
-		branchInfos[i].hasWorktree = worktreeBranches[branchInfos[i].branch]
+		key := branchInfos[i].branch
+		if branchInfos[i].isRemote {
+			key = "remote:" + key
+		}
+		branchInfos[i].merged = merged[key]
 	}
 }
 
+// matchesRecentFilters applies the time, pattern, merge and worktree filters
+func matchesRecentFilters(bi branchCommitInfo, flags recentFlags) bool {
+	if !flags.since.IsZero() && bi.timestamp.Before(flags.since) {
+		return false
+	}
+	if !flags.until.IsZero() && bi.timestamp.After(flags.until) {
+		return false
+	}
+	if flags.grep != nil && !flags.grep.MatchString(bi.branch) {
+		return false
+	}
+	if (flags.merged && !bi.merged) || (flags.unmerged && bi.merged) {
+		return false
+	}
+	if (flags.withWorktree && !bi.hasWorktree) || (flags.withoutWorktree && bi.hasWorktree) {
+		return false
+	}
+	return true
+}
+
 // filterBranches filters branches based on author and flags
 func filterBranches(branchInfos []branchCommitInfo, flags recentFlags, currentUserName string) []branchCommitInfo {
 	branches := make([]branchCommitInfo, 0, len(branchInfos))
 	for _, bi := range branchInfos {
+		if !matchesRecentFilters(bi, flags) {
+			continue
+		}
 		if flags.showAll {
 			// Show all branches
 			branches = append(branches, bi)
@@ -1651,25 +2526,102 @@ func navigateToBranch(branches []branchCommitInfo, index int, gitClient git.Clie
 
 	// Check if branch has a worktree
 	if targetBranch.hasWorktree {
-		// Find the worktree path
-		worktrees, err := gitClient.WorktreeList()
-		if err != nil {
-			printErrorAndExit("failed to get worktrees: %v", err)
+		changeDirectory(targetBranch.worktreePath)
+	} else {
+		// No worktree, checkout the branch (git creates a tracking branch for remotes)
+		branch := localBranchName(targetBranch)
+		if err := gitClient.Checkout(branch); err != nil {
+			printErrorAndExit("failed to checkout branch %s: %v", branch, err)
 		}
+		fmt.Printf("Switched to branch '%s'\n", branch)
+	}
+}
 
-		for _, wt := range worktrees {
-			if wt.Branch == targetBranch.branch {
-				fmt.Printf("CD:%s", wt.Path)
-				return
+// selectRecentBranch opens the fuzzy finder over the recent branches and switches
+// to the selected one, creating a worktree when it doesn't have one yet
+func selectRecentBranch(branches []branchCommitInfo, worktreePaths map[string]string, configMgr *config.Manager) {
+	if len(branches) == 0 {
+		return
+	}
+
+	items := formatRecentItems(branches)
+	result, err := interactive.Select(items, interactive.SelectOptions{
+		Prompt: "Select branch: ",
+		Header: "Use arrow keys to navigate, Enter to select, Esc to cancel",
+		PreviewFunc: func(i, width, height int) string {
+			if i < 0 || i >= len(branches) {
+				return ""
 			}
+			return worktree.BranchPreview(branches[i].branch, branches[i].worktreePath, width)
+		},
+		CapturedOutput: true,
+	})
+	if err != nil {
+		if err == interactive.ErrUserCancelled {
+			printErrorAndExit("selection cancelled")
+		} else {
+			printErrorAndExit("%v", err)
 		}
-	} else {
-		// No worktree, checkout the branch
-		if err := gitClient.Checkout(targetBranch.branch); err != nil {
-			printErrorAndExit("failed to checkout branch %s: %v", targetBranch.branch, err)
+		return
+	}
+
+	selected := branches[result.Indices[0]]
+	branch := localBranchName(selected)
+	if path, ok := worktreePaths[branch]; ok {
+		changeDirectory(path)
+		return
+	}
+
+	// Remote branches get a local branch created from the remote ref
+	base := ""
+	if selected.isRemote {
+		base = selected.branch
+	}
+	trustRepoCommands(configMgr)
+	path, err := worktree.SmartNewWorktree(branch, base, configMgr)
+	if err != nil {
+		printErrorAndExit("%v", err)
+	}
+	changeDirectory(path)
+	createWorktreeRuntimes(path, configMgr)
+}
+
+// localBranchName returns the local branch name, stripping the remote from remote branches
+func localBranchName(bi branchCommitInfo) string {
+	if !bi.isRemote {
+		return bi.branch
+	}
+	if _, name, found := strings.Cut(bi.branch, "/"); found {
+		return name
+	}
+	return bi.branch
+}
+
+// formatRecentItems renders one aligned line per branch for the picker
+func formatRecentItems(branches []branchCommitInfo) []string {
+	maxBranchLen := 0
+	for _, b := range branches {
+		if n := len([]rune(b.branch)); n > maxBranchLen {
+			maxBranchLen = n
+		}
+	}
+	if maxBranchLen > 40 {
+		maxBranchLen = 40
+	}
+
+	items := make([]string, len(branches))
+	for i, b := range branches {
+		marker := " "
+		if b.hasWorktree {
+			marker = "*"
 		}
-		fmt.Printf("Switched to branch '%s'\n", targetBranch.branch)
+		items[i] = fmt.Sprintf("%s %-*s  %-14s  %s",
+			marker,
+			maxBranchLen, truncateWithEllipsis(b.branch, maxBranchLen),
+			b.relativeDate,
+			truncateWithEllipsis(b.subject, 60))
 	}
+	return items
 }
 
 // displayBranches shows the list of branches in multi-line format (default)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -87,6 +88,59 @@ func TestHandleVersionCommand(t *testing.T) {
 	}
 }
 
+func TestShellInitFish(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		runCommand("shell-init", []string{"fish"}, &config.Manager{})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"function wt", "WT_SHELL=fish", "WT_DIRECTIVE_FILE", "'cd *'", "'exec *'"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected fish wrapper to contain %q", want)
+		}
+	}
+
+	if _, err := exec.LookPath("fish"); err != nil {
+		t.Skip("fish not installed")
+	}
+	if out, err := exec.Command("fish", "--no-execute", "-c", stdout).CombinedOutput(); err != nil {
+		t.Errorf("fish wrapper does not parse: %v\n%s", err, out)
+	}
+}
+
+func TestShellInitShellFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantContain string
+	}{
+		{"nu via flag", []string{"--shell", "nu"}, "def --env --wrapped wt"},
+		{"nu via equals", []string{"--shell=nu"}, "def --env --wrapped wt"},
+		{"posix sh", []string{"--shell", "sh"}, "WT_SHELL=sh"},
+		{"fish via flag", []string{"--shell", "fish"}, "function wt"},
+		{"default bash", []string{}, "wt() {"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _, _ := captureOutput(func() error {
+				handleShellInitCommand(tt.args)
+				return nil
+			})
+			if !strings.Contains(stdout, tt.wantContain) {
+				t.Errorf("expected output to contain %q", tt.wantContain)
+			}
+		})
+	}
+
+	if strings.Contains(shWrapper, "[[") || strings.Contains(shWrapper, "<<<") {
+		t.Error("POSIX sh wrapper must not use bash-only syntax")
+	}
+}
+
 func TestShellInitCommand(t *testing.T) {
 	// Initialize config manager
 	configMgr := &config.Manager{}
@@ -104,11 +158,57 @@ func TestShellInitCommand(t *testing.T) {
 	if !strings.Contains(stdout, "wt()") {
 		t.Error("expected shell function definition")
 	}
-	if !strings.Contains(stdout, "CD:") {
-		t.Error("expected CD: prefix handling")
+	if !strings.Contains(stdout, "WT_DIRECTIVE_FILE") {
+		t.Error("expected directive file handling")
+	}
+	if !strings.Contains(stdout, `"cd "*)`) || !strings.Contains(stdout, `"exec "*)`) {
+		t.Error("expected cd and exec directive handling")
+	}
+}
+
+func TestShellWrapperDirectives(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	marker := filepath.Join(dir, "ran")
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A stand-in for wt-bin that records it ran and asks for a cd
+	bin := filepath.Join(dir, "wt-bin")
+	script := "#!/bin/sh\ntouch " + marker + "\necho \"cd " + target + "\" >> \"$WT_DIRECTIVE_FILE\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
 	}
-	if !strings.Contains(stdout, "EXEC:") {
-		t.Error("expected EXEC: prefix handling")
+
+	for _, tt := range []struct{ shell, wrapper string }{{"bash", shellWrapper}, {"sh", shWrapper}} {
+		t.Run(tt.shell, func(t *testing.T) {
+			if _, err := exec.LookPath(tt.shell); err != nil {
+				t.Skipf("%s not installed", tt.shell)
+			}
+			run := func(tmpdir string) (string, string, error) {
+				os.Remove(marker)
+				cmd := exec.Command(tt.shell, "-c", tt.wrapper+"\nwt go && pwd")
+				cmd.Env = append(os.Environ(), "WT_BIN="+bin, "TMPDIR="+tmpdir)
+				var stdout, stderr strings.Builder
+				cmd.Stdout, cmd.Stderr = &stdout, &stderr
+				err := cmd.Run()
+				return stdout.String(), stderr.String(), err
+			}
+
+			stdout, stderr, err := run(t.TempDir())
+			if err != nil || strings.TrimSpace(stdout) != target {
+				t.Errorf("cd directive: err=%v stdout=%q stderr=%q", err, stdout, stderr)
+			}
+
+			// Without a directive file the cd would be lost, so wt must not run
+			_, stderr, err = run(filepath.Join(dir, "missing"))
+			if err == nil || !strings.Contains(stderr, "cannot create a directive file") {
+				t.Errorf("failed mktemp: err=%v stderr=%q", err, stderr)
+			}
+			if _, statErr := os.Stat(marker); statErr == nil {
+				t.Error("wt-bin ran without a directive file")
+			}
+		})
 	}
 }
 
@@ -135,8 +235,13 @@ func TestHandleCompletionCommand(t *testing.T) {
 			wantError: true,
 		},
 		{
-			name:      "unsupported shell",
+			name:      "fish completion",
 			args:      []string{"fish"},
+			wantShell: "fish",
+		},
+		{
+			name:      "unsupported shell",
+			args:      []string{"tcsh"},
 			wantError: true,
 		},
 	}
@@ -144,7 +249,7 @@ func TestHandleCompletionCommand(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Skip tests that would cause exit - test them in integration tests
-			if tt.wantError && (len(tt.args) == 0 || (len(tt.args) > 0 && tt.args[0] == "fish")) {
+			if tt.wantError && (len(tt.args) == 0 || (len(tt.args) > 0 && tt.args[0] == "tcsh")) {
 				t.Skip("Skipping test that exits - covered by integration tests")
 				return
 			}
@@ -172,6 +277,71 @@ func TestHandleCompletionCommand(t *testing.T) {
 	}
 }
 
+func TestHandleProjectValidateCommand(t *testing.T) {
+	oldExit := osExit
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = oldExit
+	}()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	projectsDir := filepath.Join(home, ".config", "wt", "projects")
+	helpers.CreateFiles(t, projectsDir, map[string]string{
+		"good.yaml": "name: good\nmatch:\n  paths: [/src/good]\n",
+		"bad.yaml":  "name: bad\nsetup:\n  copy_file:\n    - source: .env\ncommands:\n  list:\n    target: docs\n",
+	})
+	configMgr, err := config.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"validate"}, configMgr)
+		return nil
+	})
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for an invalid config, got %d", exitCode)
+	}
+	badPath := filepath.Join(projectsDir, "bad.yaml")
+	for _, want := range []string{
+		badPath + `:3: unknown field "copy_file" in setup (did you mean "copy_files"?)`,
+		badPath + `:6: command "list" clashes with the built-in 'wt list'`,
+		"✓ " + filepath.Join(projectsDir, "good.yaml"),
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("validate output missing %q:\n%s", want, stdout)
+		}
+	}
+
+	exitCode = -1
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"validate", filepath.Join(projectsDir, "good.yaml")}, configMgr)
+		return nil
+	})
+	if exitCode != -1 || strings.Contains(stdout, "bad.yaml") {
+		t.Errorf("validating one valid file: exit=%d, output:\n%s", exitCode, stdout)
+	}
+
+	// A broken config for the current directory is reported instead of
+	// silently not matching
+	dir := t.TempDir()
+	t.Chdir(dir)
+	helpers.CreateFiles(t, projectsDir, map[string]string{
+		"here.yaml": "name: here\nmatch:\n  paths: [" + dir + "]\nsettings:\n  worktree-base: /tmp\n",
+	})
+	_, stderr, _ := captureOutput(func() error {
+		loadProjectConfig(configMgr, "list")
+		return nil
+	})
+	if !strings.Contains(stderr, "ignoring invalid project config") || !strings.Contains(stderr, "wt project validate") {
+		t.Errorf("expected a warning about the invalid config, got %q", stderr)
+	}
+}
+
 func TestHandleProjectCommand(t *testing.T) {
 	tempDir := t.TempDir()
 	origDir, _ := os.Getwd()
@@ -441,15 +611,15 @@ func TestHelpFlagHandling(t *testing.T) {
 		{"list", handleListCommand, []string{"-h"}},
 		{"new", func(args []string) { handleNewCommand(args, &config.Manager{}) }, []string{"--help"}},
 		{"new", func(args []string) { handleNewCommand(args, &config.Manager{}) }, []string{"-h"}},
-		{"go", handleGoCommand, []string{"--help"}},
-		{"go", handleGoCommand, []string{"-h"}},
+		{"go", func(args []string) { handleGoCommand(args, &config.Manager{}) }, []string{"--help"}},
+		{"go", func(args []string) { handleGoCommand(args, &config.Manager{}) }, []string{"-h"}},
 		{"rm", handleRemoveCommand, []string{"--help"}},
 		{"rm", handleRemoveCommand, []string{"-h"}},
 		{"setup", handleSetupCommand, []string{"--help"}},
 		{"env", handleEnvCommand, []string{"--help"}},
 		{"project", func(args []string) { handleProjectCommand(args, &config.Manager{}) }, []string{"--help"}},
 		{"completion", func(args []string) { handleCompletionCommand(args, &config.Manager{}) }, []string{"--help"}},
-		{"update", handleUpdateCommand, []string{"--help"}},
+		{"update", func(args []string) { handleUpdateCommand(args, &config.Manager{}) }, []string{"--help"}},
 	}
 
 	for _, cmd := range commands {
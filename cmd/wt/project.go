@@ -0,0 +1,526 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/interactive"
+	"github.com/tobiase/worktree-utils/internal/worktree"
+	"gopkg.in/yaml.v3"
+)
+
+// Replaced in tests
+var (
+	confirm            = interactive.Confirm
+	isInteractiveInput = interactive.IsInteractiveInput
+	runEditor          = editFile
+)
+
+// handleProjectListCommand lists every project config and marks the one that
+// applies to the current directory
+func handleProjectListCommand(args []string, configMgr *config.Manager) {
+	if len(args) > 0 {
+		printErrorAndExit("unknown argument '%s' for wt project list", args[0])
+		return
+	}
+
+	files, err := configMgr.ListProjects()
+	if err != nil {
+		printErrorAndExit("failed to read project configs: %v", err)
+		return
+	}
+	projectsDir := filepath.Join(configMgr.GetConfigDir(), "projects")
+	if len(files) == 0 {
+		fmt.Printf("No project configs in %s\n", projectsDir)
+		fmt.Println("Use 'wt project init <name>' to configure the current repository")
+		return
+	}
+
+	width := 0
+	for _, file := range files {
+		width = max(width, len(file.Name()))
+	}
+
+	current := configMgr.GetCurrentProjectFile()
+	fmt.Printf("Project configs in %s:\n", projectsDir)
+	for _, file := range files {
+		marker := " "
+		detail := filepath.Base(file.Path)
+		switch {
+		case file.Path == current:
+			marker = "*"
+		case file.Err != nil:
+			marker = "!"
+			detail += "  (invalid, see 'wt project validate')"
+		}
+		fmt.Printf("%s %-*s  %s\n", marker, width, file.Name(), detail)
+	}
+	if repo := configMgr.GetRepoConfig(); repo != nil {
+		fmt.Printf("\nRepository config: %s%s\n", repo.Path, trustNote(repo))
+	}
+	if current == "" {
+		fmt.Println("\nNo project matches the current directory; see 'wt project which --explain'")
+	}
+}
+
+// trustNote flags a .wt.yaml whose commands are held back
+func trustNote(repo *config.RepoConfig) string {
+	switch {
+	case repo.Trusted:
+		return ""
+	case repo.Declined:
+		return " (commands declined; see 'wt project trust')"
+	}
+	return " (commands not trusted; see 'wt project trust')"
+}
+
+// handleProjectShowCommand prints a project config, by default the one for
+// the current directory. With --resolved it prints the config with its
+// profiles, and for the current directory the repository's .wt.yaml, merged.
+func handleProjectShowCommand(args []string, configMgr *config.Manager) {
+	resolved := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--resolved" {
+			resolved = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	path, ok := projectFileArg(rest, "show", configMgr)
+	if !ok {
+		return
+	}
+
+	if !resolved {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			printErrorAndExit("%v", err)
+			return
+		}
+		fmt.Printf("# %s\n", path)
+		os.Stdout.Write(data)
+		return
+	}
+
+	sources := path
+	// Show what an untrusted .wt.yaml would run, so it can be reviewed before
+	// 'wt project trust'
+	project := configMgr.GetProjectIncludingUntrusted()
+	var repo *config.RepoConfig
+	if len(rest) > 0 {
+		var err error
+		if project, err = configMgr.LoadProjectFile(path); err != nil {
+			printErrorAndExit("%v", err)
+			return
+		}
+	} else if repo = configMgr.GetRepoConfig(); repo != nil && repo.Path != path {
+		sources += " over " + repo.Path
+	}
+
+	data, err := yaml.Marshal(withoutGeneratedCommands(project))
+	if err != nil {
+		printErrorAndExit("%v", err)
+		return
+	}
+	if repo != nil && !repo.Trusted {
+		fmt.Printf("# %s, resolved%s\n", sources, trustNote(repo))
+		fmt.Println("# Held back until trusted:")
+		for _, command := range repo.Commands {
+			fmt.Printf("#   %s\n", command)
+		}
+	} else {
+		fmt.Printf("# %s, resolved\n", sources)
+	}
+	os.Stdout.Write(data)
+}
+
+// withoutGeneratedCommands returns project without the commands wt adds for
+// virtualenv.auto_commands and runtimes
+func withoutGeneratedCommands(project *config.ProjectConfig) *config.ProjectConfig {
+	stripped := *project
+	stripped.Commands = nil
+	for name, cmd := range project.Commands {
+		if cmd.Type == "virtualenv" || cmd.Type == "runtime" {
+			continue
+		}
+		if stripped.Commands == nil {
+			stripped.Commands = make(map[string]config.NavigationCommand)
+		}
+		stripped.Commands[name] = cmd
+	}
+	return &stripped
+}
+
+// handleProjectEditCommand opens a project config in $VISUAL or $EDITOR and
+// only saves it once it validates. The edit happens on a copy, so an invalid
+// config never replaces a working one.
+func handleProjectEditCommand(args []string, configMgr *config.Manager) {
+	path, ok := projectFileArg(args, "edit", configMgr)
+	if !ok {
+		return
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		printErrorAndExit("%v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "wt-"+strings.TrimSuffix(filepath.Base(path), ".yaml")+"-*.yaml")
+	if err != nil {
+		printErrorAndExit("failed to create temporary file: %v", err)
+		return
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		printErrorAndExit("failed to write temporary file: %v", err)
+		return
+	}
+
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			printErrorAndExit("editor failed: %v (your changes are in %s)", err, tmpPath)
+			return
+		}
+
+		data, err := os.ReadFile(tmpPath)
+		if err != nil {
+			printErrorAndExit("%v", err)
+			return
+		}
+		if bytes.Equal(data, original) {
+			os.Remove(tmpPath)
+			fmt.Printf("No changes to %s\n", path)
+			return
+		}
+
+		errs := validateProjectFile(path, data, configMgr)
+		if len(errs) == 0 {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				printErrorAndExit("failed to save %s: %v (your changes are in %s)", path, err, tmpPath)
+				return
+			}
+			os.Remove(tmpPath)
+			fmt.Printf("Saved %s\n", path)
+			return
+		}
+
+		printValidationErrors(os.Stderr, path, errs)
+		if isInteractiveInput() {
+			if again, err := confirm("Edit again?", true); err == nil && again {
+				continue
+			}
+		}
+		printErrorAndExit("%s was not changed; your edits are in %s", path, tmpPath)
+		return
+	}
+}
+
+// editFile opens path in the user's editor, attached to the terminal
+func editFile(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so editors with arguments, like "code --wait", work
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// handleProjectRmCommand deletes a project config after confirmation
+func handleProjectRmCommand(args []string, configMgr *config.Manager) {
+	yes := false
+	var names []string
+	for _, arg := range args {
+		switch {
+		case arg == "--yes" || arg == "-y":
+			yes = true
+		case strings.HasPrefix(arg, "-"):
+			printErrorAndExit("unknown flag '%s' for wt project rm", arg)
+			return
+		default:
+			names = append(names, arg)
+		}
+	}
+	if len(names) != 1 {
+		printErrorAndExit("usage: wt project rm <name> [--yes]")
+		return
+	}
+
+	file, err := configMgr.FindProject(names[0])
+	if err != nil {
+		printErrorAndExit("%v; see 'wt project list'", err)
+		return
+	}
+
+	if !yes {
+		if !isInteractiveInput() {
+			printErrorAndExit("refusing to remove %s without confirmation; use --yes", file.Path)
+			return
+		}
+		ok, err := confirm(fmt.Sprintf("Remove project '%s' (%s)?", file.Name(), file.Path), false)
+		if err != nil || !ok {
+			fmt.Println("Cancelled")
+			return
+		}
+	}
+
+	if err := os.Remove(file.Path); err != nil {
+		printErrorAndExit("failed to remove %s: %v", file.Path, err)
+		return
+	}
+	fmt.Printf("Removed project '%s' (%s)\n", file.Name(), file.Path)
+}
+
+// handleProjectWhichCommand prints the project config for the current
+// directory. With --explain it shows how every path and remote pattern of
+// every config was evaluated.
+func handleProjectWhichCommand(args []string, configMgr *config.Manager) {
+	explain := false
+	for _, arg := range args {
+		switch arg {
+		case "--explain":
+			explain = true
+		default:
+			printErrorAndExit("unknown flag '%s' for wt project which", arg)
+			return
+		}
+	}
+
+	if !explain {
+		project := configMgr.GetCurrentProject()
+		if project == nil {
+			printErrorAndExit("no project matches the current directory; run 'wt project which --explain' to see why")
+			return
+		}
+		fmt.Printf("%s (%s)\n", project.Name, configMgr.GetCurrentProjectFile())
+		if repo := configMgr.GetRepoConfig(); repo != nil && repo.Path != configMgr.GetCurrentProjectFile() {
+			fmt.Printf("  overriding %s%s\n", repo.Path, trustNote(repo))
+		}
+		return
+	}
+
+	files, err := configMgr.ListProjects()
+	if err != nil {
+		printErrorAndExit("failed to read project configs: %v", err)
+		return
+	}
+	cwd, _ := os.Getwd()
+	gitRemote, _ := worktree.GetGitRemote()
+	matched := explainProjectMatch(os.Stdout, files, cwd, gitRemote)
+	if repo := configMgr.GetRepoConfig(); repo != nil {
+		fmt.Printf("\nRepository config %s applies as the base%s.\n", repo.Path, trustNote(repo))
+		matched = true
+	}
+	if !matched {
+		osExit(1)
+	}
+}
+
+// explainProjectMatch writes the match checks of each config in the order
+// LoadProject tries them and reports whether any config matched
+func explainProjectMatch(w io.Writer, files []config.ProjectFile, cwd, gitRemote string) bool {
+	remote := gitRemote
+	if remote == "" {
+		remote = "(none)"
+	}
+	fmt.Fprintf(w, "Directory: %s\nRemote:    %s\n", cwd, remote)
+
+	var winner *config.ProjectFile
+	for i, file := range files {
+		fmt.Fprintf(w, "\n%s (%s)\n", file.Name(), file.Path)
+		if file.Project == nil {
+			fmt.Fprintf(w, "  ✗ skipped: %v\n", file.Err)
+			continue
+		}
+
+		matched := false
+		checks := config.ExplainMatch(file.Project, cwd, gitRemote)
+		for _, check := range checks {
+			mark := "✗"
+			if check.Matched {
+				mark = "✓"
+				matched = true
+			}
+			fmt.Fprintf(w, "  %s %s %s: %s\n", mark, check.Kind, check.Pattern, check.Reason)
+		}
+		if len(checks) == 0 {
+			fmt.Fprintln(w, "  ✗ no match paths or remotes configured")
+		}
+
+		switch {
+		case file.Err != nil && matched:
+			fmt.Fprintf(w, "  ✗ would match, but is skipped: %v\n", file.Err)
+		case file.Err != nil:
+			fmt.Fprintf(w, "  ✗ invalid: %v\n", file.Err)
+		case matched && winner != nil:
+			fmt.Fprintf(w, "  - also matches, but %s comes first\n", filepath.Base(winner.Path))
+		case matched:
+			winner = &files[i]
+		}
+	}
+
+	if winner == nil {
+		fmt.Fprintln(w, "\nNo project matches.")
+		return false
+	}
+	fmt.Fprintf(w, "\nUsing %s: the first matching config in file name order wins.\n", winner.Name())
+	return true
+}
+
+// projectFileArg resolves the optional project name of show and edit to a
+// config file, defaulting to the project of the current directory
+func projectFileArg(args []string, subcmd string, configMgr *config.Manager) (string, bool) {
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			printErrorAndExit("unknown flag '%s' for wt project %s", arg, subcmd)
+			return "", false
+		}
+		names = append(names, arg)
+	}
+
+	switch len(names) {
+	case 0:
+		if path := configMgr.GetCurrentProjectFile(); path != "" {
+			return path, true
+		}
+		printErrorAndExit("no project matches the current directory; name one from 'wt project list'")
+		return "", false
+	case 1:
+		file, err := configMgr.FindProject(names[0])
+		if err != nil {
+			printErrorAndExit("%v; see 'wt project list'", err)
+			return "", false
+		}
+		return file.Path, true
+	default:
+		printErrorAndExit("usage: wt project %s [name]", subcmd)
+		return "", false
+	}
+}
+
+// printValidationErrors writes config problems as file:line: message
+func printValidationErrors(w io.Writer, file string, errs []config.ValidationError) {
+	for _, e := range errs {
+		if e.Line > 0 {
+			fmt.Fprintf(w, "%s:%d: %s\n", file, e.Line, e.Message)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", file, e.Message)
+		}
+	}
+}
+
+// validateProjectFile validates a personal project config, a repository's
+// .wt.yaml or a profile; the latter two need no name or match section
+func validateProjectFile(path string, data []byte, configMgr *config.Manager) []config.ValidationError {
+	switch {
+	case filepath.Base(path) == config.RepoConfigFile:
+		return config.ValidateRepoYAML(data, commands.Names())
+	case isWithinDir(path, configMgr.GetProfilesDir()):
+		return config.ValidateProfileYAML(data, commands.Names())
+	}
+	return config.ValidateProjectYAML(data, commands.Names())
+}
+
+// trustRepoCommands asks before shell commands from the repository's
+// .wt.yaml run for the first time. Without a terminal, or when declined, the
+// project applies without them.
+func trustRepoCommands(configMgr *config.Manager) {
+	repo := configMgr.GetRepoConfig()
+	// A declined .wt.yaml isn't offered again until its commands change
+	if repo == nil || repo.Trusted || repo.Declined {
+		return
+	}
+
+	printRepoCommands(os.Stderr, repo)
+	if isInteractiveInput() {
+		ok, err := confirm("Trust and run these commands?", false)
+		if err == nil && ok {
+			if err := configMgr.TrustRepoConfig(); err != nil {
+				fmt.Fprintf(os.Stderr, "wt: failed to record trust: %v\n", err)
+			}
+			return
+		}
+		if err == nil {
+			if err := configMgr.DeclineRepoConfig(); err != nil {
+				fmt.Fprintf(os.Stderr, "wt: failed to record the refusal: %v\n", err)
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "wt: skipping them; run 'wt project trust' to allow them\n")
+}
+
+// printRepoCommands lists the untrusted commands of a .wt.yaml
+func printRepoCommands(w io.Writer, repo *config.RepoConfig) {
+	fmt.Fprintf(w, "wt: %s defines commands you have not trusted:\n", repo.Path)
+	for _, command := range repo.Commands {
+		fmt.Fprintf(w, "  %s\n", command)
+	}
+}
+
+// handleProjectTrustCommand approves the commands of the repository's
+// .wt.yaml. They are pinned by hash, so changing them asks again.
+func handleProjectTrustCommand(args []string, configMgr *config.Manager) {
+	yes := false
+	for _, arg := range args {
+		switch arg {
+		case "--yes", "-y":
+			yes = true
+		default:
+			printErrorAndExit("unknown flag '%s' for wt project trust", arg)
+			return
+		}
+	}
+
+	repo := configMgr.GetRepoConfig()
+	switch {
+	case repo == nil:
+		printErrorAndExit("no %s in this repository", config.RepoConfigFile)
+		return
+	case len(repo.Commands) == 0:
+		fmt.Printf("%s defines no commands; nothing to trust\n", repo.Path)
+		return
+	case repo.Trusted:
+		fmt.Printf("The commands in %s are already trusted\n", repo.Path)
+		return
+	}
+
+	if !yes {
+		if !isInteractiveInput() {
+			printErrorAndExit("refusing to trust %s without confirmation; use --yes", repo.Path)
+			return
+		}
+		printRepoCommands(os.Stderr, repo)
+		ok, err := confirm("Trust and run these commands?", false)
+		if err != nil || !ok {
+			fmt.Println("Cancelled")
+			return
+		}
+	}
+
+	if err := configMgr.TrustRepoConfig(); err != nil {
+		printErrorAndExit("failed to record trust: %v", err)
+		return
+	}
+	fmt.Printf("Trusted the commands in %s\n", repo.Path)
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/test/helpers"
+)
+
+// setupProjects creates project configs in a temporary HOME and returns a
+// manager loaded for dir along with the projects directory
+func setupProjects(t *testing.T, files map[string]string, dir string) (*config.Manager, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	projectsDir := filepath.Join(home, ".config", "wt", "projects")
+	helpers.CreateFiles(t, projectsDir, files)
+
+	configMgr, err := config.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = configMgr.LoadProject(dir, "")
+	return configMgr, projectsDir
+}
+
+func mockExit(t *testing.T) *int {
+	t.Helper()
+	exitCode := -1
+	oldExit := osExit
+	osExit = func(code int) { exitCode = code }
+	t.Cleanup(func() { osExit = oldExit })
+	return &exitCode
+}
+
+func TestHandleProjectListAndShow(t *testing.T) {
+	exitCode := mockExit(t)
+	configMgr, projectsDir := setupProjects(t, map[string]string{
+		"app.yaml":  "name: app\nmatch:\n  paths: [/src/app]\n",
+		"lib.yaml":  "name: library\nmatch:\n  paths: [/src/lib]\n",
+		"typo.yaml": "name: typo\nbogus: true\n",
+	}, "/src/app/cmd")
+
+	stdout, _, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"list"}, configMgr)
+		return nil
+	})
+	for _, want := range []string{"* app      app.yaml", "  library  lib.yaml", "! typo     typo.yaml  (invalid"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("list output missing %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"show"}, configMgr)
+		return nil
+	})
+	if !strings.HasPrefix(stdout, "# "+filepath.Join(projectsDir, "app.yaml")+"\n") || !strings.Contains(stdout, "paths: [/src/app]") {
+		t.Errorf("show without a name should print the current project:\n%s", stdout)
+	}
+
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"show", "library"}, configMgr)
+		return nil
+	})
+	if !strings.Contains(stdout, "name: library") {
+		t.Errorf("show library printed:\n%s", stdout)
+	}
+
+	_, stderr, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"show", "missing"}, configMgr)
+		return nil
+	})
+	if *exitCode != 1 || !strings.Contains(stderr, "no project named 'missing'") {
+		t.Errorf("show missing: exit=%d stderr=%q", *exitCode, stderr)
+	}
+}
+
+func TestHandleProjectEdit(t *testing.T) {
+	exitCode := mockExit(t)
+	configMgr, projectsDir := setupProjects(t, map[string]string{
+		"app.yaml": "name: app\nmatch:\n  paths: [/src/app]\n",
+	}, "/src/app")
+	path := filepath.Join(projectsDir, "app.yaml")
+	t.Setenv("TMPDIR", t.TempDir()) // Rejected edits are kept there
+
+	var edits []string
+	oldEditor, oldInteractive := runEditor, isInteractiveInput
+	runEditor = func(file string) error {
+		content := edits[0]
+		edits = edits[1:]
+		return os.WriteFile(file, []byte(content), 0644)
+	}
+	isInteractiveInput = func() bool { return false }
+	defer func() { runEditor, isInteractiveInput = oldEditor, oldInteractive }()
+
+	// An invalid edit leaves the config alone and keeps the edit
+	edits = []string{"name: app\nmatch:\n  path: [/src/app]\n"}
+	_, stderr, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"edit"}, configMgr)
+		return nil
+	})
+	if *exitCode != 1 || !strings.Contains(stderr, path+`:3: unknown field "path" in match (did you mean "paths"?)`) {
+		t.Errorf("invalid edit: exit=%d stderr=%q", *exitCode, stderr)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "paths:") {
+		t.Errorf("invalid edit replaced the config:\n%s", data)
+	}
+
+	// Interactively, the user can fix the edit
+	*exitCode = -1
+	isInteractiveInput = func() bool { return true }
+	oldConfirm := confirm
+	confirm = func(string, bool) (bool, error) { return true, nil }
+	defer func() { confirm = oldConfirm }()
+	edits = []string{"name: app\nmatch:\n  path: [/src/app]\n", "name: app\nmatch:\n  paths: [/src/app, /src/app2]\n"}
+	stdout, _, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"edit", "app"}, configMgr)
+		return nil
+	})
+	if *exitCode != -1 || !strings.Contains(stdout, "Saved "+path) {
+		t.Errorf("fixed edit: exit=%d stdout=%q", *exitCode, stdout)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "/src/app2") {
+		t.Errorf("valid edit was not saved:\n%s", data)
+	}
+
+	runEditor = func(string) error { return nil }
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"edit", "app"}, configMgr)
+		return nil
+	})
+	if !strings.Contains(stdout, "No changes to "+path) {
+		t.Errorf("unchanged edit printed %q", stdout)
+	}
+}
+
+func TestHandleProjectRm(t *testing.T) {
+	exitCode := mockExit(t)
+	configMgr, projectsDir := setupProjects(t, map[string]string{
+		"app.yaml": "name: app\n",
+		"lib.yaml": "name: lib\n",
+	}, "/src")
+
+	oldInteractive, oldConfirm := isInteractiveInput, confirm
+	defer func() { isInteractiveInput, confirm = oldInteractive, oldConfirm }()
+
+	// Without a terminal, removing needs --yes
+	isInteractiveInput = func() bool { return false }
+	_, stderr, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"rm", "app"}, configMgr)
+		return nil
+	})
+	if *exitCode != 1 || !strings.Contains(stderr, "use --yes") {
+		t.Errorf("rm without --yes: exit=%d stderr=%q", *exitCode, stderr)
+	}
+
+	// Declining keeps the config
+	isInteractiveInput = func() bool { return true }
+	confirm = func(string, bool) (bool, error) { return false, nil }
+	_, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"rm", "app"}, configMgr)
+		return nil
+	})
+	if _, err := os.Stat(filepath.Join(projectsDir, "app.yaml")); err != nil {
+		t.Errorf("declined rm removed the config: %v", err)
+	}
+
+	stdout, _, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"rm", "--yes", "app"}, configMgr)
+		return nil
+	})
+	if _, err := os.Stat(filepath.Join(projectsDir, "app.yaml")); !os.IsNotExist(err) || !strings.Contains(stdout, "Removed project 'app'") {
+		t.Errorf("rm --yes: stat err=%v stdout=%q", err, stdout)
+	}
+}
+
+func TestExplainProjectMatch(t *testing.T) {
+	files := []config.ProjectFile{
+		{Path: "/cfg/a.yaml", Project: &config.ProjectConfig{Name: "a", Match: config.ProjectMatch{Paths: []string{"/src/other"}}}},
+		{Path: "/cfg/b.yaml", Project: &config.ProjectConfig{Name: "b", Match: config.ProjectMatch{Paths: []string{"/src/app"}}}, Err: &config.ConfigError{Path: "/cfg/b.yaml", Errors: []config.ValidationError{{Line: 3, Message: "bad"}}}},
+		{Path: "/cfg/c.yaml", Project: &config.ProjectConfig{Name: "c", Match: config.ProjectMatch{Remotes: []string{"git@host:app.git"}}}},
+		{Path: "/cfg/d.yaml", Project: &config.ProjectConfig{Name: "d", Match: config.ProjectMatch{Paths: []string{"/src/*"}}}},
+	}
+
+	var out bytes.Buffer
+	if !explainProjectMatch(&out, files, "/src/app", "git@host:app.git") {
+		t.Fatal("expected a match")
+	}
+	for _, want := range []string{
+		"Directory: /src/app\nRemote:    git@host:app.git",
+		"a (/cfg/a.yaml)\n  ✗ path /src/other: /src/app is not /src/other or inside it",
+		"  ✓ path /src/app: current directory is /src/app\n  ✗ would match, but is skipped: /cfg/b.yaml: line 3: bad",
+		"  ✓ remote git@host:app.git: origin is git@host:app.git",
+		"  - also matches, but c.yaml comes first",
+		"Using c: the first matching config",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if explainProjectMatch(&out, files[:1], "/src/app", "") || !strings.Contains(out.String(), "Remote:    (none)") {
+		t.Errorf("expected no match:\n%s", out.String())
+	}
+}
+
+func TestHandleProjectTrust(t *testing.T) {
+	exitCode := mockExit(t)
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+	helpers.CreateFiles(t, repo, map[string]string{
+		".wt.yaml": "setup:\n  commands:\n    - command: npm ci\n",
+	})
+	configMgr, _ := setupProjects(t, map[string]string{}, repo)
+
+	oldInteractive, oldConfirm := isInteractiveInput, confirm
+	defer func() { isInteractiveInput, confirm = oldInteractive, oldConfirm }()
+
+	// Without a terminal the commands are skipped
+	isInteractiveInput = func() bool { return false }
+	_, stderr, _ := captureOutput(func() error {
+		trustRepoCommands(configMgr)
+		return nil
+	})
+	if !strings.Contains(stderr, "defines commands you have not trusted:\n  setup: npm ci") || !strings.Contains(stderr, "wt project trust") {
+		t.Errorf("untrusted warning = %q", stderr)
+	}
+	if setup := configMgr.GetCurrentProject().Setup; setup != nil && len(setup.Commands) > 0 {
+		t.Error("untrusted setup commands were applied")
+	}
+
+	// A declined prompt is remembered, also by a new process
+	isInteractiveInput = func() bool { return true }
+	asked := 0
+	confirm = func(string, bool) (bool, error) { asked++; return false, nil }
+	for range 2 {
+		_, _, _ = captureOutput(func() error {
+			trustRepoCommands(configMgr)
+			return nil
+		})
+	}
+	declinedMgr, err := config.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = declinedMgr.LoadProject(repo, "")
+	_, stderr, _ = captureOutput(func() error {
+		trustRepoCommands(declinedMgr)
+		return nil
+	})
+	if asked != 1 || stderr != "" || !declinedMgr.GetRepoConfig().Declined {
+		t.Errorf("declined commands: asked %d times, stderr=%q", asked, stderr)
+	}
+	isInteractiveInput = func() bool { return false }
+
+	_, stderr, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"trust"}, configMgr)
+		return nil
+	})
+	if *exitCode != 1 || !strings.Contains(stderr, "use --yes") {
+		t.Errorf("trust without a terminal: exit=%d stderr=%q", *exitCode, stderr)
+	}
+
+	stdout, _, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"trust", "--yes"}, configMgr)
+		return nil
+	})
+	if !strings.Contains(stdout, "Trusted the commands in") || len(configMgr.GetCurrentProject().Setup.Commands) != 1 {
+		t.Errorf("trust --yes printed %q", stdout)
+	}
+
+	// A new process remembers the trust
+	configMgr, err = config.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = configMgr.LoadProject(repo, "")
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"trust"}, configMgr)
+		return nil
+	})
+	if !strings.Contains(stdout, "already trusted") {
+		t.Errorf("second trust printed %q", stdout)
+	}
+}
+
+func TestUntrustedRepoConfigWritesNoDirectives(t *testing.T) {
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+	helpers.CreateFiles(t, repo, map[string]string{
+		".wt.yaml": `env:
+  PROMPT_COMMAND: ./hook
+setup:
+  create_runtimes: true
+runtimes:
+  - type: venv
+    python: ./x
+on_enter:
+  runtimes: true
+`,
+	})
+	configMgr, _ := setupProjects(t, map[string]string{}, repo)
+
+	oldInteractive := isInteractiveInput
+	isInteractiveInput = func() bool { return false }
+	defer func() { isInteractiveInput = oldInteractive }()
+
+	directives := func() string {
+		t.Helper()
+		file := filepath.Join(t.TempDir(), "directives")
+		t.Setenv("WT_DIRECTIVE_FILE", file)
+		_, _, _ = captureOutput(func() error {
+			_, after := worktreeHooks("", repo, configMgr)
+			execAllInShell(after)
+			exportWorktreeEnv(repo, configMgr)
+			createWorktreeRuntimes(repo, configMgr)
+			return nil
+		})
+		data, _ := os.ReadFile(file)
+		return string(data)
+	}
+
+	got := directives()
+	if strings.Contains(got, "exec ") || strings.Contains(got, "PROMPT_COMMAND") {
+		t.Errorf("untrusted .wt.yaml wrote directives:\n%s", got)
+	}
+
+	if err := configMgr.TrustRepoConfig(); err != nil {
+		t.Fatal(err)
+	}
+	got = directives()
+	if !strings.Contains(got, "setenv PROMPT_COMMAND=./hook") || !strings.Contains(got, "exec './x' -m venv") {
+		t.Errorf("trusted .wt.yaml directives:\n%s", got)
+	}
+}
+
+func TestHandleProjectShowResolved(t *testing.T) {
+	exitCode := mockExit(t)
+	configMgr, projectsDir := setupProjects(t, map[string]string{
+		"app.yaml":              "name: app\nextends: node\nmatch:\n  paths: [/src/app]\nvirtualenv:\n  auto_commands: true\n",
+		"loop.yaml":             "name: loop\ninclude: [loop.yaml]\n",
+		"../profiles/node.yaml": "commands:\n  web:\n    target: web\n",
+	}, "/src/app")
+
+	stdout, _, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"show", "--resolved"}, configMgr)
+		return nil
+	})
+	if !strings.HasPrefix(stdout, "# "+filepath.Join(projectsDir, "app.yaml")+", resolved\n") ||
+		!strings.Contains(stdout, "target: web") || strings.Contains(stdout, "extends") {
+		t.Errorf("show --resolved printed:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "mkvenv") {
+		t.Errorf("show --resolved should leave out the commands wt adds:\n%s", stdout)
+	}
+
+	_, stderr, _ := captureOutput(func() error {
+		handleProjectCommand([]string{"show", "loop", "--resolved"}, configMgr)
+		return nil
+	})
+	if *exitCode != 1 || !strings.Contains(stderr, "include cycle: loop.yaml -> loop.yaml") {
+		t.Errorf("show --resolved of a cycle: exit=%d stderr=%q", *exitCode, stderr)
+	}
+
+	// An untrusted .wt.yaml shows the commands it would run
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+	helpers.CreateFiles(t, repo, map[string]string{
+		".wt.yaml": "env:\n  PROMPT_COMMAND: ./hook\nsetup:\n  commands:\n    - command: npm ci\n",
+	})
+	repoMgr, err := config.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = repoMgr.LoadProject(repo, "")
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"show", "--resolved"}, repoMgr)
+		return nil
+	})
+	for _, want := range []string{"(commands not trusted; see 'wt project trust')", "# Held back until trusted:\n#   setup: npm ci\n#   env: PROMPT_COMMAND=./hook\n", "command: npm ci", "PROMPT_COMMAND: ./hook"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("show --resolved of an untrusted .wt.yaml is missing %q:\n%s", want, stdout)
+		}
+	}
+
+	// validate checks profiles, and the chain of each config
+	*exitCode = -1
+	stdout, _, _ = captureOutput(func() error {
+		handleProjectCommand([]string{"validate"}, configMgr)
+		return nil
+	})
+	if *exitCode != 1 || !strings.Contains(stdout, "✓ "+filepath.Join(filepath.Dir(projectsDir), "profiles", "node.yaml")) ||
+		!strings.Contains(stdout, "loop.yaml: include cycle") {
+		t.Errorf("validate: exit=%d output:\n%s", *exitCode, stdout)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tobiase/worktree-utils/internal/git"
+)
+
+// recentCacheEntry is the last non-merge commit found behind a merge tip
+type recentCacheEntry struct {
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	Author    string `json:"author"`
+	Subject   string `json:"subject"`
+}
+
+// apply copies the cached commit into info, computing the relative date fresh
+func (e recentCacheEntry) apply(info *branchCommitInfo, now time.Time) {
+	info.commitHash = e.Hash
+	info.timestamp = time.Unix(e.Timestamp, 0)
+	info.relativeDate = formatRelativeDate(info.timestamp, now)
+	info.author = e.Author
+	info.subject = e.Subject
+}
+
+// recentCache remembers, per repository, the last non-merge commit behind each
+// merge tip. Entries are keyed by the tip SHA, so they never go stale; tips no
+// longer referenced by any branch are dropped on save.
+type recentCache struct {
+	path    string
+	entries map[string]recentCacheEntry
+	used    map[string]recentCacheEntry
+	changed bool
+}
+
+// openRecentCache loads the cache for the current repository from <configDir>/cache
+func openRecentCache(configDir string, gitClient git.Client) *recentCache {
+	commonDir, err := gitClient.RevParse("--git-common-dir")
+	if err != nil {
+		return nil
+	}
+	if absDir, err := filepath.Abs(commonDir); err == nil {
+		commonDir = absDir
+	}
+
+	sum := sha256.Sum256([]byte(commonDir))
+	path := filepath.Join(configDir, "cache", fmt.Sprintf("recent-%s.json", hex.EncodeToString(sum[:8])))
+	return loadRecentCache(path)
+}
+
+// loadRecentCache reads a cache file; a missing or corrupt file yields an empty cache
+func loadRecentCache(path string) *recentCache {
+	cache := &recentCache{
+		path:    path,
+		entries: make(map[string]recentCacheEntry),
+		used:    make(map[string]recentCacheEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		cache.entries = make(map[string]recentCacheEntry)
+	}
+	return cache
+}
+
+func (c *recentCache) get(tip string) (recentCacheEntry, bool) {
+	if c == nil {
+		return recentCacheEntry{}, false
+	}
+	entry, ok := c.entries[tip]
+	if ok {
+		c.used[tip] = entry
+	}
+	return entry, ok
+}
+
+func (c *recentCache) put(tip string, entry recentCacheEntry) {
+	if c == nil {
+		return
+	}
+	c.used[tip] = entry
+	c.changed = true
+}
+
+// save writes the entries used in this run, if anything was added or dropped
+func (c *recentCache) save() error {
+	if c == nil || (!c.changed && len(c.used) == len(c.entries)) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c.used)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
+
+// formatRelativeDate mirrors git's relative date format (e.g. "3 days ago")
+// for dates that don't come straight from git
+func formatRelativeDate(t, now time.Time) string {
+	if t.After(now) {
+		return "in the future"
+	}
+
+	diff := int64(now.Sub(t) / time.Second)
+	if diff < 90 {
+		return plural(diff, "second") + " ago"
+	}
+	// Minutes
+	diff = (diff + 30) / 60
+	if diff < 90 {
+		return plural(diff, "minute") + " ago"
+	}
+	// Hours
+	diff = (diff + 30) / 60
+	if diff < 36 {
+		return plural(diff, "hour") + " ago"
+	}
+	// Days
+	diff = (diff + 12) / 24
+	if diff < 14 {
+		return plural(diff, "day") + " ago"
+	}
+	if diff < 70 {
+		return plural((diff+3)/7, "week") + " ago"
+	}
+	if diff < 365 {
+		return plural((diff+15)/30, "month") + " ago"
+	}
+	if diff < 1825 {
+		totalMonths := (diff*12*2 + 365) / (365 * 2)
+		years, months := totalMonths/12, totalMonths%12
+		if months > 0 {
+			return plural(years, "year") + ", " + plural(months, "month") + " ago"
+		}
+		return plural(years, "year") + " ago"
+	}
+	return plural((diff+183)/365, "year") + " ago"
+}
+
+func plural(n int64, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -4,9 +4,15 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/tobiase/worktree-utils/internal/git"
 )
 
 // TestHandleRecentCommand tests the recent command functionality
@@ -71,6 +77,7 @@ func TestParseRecentFlags(t *testing.T) {
 		wantNavigate int
 		wantVerbose  bool
 		wantCompact  bool
+		wantInteract bool
 		wantError    bool
 	}{
 		{
@@ -187,6 +194,26 @@ func TestParseRecentFlags(t *testing.T) {
 			wantCount:    5,
 			wantNavigate: -1,
 		},
+		{
+			name:         "interactive flag long form",
+			args:         []string{"--interactive"},
+			wantInteract: true,
+			wantCount:    10,
+			wantNavigate: -1,
+		},
+		{
+			name:         "interactive flag short form",
+			args:         []string{"-i", "--all"},
+			wantAll:      true,
+			wantInteract: true,
+			wantCount:    10,
+			wantNavigate: -1,
+		},
+		{
+			name:      "interactive with navigation index",
+			args:      []string{"-i", "2"},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -223,6 +250,10 @@ func TestParseRecentFlags(t *testing.T) {
 			if flags.compact != tt.wantCompact {
 				t.Errorf("compact flag: got %v, want %v", flags.compact, tt.wantCompact)
 			}
+
+			if flags.interactive != tt.wantInteract {
+				t.Errorf("interactive flag: got %v, want %v", flags.interactive, tt.wantInteract)
+			}
 		})
 	}
 }
@@ -975,3 +1006,367 @@ func TestDisplayBranchesCompactFormatting(t *testing.T) {
 		}
 	})
 }
+
+func TestFormatRecentItems(t *testing.T) {
+	branches := []branchCommitInfo{
+		{branch: "feature-auth", relativeDate: "2 hours ago", subject: "Add login", hasWorktree: true},
+		{branch: "fix", relativeDate: "3 days ago", subject: strings.Repeat("x", 80)},
+	}
+
+	items := formatRecentItems(branches)
+	if len(items) != len(branches) {
+		t.Fatalf("Expected %d items, got %d", len(branches), len(items))
+	}
+
+	if !strings.HasPrefix(items[0], "* feature-auth") {
+		t.Errorf("Worktree branch should be marked: %q", items[0])
+	}
+	if !strings.HasPrefix(items[1], "  fix         ") {
+		t.Errorf("Branch names should be padded to the same width: %q", items[1])
+	}
+	if !strings.HasSuffix(items[1], "...") {
+		t.Errorf("Long subjects should be truncated: %q", items[1])
+	}
+	if strings.Index(items[0], "2 hours ago") != strings.Index(items[1], "3 days ago") {
+		t.Errorf("Dates should be aligned:\n%s\n%s", items[0], items[1])
+	}
+}
+
+func TestParseTimeFilter(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "12h", want: now.Add(-12 * time.Hour)},
+		{value: "3d", want: now.AddDate(0, 0, -3)},
+		{value: "2w", want: now.AddDate(0, 0, -14)},
+		{value: "6mo", want: now.AddDate(0, -6, 0)},
+		{value: "1y", want: now.AddDate(-1, 0, 0)},
+		{value: "2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-01-31T08:30:00Z", want: time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC)},
+		{value: "2 weeks", wantErr: true},
+		{value: "5m", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeFilter(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeFilter(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseTimeFilter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecentFilterFlags(t *testing.T) {
+	flags := parseRecentFlags([]string{"--since", "2w", "--until=1d", "--grep", "^feat", "--unmerged", "--without-worktree", "--remote", "-c", "-n", "5"})
+
+	if flags.since.IsZero() || flags.until.IsZero() || !flags.since.Before(flags.until) {
+		t.Errorf("Expected since before until, got since=%v until=%v", flags.since, flags.until)
+	}
+	if flags.grep == nil || !flags.grep.MatchString("feature") || flags.grep.MatchString("bugfix") {
+		t.Errorf("Unexpected grep pattern: %v", flags.grep)
+	}
+	if !flags.unmerged || flags.merged {
+		t.Error("Expected only --unmerged to be set")
+	}
+	if !flags.withoutWorktree || flags.withWorktree {
+		t.Error("Expected only --without-worktree to be set")
+	}
+	if !flags.remote || !flags.compact || flags.count != 5 {
+		t.Errorf("Filters should compose with other flags: %+v", flags)
+	}
+}
+
+func TestFilterBranchesWithFilters(t *testing.T) {
+	now := time.Now()
+	branches := []branchCommitInfo{
+		{branch: "feature-new", author: testUser, timestamp: now.Add(-2 * time.Hour), hasWorktree: true},
+		{branch: "feature-old", author: testUser, timestamp: now.AddDate(0, 0, -30), merged: true},
+		{branch: "fix-login", author: testUser, timestamp: now.AddDate(0, 0, -3)},
+		{branch: "origin/feature-remote", author: testUser, timestamp: now.AddDate(0, 0, -1), isRemote: true},
+	}
+
+	tests := []struct {
+		name  string
+		flags recentFlags
+		want  []string
+	}{
+		{
+			name:  "no filters",
+			flags: recentFlags{},
+			want:  []string{"feature-new", "feature-old", "fix-login", "origin/feature-remote"},
+		},
+		{
+			name:  "since",
+			flags: recentFlags{since: now.AddDate(0, 0, -7)},
+			want:  []string{"feature-new", "fix-login", "origin/feature-remote"},
+		},
+		{
+			name:  "until",
+			flags: recentFlags{until: now.AddDate(0, 0, -2)},
+			want:  []string{"feature-old", "fix-login"},
+		},
+		{
+			name:  "grep",
+			flags: recentFlags{grep: regexp.MustCompile("^feature")},
+			want:  []string{"feature-new", "feature-old"},
+		},
+		{
+			name:  "merged",
+			flags: recentFlags{merged: true},
+			want:  []string{"feature-old"},
+		},
+		{
+			name:  "unmerged with worktree",
+			flags: recentFlags{unmerged: true, withWorktree: true},
+			want:  []string{"feature-new"},
+		},
+		{
+			name:  "without worktree and since",
+			flags: recentFlags{withoutWorktree: true, since: now.AddDate(0, 0, -7)},
+			want:  []string{"fix-login", "origin/feature-remote"},
+		},
+		{
+			name:  "others excludes everything",
+			flags: recentFlags{showOthers: true, since: now.AddDate(0, 0, -7)},
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := filterBranches(branches, tt.flags, testUser)
+			got := make([]string, 0, len(result))
+			for _, bi := range result {
+				got = append(got, bi.branch)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalBranchName(t *testing.T) {
+	tests := []struct {
+		info branchCommitInfo
+		want string
+	}{
+		{branchCommitInfo{branch: "feature/login"}, "feature/login"},
+		{branchCommitInfo{branch: "origin/feature/login", isRemote: true}, "feature/login"},
+		{branchCommitInfo{branch: "upstream/main", isRemote: true}, "main"},
+	}
+
+	for _, tt := range tests {
+		if got := localBranchName(tt.info); got != tt.want {
+			t.Errorf("localBranchName(%q) = %q, want %q", tt.info.branch, got, tt.want)
+		}
+	}
+}
+
+// createRecentTestRepo creates a repository with the given number of branches.
+// Every fifth branch ends in a merge commit to exercise the merge-tip fallback.
+func createRecentTestRepo(tb testing.TB, branchCount int) string {
+	tb.Helper()
+
+	repo := tb.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2024-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2024-01-01T00:00:00Z")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			tb.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.name", testUser)
+	run("config", "user.email", "john@example.com")
+	run("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	base := run("rev-parse", "HEAD")
+	feature := run("commit-tree", base+"^{tree}", "-p", base, "-m", "Feature work")
+	merge := run("commit-tree", base+"^{tree}", "-p", base, "-p", feature, "-m", "Merge feature")
+
+	var refs strings.Builder
+	for i := 0; i < branchCount; i++ {
+		target := feature
+		if i%5 == 0 {
+			target = merge
+		}
+		fmt.Fprintf(&refs, "create refs/heads/branch-%04d %s\n", i, target)
+	}
+	cmd := exec.Command("git", "update-ref", "--stdin")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(refs.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("update-ref failed: %v\n%s", err, output)
+	}
+
+	return repo
+}
+
+// collectBranchInfoPerBranch is the previous implementation, which ran one git
+// log per branch. It is kept here as the benchmark baseline and correctness oracle.
+func collectBranchInfoPerBranch(gitClient git.Client) []branchCommitInfo {
+	branchesOutput, _ := gitClient.ForEachRef("%(refname:short)", "refs/heads/")
+	var infos []branchCommitInfo
+	for _, branch := range strings.Split(branchesOutput, "\n") {
+		commitInfo, err := gitClient.GetLastNonMergeCommit(branch, "%H|%cr|%s|%an|%ct")
+		if err != nil || commitInfo == "" {
+			continue
+		}
+		parts := strings.Split(commitInfo, "|")
+		unixTime, _ := strconv.ParseInt(parts[4], 10, 64)
+		infos = append(infos, branchCommitInfo{
+			branch:       branch,
+			commitHash:   parts[0],
+			relativeDate: parts[1],
+			subject:      parts[2],
+			author:       parts[3],
+			timestamp:    time.Unix(unixTime, 0),
+		})
+	}
+	return infos
+}
+
+func TestCollectBranchInfoMatchesPerBranchLookup(t *testing.T) {
+	repo := createRecentTestRepo(t, 20)
+	gitClient := git.NewCommandClient(repo)
+
+	want := make(map[string]branchCommitInfo)
+	for _, info := range collectBranchInfoPerBranch(gitClient) {
+		want[info.branch] = info
+	}
+
+	for _, cache := range []*recentCache{nil, loadRecentCache(filepath.Join(t.TempDir(), "recent.json"))} {
+		result := collectBranchInfo(gitClient, false, cache)
+		if len(result.branches) != len(want) {
+			t.Fatalf("Expected %d branches, got %d (skipped: %v)", len(want), len(result.branches), result.skipped)
+		}
+		for _, got := range result.branches {
+			exp := want[got.branch]
+			if got.commitHash != exp.commitHash || got.subject != exp.subject || got.author != exp.author ||
+				!got.timestamp.Equal(exp.timestamp) || got.relativeDate != exp.relativeDate {
+				t.Errorf("Branch %s: got %+v, want %+v", got.branch, got, exp)
+			}
+			if got.subject == "Merge feature" {
+				t.Errorf("Branch %s: merge commits should be skipped", got.branch)
+			}
+		}
+	}
+}
+
+func TestRecentCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "recent.json")
+
+	cache := loadRecentCache(path)
+	if _, ok := cache.get("tip1"); ok {
+		t.Fatal("New cache should be empty")
+	}
+	cache.put("tip1", recentCacheEntry{Hash: "abc", Timestamp: 1700000000, Author: testUser, Subject: "Work"})
+	cache.put("tip2", recentCacheEntry{Hash: "def", Timestamp: 1700000000, Author: testUser, Subject: "Other"})
+	if err := cache.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	// A run that only sees tip1 keeps it and drops tip2
+	cache = loadRecentCache(path)
+	entry, ok := cache.get("tip1")
+	if !ok || entry.Hash != "abc" || entry.Subject != "Work" {
+		t.Fatalf("get(tip1) = %+v, %v", entry, ok)
+	}
+	if err := cache.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	cache = loadRecentCache(path)
+	if _, ok := cache.get("tip2"); ok {
+		t.Error("Unused entries should be pruned on save")
+	}
+	if _, ok := cache.get("tip1"); !ok {
+		t.Error("Used entries should be kept on save")
+	}
+
+	// Corrupt files are ignored
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadRecentCache(path).get("tip1"); ok {
+		t.Error("Corrupt cache should load as empty")
+	}
+
+	// A nil cache is a no-op
+	var disabled *recentCache
+	disabled.put("tip", recentCacheEntry{})
+	if _, ok := disabled.get("tip"); ok || disabled.save() != nil {
+		t.Error("nil cache should be a no-op")
+	}
+}
+
+func TestFormatRelativeDate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{1 * time.Second, "1 second ago"},
+		{45 * time.Second, "45 seconds ago"},
+		{5 * time.Minute, "5 minutes ago"},
+		{1 * time.Hour, "60 minutes ago"},
+		{2 * time.Hour, "2 hours ago"},
+		{3 * 24 * time.Hour, "3 days ago"},
+		{21 * 24 * time.Hour, "3 weeks ago"},
+		{90 * 24 * time.Hour, "3 months ago"},
+		{400 * 24 * time.Hour, "1 year, 1 month ago"},
+		{730 * 24 * time.Hour, "2 years ago"},
+		{3000 * 24 * time.Hour, "8 years ago"},
+		{-time.Hour, "in the future"},
+	}
+
+	for _, tt := range tests {
+		if got := formatRelativeDate(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("formatRelativeDate(-%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkCollectBranchInfo(b *testing.B) {
+	repo := createRecentTestRepo(b, 500)
+	gitClient := git.NewCommandClient(repo)
+
+	b.Run("per-branch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			collectBranchInfoPerBranch(gitClient)
+		}
+	})
+
+	b.Run("single-pass", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			collectBranchInfo(gitClient, false, nil)
+		}
+	})
+
+	b.Run("single-pass-cached", func(b *testing.B) {
+		cachePath := filepath.Join(b.TempDir(), "recent.json")
+		warm := loadRecentCache(cachePath)
+		collectBranchInfo(gitClient, false, warm)
+		if err := warm.save(); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			collectBranchInfo(gitClient, false, loadRecentCache(cachePath))
+		}
+	})
+}
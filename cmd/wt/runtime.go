@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/directive"
+	"github.com/tobiase/worktree-utils/internal/runtimes"
+	"github.com/tobiase/worktree-utils/internal/worktree"
+)
+
+// handleRuntimeCommand creates, activates or removes the project's runtimes in
+// the current worktree (the activate, mkenv and rmenv project commands)
+func handleRuntimeCommand(navCmd *config.NavigationCommand, configMgr *config.Manager) {
+	repo, err := worktree.GetRepoRoot()
+	if err != nil {
+		printErrorAndExit("%v", err)
+		return
+	}
+
+	commands, err := runtimes.Commands(configMgr.GetRuntimes(), repo, navCmd.Target)
+	if err != nil {
+		printErrorAndExit("%v", err)
+		return
+	}
+	execAllInShell(commands)
+}
+
+// createWorktreeRuntimes creates the project's runtimes in a new worktree when
+// its setup sets create_runtimes. Creation runs in the calling shell, so
+// wrappers without a directive file are told to run 'wt mkenv' instead.
+func createWorktreeRuntimes(path string, configMgr *config.Manager) {
+	if configMgr == nil {
+		return
+	}
+	project := configMgr.GetCurrentProject()
+	if project == nil || project.Setup == nil || !project.Setup.CreateRuntimes || len(project.Runtimes) == 0 {
+		return
+	}
+
+	if !directive.Enabled() {
+		fmt.Printf("Run 'wt mkenv' in %s to create its runtimes\n", path)
+		return
+	}
+
+	commands, err := runtimes.Commands(project.Runtimes, path, runtimes.ActionCreate)
+	if err != nil {
+		fmt.Printf("Warning: Runtime setup failed: %v\n", err)
+		return
+	}
+	execAllInShell(commands)
+}
+
+// execAllInShell asks the shell wrapper to evaluate commands in order. Wrappers
+// that scrape stdout take a single EXEC: line, so they get the commands joined
+// with &&.
+func execAllInShell(commands []string) {
+	if len(commands) == 0 {
+		return
+	}
+	if !directive.Enabled() {
+		execInShell(strings.Join(commands, " && "))
+		return
+	}
+	for _, command := range commands {
+		execInShell(command)
+	}
+}
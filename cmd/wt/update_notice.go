@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/tobiase/worktree-utils/internal/completion"
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/interactive"
+	"github.com/tobiase/worktree-utils/internal/update"
+)
+
+// updateCheckCmd is the hidden command the background update check runs as
+const updateCheckCmd = "__update-check"
+
+// noticeFreeCommands never print the update notice: they manage updates
+// themselves or their output is read by the shell
+var noticeFreeCommands = map[string]bool{
+	"update":                   true,
+	"version":                  true,
+	"setup":                    true,
+	"completion":               true,
+	shellInitCmd:               true,
+	completion.CompleteCommand: true,
+	updateCheckCmd:             true,
+}
+
+// notifyUpdate prints a one-line notice when the last background check found
+// a newer release, and starts a new check in the background when one is due.
+// It is opt-in through "update: notify: true" in config.yaml and never waits
+// on the network.
+func notifyUpdate(cmd string, configMgr *config.Manager) {
+	if !updateNoticesAllowed(cmd) {
+		return
+	}
+
+	cfg, err := configMgr.LoadGlobalConfig()
+	if err != nil || !cfg.Update.Notify {
+		return
+	}
+	channel, interval, ok := updateCheckSettings(cfg.Update)
+	if !ok {
+		return
+	}
+
+	statePath := filepath.Join(configMgr.GetConfigDir(), update.CheckStateFile)
+	state := update.LoadCheckState(statePath)
+	if state.Channel == channel {
+		if notice := state.Notice(version); notice != "" {
+			fmt.Fprintln(os.Stderr, notice)
+		}
+	}
+
+	if state.Due(channel, interval, time.Now()) && update.BeginCheck(statePath, channel) == nil {
+		startBackgroundUpdateCheck(channel)
+	}
+}
+
+// updateNoticesAllowed reports whether cmd may show a notice in this
+// environment: never in CI, for development builds or when nobody is
+// looking at stderr
+func updateNoticesAllowed(cmd string) bool {
+	if noticeFreeCommands[cmd] || interactive.IsCIEnvironment() {
+		return false
+	}
+	if _, err := update.ParseVersion(version); err != nil {
+		return false
+	}
+	return isatty.IsTerminal(os.Stderr.Fd())
+}
+
+// updateCheckSettings returns the channel and interval configured for
+// background checks, and false when they are invalid
+func updateCheckSettings(settings config.UpdateSettings) (string, time.Duration, bool) {
+	channel := settings.Channel
+	if channel == "" {
+		channel = update.ChannelStable
+	}
+	if channel != update.ChannelStable && channel != update.ChannelBeta {
+		return "", 0, false
+	}
+
+	interval := update.DefaultCheckInterval
+	if settings.CheckInterval != "" {
+		d, err := time.ParseDuration(settings.CheckInterval)
+		if err != nil || d <= 0 {
+			return "", 0, false
+		}
+		interval = d
+	}
+	return channel, interval, true
+}
+
+// startBackgroundUpdateCheck runs the check in a detached wt process so the
+// current command can exit right away
+func startBackgroundUpdateCheck(channel string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, updateCheckCmd, channel)
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	_ = cmd.Process.Release()
+}
+
+// handleUpdateCheckCommand runs a background update check and caches the
+// result for the next notice. Failures are silent; the check is retried at
+// the next interval.
+func handleUpdateCheckCommand(args []string, configMgr *config.Manager) {
+	channel := update.ChannelStable
+	if len(args) > 0 {
+		channel = args[0]
+	}
+	if configureUpdates(configMgr) != nil {
+		return
+	}
+	statePath := filepath.Join(configMgr.GetConfigDir(), update.CheckStateFile)
+	_ = update.RunCheck(statePath, version, channel)
+}
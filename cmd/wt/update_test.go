@@ -0,0 +1,180 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/update"
+)
+
+func TestParseUpdateFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		want      updateFlags
+		wantError bool
+	}{
+		{
+			name: "no flags",
+			args: []string{},
+			want: updateFlags{channel: update.ChannelStable},
+		},
+		{
+			name: "check and force",
+			args: []string{"--check", "--force"},
+			want: updateFlags{checkOnly: true, force: true, channel: update.ChannelStable},
+		},
+		{
+			name: "beta channel",
+			args: []string{"--channel", "beta"},
+			want: updateFlags{channel: update.ChannelBeta},
+		},
+		{
+			name: "pinned version with equals",
+			args: []string{"--version=v1.4.2", "--check"},
+			want: updateFlags{checkOnly: true, channel: update.ChannelStable, version: "v1.4.2"},
+		},
+		{
+			name: "rollback",
+			args: []string{"--rollback"},
+			want: updateFlags{rollback: true, channel: update.ChannelStable},
+		},
+		{
+			name:      "rollback with version",
+			args:      []string{"--rollback", "--version", "v1.0.0"},
+			wantError: true,
+		},
+		{
+			name:      "unknown channel",
+			args:      []string{"--channel", "nightly"},
+			wantError: true,
+		},
+		{
+			name:      "channel with version",
+			args:      []string{"--channel", "beta", "--version", "v1.0.0"},
+			wantError: true,
+		},
+		{
+			name:      "missing value",
+			args:      []string{"--version"},
+			wantError: true,
+		},
+		{
+			name:      "unknown option",
+			args:      []string{"--nope"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode := -1
+			oldExit := osExit
+			osExit = func(code int) {
+				if exitCode == -1 {
+					exitCode = code
+				}
+			}
+			defer func() { osExit = oldExit }()
+
+			var flags updateFlags
+			_, _, _ = captureOutput(func() error {
+				flags = parseUpdateFlags(tt.args)
+				return nil
+			})
+
+			if tt.wantError {
+				if exitCode != 1 {
+					t.Errorf("Expected exit code 1, got %d", exitCode)
+				}
+				return
+			}
+			if exitCode != -1 {
+				t.Fatalf("Unexpected exit %d", exitCode)
+			}
+			if flags != tt.want {
+				t.Errorf("parseUpdateFlags(%q) = %+v, want %+v", tt.args, flags, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateVersionHelpers(t *testing.T) {
+	if !sameVersion("v1.2.0", "1.2.0") || sameVersion("v1.2.0", "v1.2.1") {
+		t.Error("sameVersion should ignore the v prefix and compare versions")
+	}
+	if !sameVersion("dev", "dev") {
+		t.Error("sameVersion should fall back to comparing unparsable versions as strings")
+	}
+	if !isOlderVersion("v1.4.2", "v1.10.0") || isOlderVersion("v1.10.0", "v1.4.2") {
+		t.Error("isOlderVersion should compare versions numerically")
+	}
+	if isOlderVersion("v1.0.0", "dev") {
+		t.Error("isOlderVersion should be false for unparsable versions")
+	}
+}
+
+func TestUpdateCheckStatus(t *testing.T) {
+	tests := []struct {
+		tag, current string
+		hasUpdate    bool
+		want         int
+	}{
+		{"v1.2.0", "v1.2.0", false, 0},
+		{"v1.3.0", "v1.2.0", true, updateCheckNewer},
+		{"v1.1.0", "v1.2.0", true, updateCheckOlder}, // --version pinned to a downgrade
+		{"v1.0.0", "dev", true, updateCheckNewer},
+	}
+	for _, tt := range tests {
+		if got := updateCheckStatus(tt.tag, tt.current, tt.hasUpdate); got != tt.want {
+			t.Errorf("updateCheckStatus(%q, %q, %v) = %d, want %d", tt.tag, tt.current, tt.hasUpdate, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateCheckSettings(t *testing.T) {
+	tests := []struct {
+		settings     config.UpdateSettings
+		wantChannel  string
+		wantInterval time.Duration
+		wantOK       bool
+	}{
+		{config.UpdateSettings{Notify: true}, update.ChannelStable, update.DefaultCheckInterval, true},
+		{config.UpdateSettings{Channel: "beta", CheckInterval: "6h"}, update.ChannelBeta, 6 * time.Hour, true},
+		{config.UpdateSettings{Channel: "nightly"}, "", 0, false},
+		{config.UpdateSettings{CheckInterval: "daily"}, "", 0, false},
+		{config.UpdateSettings{CheckInterval: "-1h"}, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		channel, interval, ok := updateCheckSettings(tt.settings)
+		if channel != tt.wantChannel || interval != tt.wantInterval || ok != tt.wantOK {
+			t.Errorf("updateCheckSettings(%+v) = %q, %v, %v; want %q, %v, %v",
+				tt.settings, channel, interval, ok, tt.wantChannel, tt.wantInterval, tt.wantOK)
+		}
+	}
+}
+
+func TestUpdateNoticesAllowed(t *testing.T) {
+	oldVersion := version
+	version = "v1.0.0"
+	defer func() { version = oldVersion }()
+
+	for _, cmd := range []string{"update", "shell-init", "__complete", updateCheckCmd} {
+		if updateNoticesAllowed(cmd) {
+			t.Errorf("%s should never show an update notice", cmd)
+		}
+	}
+
+	t.Setenv("CI", "true")
+	if updateNoticesAllowed("list") {
+		t.Error("Update notices should be disabled in CI")
+	}
+	t.Setenv("CI", "")
+
+	version = "dev"
+	if updateNoticesAllowed("list") {
+		t.Error("Development builds should not show update notices")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/worktree"
+)
+
+// venvLockfilePatterns match the files whose contents key a shared virtualenv
+var venvLockfilePatterns = []string{"requirements*.txt", "uv.lock", "poetry.lock"}
+
+const (
+	// venvReadyFile marks a shared virtualenv whose dependencies installed
+	venvReadyFile = ".wt-ready"
+	// venvLinksFile lists the worktree symlinks pointing at a shared virtualenv
+	venvLinksFile = ".wt-links"
+)
+
+// venvCacheDir returns where shared virtualenvs live: <configDir>/cache/venvs
+func venvCacheDir(configDir string) string {
+	return filepath.Join(configDir, "cache", "venvs")
+}
+
+// venvLockfiles returns the lockfiles in worktreePath, relative and sorted
+func venvLockfiles(worktreePath string) ([]string, error) {
+	var lockfiles []string
+	for _, pattern := range venvLockfilePatterns {
+		matches, err := filepath.Glob(filepath.Join(worktreePath, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			lockfiles = append(lockfiles, filepath.Base(match))
+		}
+	}
+	sort.Strings(lockfiles)
+	return lockfiles, nil
+}
+
+// venvCacheKey hashes the python executable with the name and contents of each
+// lockfile, so worktrees with identical dependencies get the same key
+func venvCacheKey(worktreePath, python string, lockfiles []string) (string, error) {
+	if len(lockfiles) == 0 {
+		return "", fmt.Errorf("no lockfile found in %s (shared virtualenvs are keyed by %s)",
+			worktreePath, strings.Join(venvLockfilePatterns, ", "))
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "python\x00%s\x00", python)
+	for _, name := range lockfiles {
+		data, err := os.ReadFile(filepath.Join(worktreePath, name))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(data))
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8]), nil
+}
+
+// createSharedVirtualenv links venvPath to the cached virtualenv for the
+// worktree's lockfiles, creating and installing it on first use
+func createSharedVirtualenv(venvPath, worktreePath, python, configDir string) error {
+	lockfiles, err := venvLockfiles(worktreePath)
+	if err != nil {
+		return err
+	}
+	key, err := venvCacheKey(worktreePath, python, lockfiles)
+	if err != nil {
+		return err
+	}
+
+	envDir := filepath.Join(venvCacheDir(configDir), key)
+	if _, err := os.Stat(filepath.Join(envDir, venvReadyFile)); err == nil {
+		fmt.Printf("Reusing shared virtualenv %s\n", key)
+	} else {
+		if err := buildSharedVirtualenv(envDir, worktreePath, python, lockfiles); err != nil {
+			return err
+		}
+	}
+
+	return linkSharedVirtualenv(envDir, venvPath)
+}
+
+// buildSharedVirtualenv creates the virtualenv at envDir and installs the
+// lockfiles into it, removing it again if anything fails
+func buildSharedVirtualenv(envDir, worktreePath, python string, lockfiles []string) error {
+	// A directory without the ready marker is left over from a failed build
+	if err := os.RemoveAll(envDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(envDir), 0755); err != nil {
+		return fmt.Errorf("failed to create virtualenv cache: %v", err)
+	}
+
+	fmt.Printf("Creating shared virtualenv %s...\n", filepath.Base(envDir))
+	err := worktree.RunCommand(python, "-m", "venv", envDir)
+	if err == nil {
+		err = installVenvDependencies(envDir, worktreePath, lockfiles)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(envDir, venvReadyFile), nil, 0644)
+	}
+	if err != nil {
+		_ = os.RemoveAll(envDir)
+		return fmt.Errorf("failed to create shared virtualenv: %v", err)
+	}
+	return nil
+}
+
+// installVenvDependencies installs each lockfile into the virtualenv at envDir
+func installVenvDependencies(envDir, worktreePath string, lockfiles []string) error {
+	for _, name := range lockfiles {
+		var cmd *exec.Cmd
+		switch name {
+		case "uv.lock":
+			cmd = exec.Command("uv", "sync", "--frozen")
+			cmd.Env = append(os.Environ(), "UV_PROJECT_ENVIRONMENT="+envDir)
+		case "poetry.lock":
+			cmd = exec.Command("poetry", "install", "--no-root")
+			cmd.Env = append(os.Environ(), "VIRTUAL_ENV="+envDir,
+				"PATH="+filepath.Join(envDir, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+		default:
+			cmd = exec.Command(filepath.Join(envDir, "bin", "pip"), "install", "-r", name)
+		}
+		cmd.Dir = worktreePath
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		fmt.Printf("Installing %s...\n", name)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// linkSharedVirtualenv points venvPath at envDir, replacing an existing link,
+// and records the link so 'wt venv gc' knows envDir is in use
+func linkSharedVirtualenv(envDir, venvPath string) error {
+	if info, err := os.Lstat(venvPath); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%s already exists and is not a shared virtualenv link", venvPath)
+		}
+		if err := os.Remove(venvPath); err != nil {
+			return err
+		}
+	}
+	if err := os.Symlink(envDir, venvPath); err != nil {
+		return fmt.Errorf("failed to link shared virtualenv: %v", err)
+	}
+
+	absPath, err := filepath.Abs(venvPath)
+	if err != nil {
+		return err
+	}
+	links := readVenvLinks(envDir)
+	for _, link := range links {
+		if link == absPath {
+			return nil
+		}
+	}
+	return writeVenvLinks(envDir, append(links, absPath))
+}
+
+// gcSharedVirtualenvs removes cached virtualenvs no worktree links to any more
+// and returns their paths. With dryRun nothing is removed.
+func gcSharedVirtualenvs(configDir string, dryRun bool) ([]string, error) {
+	cacheDir := venvCacheDir(configDir)
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		envDir := filepath.Join(cacheDir, entry.Name())
+
+		var live []string
+		for _, link := range readVenvLinks(envDir) {
+			if target, err := os.Readlink(link); err == nil && filepath.Clean(target) == envDir {
+				live = append(live, link)
+			}
+		}
+
+		if len(live) > 0 {
+			if !dryRun {
+				if err := writeVenvLinks(envDir, live); err != nil {
+					return removed, err
+				}
+			}
+			continue
+		}
+
+		if !dryRun {
+			if err := os.RemoveAll(envDir); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, envDir)
+	}
+	return removed, nil
+}
+
+func readVenvLinks(envDir string) []string {
+	file, err := os.Open(filepath.Join(envDir, venvLinksFile))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var links []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			links = append(links, line)
+		}
+	}
+	return links
+}
+
+func writeVenvLinks(envDir string, links []string) error {
+	sort.Strings(links)
+	data := strings.Join(links, "\n") + "\n"
+	return os.WriteFile(filepath.Join(envDir, venvLinksFile), []byte(data), 0644)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVenvCacheKey(t *testing.T) {
+	first, second, third := t.TempDir(), t.TempDir(), t.TempDir()
+	writeTestFiles(t, first, map[string]string{"requirements.txt": "requests==2.31\n", "requirements-dev.txt": "pytest\n", "README.md": "a"})
+	writeTestFiles(t, second, map[string]string{"requirements.txt": "requests==2.31\n", "requirements-dev.txt": "pytest\n", "README.md": "b"})
+	writeTestFiles(t, third, map[string]string{"requirements.txt": "requests==2.32\n", "requirements-dev.txt": "pytest\n"})
+
+	key := func(dir, python string) string {
+		lockfiles, err := venvLockfiles(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lockfiles, []string{"requirements-dev.txt", "requirements.txt"}) {
+			t.Fatalf("venvLockfiles() = %v", lockfiles)
+		}
+		k, err := venvCacheKey(dir, python, lockfiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	if key(first, "python3") != key(second, "python3") {
+		t.Error("Expected identical lockfiles to share a key")
+	}
+	if key(first, "python3") == key(third, "python3") {
+		t.Error("Expected different lockfiles to get different keys")
+	}
+	if key(first, "python3") == key(first, "python3.12") {
+		t.Error("Expected the python executable to be part of the key")
+	}
+
+	if _, err := venvCacheKey(t.TempDir(), "python3", nil); err == nil || !strings.Contains(err.Error(), "no lockfile") {
+		t.Errorf("Expected no lockfile error, got %v", err)
+	}
+}
+
+func TestSharedVirtualenvLinkAndGC(t *testing.T) {
+	configDir := t.TempDir()
+	first, second := t.TempDir(), t.TempDir()
+	for _, dir := range []string{first, second} {
+		writeTestFiles(t, dir, map[string]string{"uv.lock": "version = 1\n"})
+	}
+
+	// A ready cache entry is reused without running python
+	lockfiles, _ := venvLockfiles(first)
+	key, err := venvCacheKey(first, "python3", lockfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envDir := filepath.Join(venvCacheDir(configDir), key)
+	if err := os.MkdirAll(envDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, envDir, map[string]string{venvReadyFile: ""})
+
+	// An unused entry with no links
+	staleDir := filepath.Join(venvCacheDir(configDir), "stale")
+	if err := os.MkdirAll(staleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{first, second} {
+		if err := createSharedVirtualenv(filepath.Join(dir, ".venv"), dir, "python3", configDir); err != nil {
+			t.Fatalf("createSharedVirtualenv() error = %v", err)
+		}
+		if target, err := os.Readlink(filepath.Join(dir, ".venv")); err != nil || target != envDir {
+			t.Fatalf("Expected %s/.venv to link to %s, got %q (%v)", dir, envDir, target, err)
+		}
+	}
+	if links := readVenvLinks(envDir); len(links) != 2 {
+		t.Errorf("Expected 2 recorded links, got %v", links)
+	}
+
+	// A real directory is never replaced
+	blocked := t.TempDir()
+	writeTestFiles(t, blocked, map[string]string{"uv.lock": "version = 1\n"})
+	if err := os.Mkdir(filepath.Join(blocked, ".venv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := createSharedVirtualenv(filepath.Join(blocked, ".venv"), blocked, "python3", configDir); err == nil {
+		t.Error("Expected an existing virtualenv directory to be left alone")
+	}
+
+	removed, err := gcSharedVirtualenvs(configDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{staleDir}) {
+		t.Errorf("gc removed %v, want only %s", removed, staleDir)
+	}
+
+	if err := os.Remove(filepath.Join(first, ".venv")); err != nil {
+		t.Fatal(err)
+	}
+	if removed, _ := gcSharedVirtualenvs(configDir, false); len(removed) != 0 {
+		t.Errorf("Expected entry linked from one worktree to be kept, removed %v", removed)
+	}
+	if links := readVenvLinks(envDir); !reflect.DeepEqual(links, []string{filepath.Join(second, ".venv")}) {
+		t.Errorf("Expected dead link to be pruned, got %v", links)
+	}
+
+	if err := os.Remove(filepath.Join(second, ".venv")); err != nil {
+		t.Fatal(err)
+	}
+	if removed, _ := gcSharedVirtualenvs(configDir, true); !reflect.DeepEqual(removed, []string{envDir}) {
+		t.Errorf("Dry run reported %v, want %s", removed, envDir)
+	}
+	if _, err := os.Stat(envDir); err != nil {
+		t.Error("Dry run should not remove anything")
+	}
+	if removed, _ := gcSharedVirtualenvs(configDir, false); !reflect.DeepEqual(removed, []string{envDir}) {
+		t.Errorf("gc removed %v, want %s", removed, envDir)
+	}
+	if _, err := os.Stat(envDir); !os.IsNotExist(err) {
+		t.Error("Expected unused entry to be deleted")
+	}
+}
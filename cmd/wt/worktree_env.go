@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/directive"
+	"github.com/tobiase/worktree-utils/internal/runtimes"
+	"github.com/tobiase/worktree-utils/internal/worktree"
+)
+
+// envKeysVar lists the project env keys exported by the last 'wt go', so keys
+// that the next project doesn't declare can be unset
+const envKeysVar = "WT_ENV_KEYS"
+
+// exportWorktreeEnv asks the shell wrapper to export WT_BRANCH, WT_WORKTREE,
+// WT_PROJECT and the project's env block for the worktree at path. It is a
+// no-op for wrappers that scrape stdout, keeping 'wt go' output to the CD: line.
+func exportWorktreeEnv(path string, configMgr *config.Manager) {
+	if !directive.Enabled() {
+		return
+	}
+
+	branch, _ := worktree.BranchForPath(path)
+	var project *config.ProjectConfig
+	if configMgr != nil {
+		project = configMgr.GetCurrentProject()
+	}
+
+	set, unset := buildWorktreeEnv(branch, path, project, os.Getenv(envKeysVar))
+
+	for _, name := range unset {
+		if err := directive.Unsetenv(name); err != nil {
+			printErrorAndExit("%v", err)
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := directive.Setenv(name, set[name]); err != nil {
+			printErrorAndExit("%v", err)
+		}
+	}
+}
+
+// buildWorktreeEnv returns the variables to export for a worktree and the
+// variables to unset: WT_PROJECT outside a project, and env keys exported for
+// a previous project (listed in previousKeys) that this one doesn't declare.
+// on_enter.env entries override the project env block. Values may reference $WT_BRANCH, $WT_WORKTREE and $WT_PROJECT.
+func buildWorktreeEnv(branch, path string, project *config.ProjectConfig, previousKeys string) (map[string]string, []string) {
+	set := map[string]string{
+		"WT_BRANCH":   branch,
+		"WT_WORKTREE": path,
+	}
+	var unset []string
+
+	if project != nil {
+		set["WT_PROJECT"] = project.Name
+	} else {
+		unset = append(unset, "WT_PROJECT")
+	}
+
+	builtins := map[string]string{
+		"WT_BRANCH":   set["WT_BRANCH"],
+		"WT_WORKTREE": set["WT_WORKTREE"],
+		"WT_PROJECT":  set["WT_PROJECT"],
+	}
+	expand := func(name string) string {
+		if value, ok := builtins[name]; ok {
+			return value
+		}
+		return "${" + name + "}"
+	}
+
+	var keys []string
+	if project != nil {
+		env := make(map[string]string, len(project.Env))
+		for name, value := range project.Env {
+			env[name] = value
+		}
+		if project.OnEnter != nil {
+			for name, value := range project.OnEnter.Env {
+				env[name] = value
+			}
+		}
+		for name, value := range env {
+			if _, builtin := builtins[name]; builtin {
+				continue
+			}
+			set[name] = os.Expand(value, expand)
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, name := range strings.Split(previousKeys, ",") {
+		if name == "" || name == envKeysVar {
+			continue
+		}
+		if _, ok := set[name]; !ok {
+			unset = append(unset, name)
+		}
+	}
+
+	if len(keys) > 0 {
+		set[envKeysVar] = strings.Join(keys, ",")
+	} else if previousKeys != "" {
+		unset = append(unset, envKeysVar)
+	}
+
+	sort.Strings(unset)
+	return set, unset
+}
+
+// worktreeHooks returns the on_leave and on_enter commands for moving from the
+// worktree at from to the one at to. It is a no-op for wrappers that scrape
+// stdout, which can't run more than one command.
+func worktreeHooks(from, to string, configMgr *config.Manager) (before, after []string) {
+	if !directive.Enabled() {
+		return nil, nil
+	}
+	var project *config.ProjectConfig
+	if configMgr != nil {
+		trustRepoCommands(configMgr)
+		project = configMgr.GetCurrentProject()
+	}
+
+	var targetVenv, activateCmd string
+	if project != nil && project.OnEnter != nil && project.OnEnter.Virtualenv {
+		venvPath, err := virtualenvPath(to, configMgr.GetVirtualenvConfig())
+		if err == nil {
+			activateCmd, err = runtimes.ActivateVenv(venvPath)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wt: on_enter: %v\n", err)
+		} else {
+			targetVenv = venvPath
+		}
+	}
+
+	var runtimeCmds []string
+	if project != nil && project.OnEnter != nil && project.OnEnter.Runtimes {
+		commands, err := runtimes.Commands(project.Runtimes, to, runtimes.ActionActivate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wt: on_enter: %v\n", err)
+		} else {
+			runtimeCmds = commands
+		}
+	}
+
+	return planWorktreeHooks(from, to, project, os.Getenv("VIRTUAL_ENV"), targetVenv, activateCmd, runtimeCmds)
+}
+
+// planWorktreeHooks orders the commands run around the directory change:
+// on_leave.run and deactivating a virtualenv that lives in the worktree being
+// left before it, then activating the target's virtualenv (activateCmd, empty
+// if none), its runtimes and on_enter.run after it. Staying in the same worktree runs no
+// hooks, and an already active target virtualenv is left alone. The previous
+// worktree's virtualenv is deactivated even when project is nil. Virtualenvs
+// are compared with symlinks resolved, since a shared virtualenv is a link
+// into the cache and activates as the cache path.
+func planWorktreeHooks(from, to string, project *config.ProjectConfig, activeVenv, targetVenv, activateCmd string, runtimeCmds []string) (before, after []string) {
+	changing := from == "" || filepath.Clean(from) != filepath.Clean(to)
+	activeIsTarget := activeVenv != "" && targetVenv != "" && resolvePath(activeVenv) == resolvePath(targetVenv)
+
+	if changing {
+		if project != nil && project.OnLeave != nil {
+			before = append(before, project.OnLeave.Run...)
+		}
+		if activeVenv != "" && !activeIsTarget && from != "" && venvOfWorktree(activeVenv, from, to, targetVenv) {
+			before = append(before, "deactivate")
+		}
+	}
+
+	if activateCmd != "" && !activeIsTarget {
+		after = append(after, activateCmd)
+	}
+	if changing {
+		after = append(after, runtimeCmds...)
+	}
+	if changing && project != nil && project.OnEnter != nil {
+		after = append(after, project.OnEnter.Run...)
+	}
+	return before, after
+}
+
+// venvOfWorktree reports whether the active virtualenv belongs to the
+// worktree being left: it lies inside from, or from's counterpart of
+// targetVenv links to it
+func venvOfWorktree(activeVenv, from, to, targetVenv string) bool {
+	if isWithinDir(activeVenv, from) {
+		return true
+	}
+	if targetVenv == "" {
+		return false
+	}
+	rel, err := filepath.Rel(to, targetVenv)
+	if err != nil || !filepath.IsLocal(rel) {
+		return false
+	}
+	return resolvePath(filepath.Join(from, rel)) == resolvePath(activeVenv)
+}
+
+// resolvePath returns path with symlinks resolved, or cleaned if it can't be
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// isWithinDir reports whether path is dir or lies below it
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
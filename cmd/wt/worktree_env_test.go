@@ -0,0 +1,218 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+)
+
+func TestBuildWorktreeEnv(t *testing.T) {
+	project := &config.ProjectConfig{
+		Name: "shop",
+		Env: map[string]string{
+			"COMPOSE_PROJECT_NAME": "shop-${WT_BRANCH}",
+			"API_URL":              "http://localhost:3000/$WT_PROJECT",
+			"KEEP":                 "${HOME}/cache",
+			"WT_BRANCH":            "ignored",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		project      *config.ProjectConfig
+		previousKeys string
+		wantSet      map[string]string
+		wantUnset    []string
+	}{
+		{
+			name:    "project env with expansion",
+			project: project,
+			wantSet: map[string]string{
+				"WT_BRANCH":            "feature",
+				"WT_WORKTREE":          "/repo-worktrees/feature",
+				"WT_PROJECT":           "shop",
+				"COMPOSE_PROJECT_NAME": "shop-feature",
+				"API_URL":              "http://localhost:3000/shop",
+				"KEEP":                 "${HOME}/cache",
+				"WT_ENV_KEYS":          "API_URL,COMPOSE_PROJECT_NAME,KEEP",
+			},
+		},
+		{
+			name:         "keys from previous project are unset",
+			project:      project,
+			previousKeys: "DATABASE_URL,KEEP",
+			wantSet: map[string]string{
+				"WT_BRANCH":            "feature",
+				"WT_WORKTREE":          "/repo-worktrees/feature",
+				"WT_PROJECT":           "shop",
+				"COMPOSE_PROJECT_NAME": "shop-feature",
+				"API_URL":              "http://localhost:3000/shop",
+				"KEEP":                 "${HOME}/cache",
+				"WT_ENV_KEYS":          "API_URL,COMPOSE_PROJECT_NAME,KEEP",
+			},
+			wantUnset: []string{"DATABASE_URL"},
+		},
+		{
+			name:         "no project",
+			previousKeys: "DATABASE_URL",
+			wantSet: map[string]string{
+				"WT_BRANCH":   "feature",
+				"WT_WORKTREE": "/repo-worktrees/feature",
+			},
+			wantUnset: []string{"DATABASE_URL", "WT_ENV_KEYS", "WT_PROJECT"},
+		},
+		{
+			name: "on_enter env overrides project env",
+			project: &config.ProjectConfig{
+				Name:    "shop",
+				Env:     map[string]string{"PORT": "3000"},
+				OnEnter: &config.EnterHook{Env: map[string]string{"PORT": "4000", "NODE_ENV": "development"}},
+			},
+			wantSet: map[string]string{
+				"WT_BRANCH":   "feature",
+				"WT_WORKTREE": "/repo-worktrees/feature",
+				"WT_PROJECT":  "shop",
+				"PORT":        "4000",
+				"NODE_ENV":    "development",
+				"WT_ENV_KEYS": "NODE_ENV,PORT",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, unset := buildWorktreeEnv("feature", "/repo-worktrees/feature", tt.project, tt.previousKeys)
+			if !reflect.DeepEqual(set, tt.wantSet) {
+				t.Errorf("set = %v, want %v", set, tt.wantSet)
+			}
+			if !reflect.DeepEqual(unset, tt.wantUnset) {
+				t.Errorf("unset = %v, want %v", unset, tt.wantUnset)
+			}
+		})
+	}
+}
+
+func TestPlanWorktreeHooks(t *testing.T) {
+	project := &config.ProjectConfig{
+		Name:    "shop",
+		OnEnter: &config.EnterHook{Virtualenv: true, Run: []string{"nvm use"}},
+		OnLeave: &config.LeaveHook{Run: []string{"docker compose stop"}},
+	}
+	const activate = "source '/repo-worktrees/feature/.venv/bin/activate'"
+
+	tests := []struct {
+		name        string
+		from        string
+		project     *config.ProjectConfig
+		activeVenv  string
+		activateCmd string
+		runtimeCmds []string
+		wantBefore  []string
+		wantAfter   []string
+	}{
+		{
+			name:        "switching deactivates the previous worktree's virtualenv",
+			from:        "/repo",
+			project:     project,
+			activeVenv:  "/repo/.venv",
+			activateCmd: activate,
+			wantBefore:  []string{"docker compose stop", "deactivate"},
+			wantAfter:   []string{activate, "nvm use"},
+		},
+		{
+			name:        "virtualenv outside the worktree is left alone",
+			from:        "/repo",
+			project:     project,
+			activeVenv:  "/home/user/.venvs/tools",
+			activateCmd: activate,
+			wantBefore:  []string{"docker compose stop"},
+			wantAfter:   []string{activate, "nvm use"},
+		},
+		{
+			name:        "staying in the worktree activates a missing virtualenv only",
+			from:        "/repo-worktrees/feature",
+			project:     project,
+			activateCmd: activate,
+			wantAfter:   []string{activate},
+		},
+		{
+			name:        "active target virtualenv is not reactivated",
+			from:        "/repo-worktrees/feature",
+			project:     project,
+			activeVenv:  "/repo-worktrees/feature/.venv",
+			activateCmd: activate,
+		},
+		{
+			name:        "runtimes activate after the virtualenv",
+			from:        "/repo",
+			project:     project,
+			activateCmd: activate,
+			runtimeCmds: []string{"nvm use '20'"},
+			wantBefore:  []string{"docker compose stop"},
+			wantAfter:   []string{activate, "nvm use '20'", "nvm use"},
+		},
+		{
+			name:       "previous virtualenv is deactivated outside a project",
+			from:       "/repo",
+			activeVenv: "/repo/.venv",
+			wantBefore: []string{"deactivate"},
+		},
+		{
+			name:    "no hooks configured",
+			from:    "/repo",
+			project: &config.ProjectConfig{Name: "shop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := planWorktreeHooks(tt.from, "/repo-worktrees/feature", tt.project,
+				tt.activeVenv, "/repo-worktrees/feature/.venv", tt.activateCmd, tt.runtimeCmds)
+			if !reflect.DeepEqual(before, tt.wantBefore) {
+				t.Errorf("before = %v, want %v", before, tt.wantBefore)
+			}
+			if !reflect.DeepEqual(after, tt.wantAfter) {
+				t.Errorf("after = %v, want %v", after, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestPlanWorktreeHooksSharedVirtualenv(t *testing.T) {
+	// Both worktrees link .venv to one cached virtualenv, which activates as
+	// the cache path
+	root := t.TempDir()
+	cached := filepath.Join(root, "cache", "venvs", "abc123")
+	repo, feature := filepath.Join(root, "repo"), filepath.Join(root, "repo-worktrees", "feature")
+	for _, dir := range []string{cached, repo, feature} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range []string{repo, feature} {
+		if err := os.Symlink(cached, filepath.Join(dir, ".venv")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := &config.ProjectConfig{Name: "shop", OnEnter: &config.EnterHook{Virtualenv: true}}
+	const activate = "source activate"
+
+	// Switching between worktrees sharing the virtualenv keeps it active
+	before, after := planWorktreeHooks(repo, feature, project, cached, filepath.Join(feature, ".venv"), activate, nil)
+	if len(before) != 0 || len(after) != 0 {
+		t.Errorf("shared virtualenv: before = %v, after = %v, want nothing", before, after)
+	}
+
+	// Leaving for a worktree with its own virtualenv deactivates the shared one
+	other := filepath.Join(root, "repo-worktrees", "other")
+	if err := os.MkdirAll(filepath.Join(other, ".venv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	before, after = planWorktreeHooks(repo, other, project, cached, filepath.Join(other, ".venv"), activate, nil)
+	if !reflect.DeepEqual(before, []string{"deactivate"}) || !reflect.DeepEqual(after, []string{activate}) {
+		t.Errorf("leaving a shared virtualenv: before = %v, after = %v", before, after)
+	}
+}
@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/help"
+	"github.com/tobiase/worktree-utils/internal/suggest"
+)
+
+// ArgumentType says what a positional argument or flag value completes to
+type ArgumentType int
+
+const (
+	ArgString ArgumentType = iota
+	ArgBranch
+	ArgWorktreeBranch
+	ArgProject
+	ArgFile
+	ArgChoice
+)
+
+// Command describes a command once for dispatch, --help output and completion
+type Command struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	// Summary is the one-line description used in completion and subcommand
+	// lists; Description is the longer help text and defaults to Summary
+	Summary     string
+	Description string
+	Flags       []Flag
+	Args        []Argument
+	Subcommands []*Command
+	Examples    []string
+	SeeAlso     []string
+	// Group is the heading 'wt --help' lists the command under
+	Group string
+	// Hidden commands run but are left out of help and completion
+	Hidden bool
+	// Run handles the command; configMgr is nil for commands run before the
+	// config is loaded
+	Run func(args []string, configMgr *config.Manager)
+}
+
+// Flag describes a command flag. Value and Values say what completes after a
+// flag with HasValue set, and ValueName is its placeholder in help.
+type Flag struct {
+	Name        string
+	Short       string
+	Description string
+	Example     string
+	HasValue    bool
+	ValueName   string
+	Value       ArgumentType
+	Values      []string
+}
+
+// Argument describes a positional argument. Values lists the accepted values
+// of an ArgChoice argument.
+type Argument struct {
+	Name        string
+	Description string
+	Type        ArgumentType
+	Values      []string
+}
+
+// Registry holds the commands wt knows about, in the order they were added
+type Registry struct {
+	commands []*Command
+	byName   map[string]*Command
+}
+
+// NewRegistry creates a registry of commands and registers their help. It
+// panics on a repeated name or alias, which is a programming error.
+func NewRegistry(commands ...*Command) *Registry {
+	r := &Registry{byName: make(map[string]*Command)}
+	for _, cmd := range commands {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if _, exists := r.byName[name]; exists {
+				panic(fmt.Sprintf("cli: command %q registered twice", name))
+			}
+			r.byName[name] = cmd
+		}
+		r.commands = append(r.commands, cmd)
+
+		if !cmd.Hidden {
+			help.Register(cmd.Help(), cmd.Aliases...)
+		}
+	}
+	return r
+}
+
+// Lookup returns the command with the given name or alias, or nil
+func (r *Registry) Lookup(name string) *Command {
+	return r.byName[name]
+}
+
+// Resolve returns the command name an alias stands for, or name unchanged
+func (r *Registry) Resolve(name string) string {
+	if cmd := r.byName[name]; cmd != nil {
+		return cmd.Name
+	}
+	return name
+}
+
+// Commands returns the commands that are not hidden, in registration order
+func (r *Registry) Commands() []*Command {
+	var visible []*Command
+	for _, cmd := range r.commands {
+		if !cmd.Hidden {
+			visible = append(visible, cmd)
+		}
+	}
+	return visible
+}
+
+// Names returns every name and alias a command can be run by, hidden ones
+// included, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Aliases maps each alias of a visible command to the command name
+func (r *Registry) Aliases() map[string]string {
+	aliases := make(map[string]string)
+	for _, cmd := range r.Commands() {
+		for _, alias := range cmd.Aliases {
+			aliases[alias] = cmd.Name
+		}
+	}
+	return aliases
+}
+
+// Suggest returns up to three visible command names or aliases, or any of
+// extra, that look like input: starting with it, or a typo or two away
+func (r *Registry) Suggest(input string, extra ...string) []string {
+	var names []string
+	for _, cmd := range r.Commands() {
+		names = append(names, cmd.Name)
+		names = append(names, cmd.Aliases...)
+	}
+	names = append(names, extra...)
+	return closestNames(input, names)
+}
+
+func closestNames(input string, names []string) []string {
+	input = strings.ToLower(input)
+	if input == "" {
+		return nil
+	}
+
+	// Short inputs get one typo, longer ones two
+	maxDistance := 1
+	if len(input) > 4 {
+		maxDistance = 2
+	}
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, input) {
+			matches = append(matches, match{name, 0})
+			continue
+		}
+		// A one-letter name is a typo away from almost anything
+		if distance := suggest.Distance(input, lower); distance <= maxDistance && len(lower) >= 2*distance {
+			matches = append(matches, match{name, distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < 3; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// Subcommand returns the subcommand with the given name, or nil
+func (c *Command) Subcommand(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// usages returns the usage lines of the command and its subcommands, each
+// listed once
+func (c *Command) usages() []string {
+	var lines []string
+	seen := make(map[string]bool)
+	var add func(cmd *Command)
+	add = func(cmd *Command) {
+		if cmd.Usage != "" && !seen[cmd.Usage] {
+			seen[cmd.Usage] = true
+			lines = append(lines, cmd.Usage)
+		}
+		for _, sub := range cmd.Subcommands {
+			add(sub)
+		}
+	}
+	add(c)
+	return lines
+}
+
+// FindFlag returns the flag with the given long or short name, or nil
+func (c *Command) FindFlag(name string) *Flag {
+	for i := range c.Flags {
+		if c.Flags[i].Name == name || (c.Flags[i].Short != "" && c.Flags[i].Short == name) {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+// Help returns the command's help page, listing the flags of its subcommands
+// after its own
+func (c *Command) Help() help.CommandHelp {
+	description := c.Description
+	if description == "" {
+		description = c.Summary
+	}
+
+	h := help.CommandHelp{
+		Name:        c.Name,
+		Usage:       strings.Join(c.usages(), "\n    "),
+		Description: description,
+		Examples:    c.Examples,
+		Aliases:     c.Aliases,
+		SeeAlso:     c.SeeAlso,
+	}
+
+	width := 0
+	for _, sub := range c.Subcommands {
+		width = max(width, len(sub.Name))
+	}
+	for _, sub := range c.Subcommands {
+		h.Subcommands = append(h.Subcommands, fmt.Sprintf("%-*s  %s", width, sub.Name, sub.Summary))
+	}
+
+	seen := make(map[string]bool)
+	var addFlags func(cmd *Command)
+	addFlags = func(cmd *Command) {
+		for _, flag := range cmd.Flags {
+			if seen[flag.Name] {
+				continue
+			}
+			seen[flag.Name] = true
+
+			name := flag.Name
+			if flag.HasValue && flag.ValueName != "" {
+				name += " <" + flag.ValueName + ">"
+			}
+			h.Flags = append(h.Flags, help.FlagHelp{
+				Flag:        name,
+				ShortFlag:   flag.Short,
+				Description: flag.Description,
+				Example:     flag.Example,
+			})
+		}
+		for _, sub := range cmd.Subcommands {
+			addFlags(sub)
+		}
+	}
+	addFlags(c)
+
+	return h
+}
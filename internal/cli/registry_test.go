@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testRegistry() *Registry {
+	return NewRegistry(
+		&Command{Name: "list", Aliases: []string{"ls"}, Summary: "List worktrees"},
+		&Command{Name: "go", Aliases: []string{"switch", "s"}, Summary: "Switch to a worktree"},
+		&Command{
+			Name:    "env",
+			Usage:   "wt env <subcommand>",
+			Summary: "Manage .env files",
+			Flags:   []Flag{{Name: "--all", Description: "Every worktree"}},
+			Subcommands: []*Command{
+				{
+					Name:    "sync",
+					Usage:   "wt env sync [branch]",
+					Summary: "Copy .env files",
+					Flags: []Flag{
+						{Name: "--all", Description: "Every worktree"},
+						{Name: "--fuzzy", Short: "-f", Description: "Pick interactively"},
+					},
+				},
+				{Name: "list", Summary: "List .env files"},
+			},
+		},
+		&Command{
+			Name:    "new",
+			Summary: "Create a worktree",
+			Flags:   []Flag{{Name: "--base", Description: "Base branch", HasValue: true, ValueName: "branch", Value: ArgBranch}},
+		},
+		&Command{Name: "__complete", Hidden: true},
+	)
+}
+
+func TestRegistry(t *testing.T) {
+	r := testRegistry()
+
+	t.Run("lookup by name and alias", func(t *testing.T) {
+		for _, name := range []string{"go", "switch", "s"} {
+			if cmd := r.Lookup(name); cmd == nil || cmd.Name != "go" {
+				t.Errorf("Lookup(%q) = %v, want go", name, cmd)
+			}
+		}
+		if r.Lookup("nope") != nil {
+			t.Error("Expected unknown command to be nil")
+		}
+		if r.Resolve("ls") != "list" || r.Resolve("nope") != "nope" {
+			t.Errorf("Resolve(ls) = %q, Resolve(nope) = %q", r.Resolve("ls"), r.Resolve("nope"))
+		}
+	})
+
+	t.Run("hidden commands are dispatchable but not listed", func(t *testing.T) {
+		if r.Lookup("__complete") == nil {
+			t.Error("Expected hidden command to be found")
+		}
+		for _, cmd := range r.Commands() {
+			if cmd.Hidden {
+				t.Errorf("Commands() returned hidden command %q", cmd.Name)
+			}
+		}
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		want := map[string]string{"ls": "list", "switch": "go", "s": "go"}
+		if got := r.Aliases(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Aliases() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("names include aliases and hidden commands", func(t *testing.T) {
+		names := r.Names()
+		for _, want := range []string{"go", "s", "ls", "__complete"} {
+			found := false
+			for _, name := range names {
+				found = found || name == want
+			}
+			if !found {
+				t.Errorf("Names() = %v, missing %q", names, want)
+			}
+		}
+	})
+
+	t.Run("duplicate names panic", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected a repeated alias to panic")
+			}
+		}()
+		NewRegistry(&Command{Name: "list"}, &Command{Name: "ls2", Aliases: []string{"list"}})
+	})
+}
+
+func TestRegistrySuggest(t *testing.T) {
+	r := testRegistry()
+
+	tests := []struct {
+		input string
+		extra []string
+		want  []string
+	}{
+		{"lsit", nil, []string{"list"}},
+		{"swtich", nil, []string{"switch"}},
+		{"sw", nil, []string{"switch"}},
+		{"en", nil, []string{"env"}},
+		{"ap", []string{"api"}, []string{"api"}},
+		{"__complet", nil, nil},
+		{"zzzzzz", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := r.Suggest(tt.input, tt.extra...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	r := testRegistry()
+
+	envHelp := r.Lookup("env").Help()
+	if envHelp.Description != "Manage .env files" {
+		t.Errorf("Description = %q, want the summary", envHelp.Description)
+	}
+	if want := "wt env <subcommand>\n    wt env sync [branch]"; envHelp.Usage != want {
+		t.Errorf("Usage = %q, want the subcommand usages after the command's", envHelp.Usage)
+	}
+	wantSubcommands := []string{"sync  Copy .env files", "list  List .env files"}
+	if !reflect.DeepEqual(envHelp.Subcommands, wantSubcommands) {
+		t.Errorf("Subcommands = %q, want %q", envHelp.Subcommands, wantSubcommands)
+	}
+
+	// Subcommand flags follow the command's own, without repeats
+	var flags []string
+	for _, flag := range envHelp.Flags {
+		flags = append(flags, flag.Flag+" "+flag.ShortFlag)
+	}
+	if want := []string{"--all ", "--fuzzy -f"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("Flags = %q, want %q", flags, want)
+	}
+
+	if got := r.Lookup("new").Help().Flags[0].Flag; got != "--base <branch>" {
+		t.Errorf("value flag rendered as %q, want %q", got, "--base <branch>")
+	}
+
+	if cmd := r.Lookup("env").Subcommand("sync"); cmd == nil || cmd.FindFlag("-f") == nil {
+		t.Error("Expected env sync to have -f")
+	}
+}
@@ -0,0 +1,247 @@
+package completion
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/cli"
+)
+
+// CompleteCommand is the hidden command the shell scripts call for candidates
+const CompleteCommand = "__complete"
+
+// Candidate is a single completion value with an optional description
+type Candidate struct {
+	Value       string
+	Description string
+}
+
+// String formats the candidate as the shell scripts expect: the value, then a
+// tab and the description if there is one
+func (c Candidate) String() string {
+	if c.Description == "" {
+		return c.Value
+	}
+	return c.Value + "\t" + c.Description
+}
+
+// Complete returns the candidates for the last of words, the arguments after
+// 'wt' on the command line. The last word is the one being completed and may
+// be empty.
+func (d *CompletionData) Complete(words []string) []Candidate {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+
+	if len(words) == 1 {
+		return filterCandidates(d.commandCandidates(), cur)
+	}
+
+	cmd := d.GetCommandByName(words[0])
+	if cmd == nil {
+		return nil
+	}
+
+	// Descend into subcommands named on the command line
+	args := words[1 : len(words)-1]
+	for len(cmd.Subcommands) > 0 {
+		i := firstPositional(cmd, args)
+		if i < 0 {
+			break
+		}
+		if cmd = cmd.Subcommand(args[i]); cmd == nil {
+			return nil
+		}
+		args = args[i+1:]
+	}
+
+	// Value for a flag that takes one
+	if len(args) > 0 {
+		if flag := cmd.FindFlag(args[len(args)-1]); flag != nil && flag.HasValue {
+			return filterCandidates(d.typeCandidates(flag.Value, flag.Values), cur)
+		}
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		var candidates []Candidate
+		for _, flag := range cmd.Flags {
+			candidates = append(candidates, Candidate{Value: flag.Name, Description: flag.Description})
+		}
+		return filterCandidates(candidates, cur)
+	}
+
+	if len(cmd.Subcommands) > 0 {
+		var candidates []Candidate
+		for _, sub := range cmd.Subcommands {
+			candidates = append(candidates, Candidate{Value: sub.Name, Description: sub.Summary})
+		}
+		return filterCandidates(candidates, cur)
+	}
+
+	position := countPositional(cmd, args)
+	if position >= len(cmd.Args) {
+		return nil
+	}
+	arg := cmd.Args[position]
+	return filterCandidates(d.typeCandidates(arg.Type, arg.Values), cur)
+}
+
+// commandCandidates lists core commands, aliases and project commands
+func (d *CompletionData) commandCandidates() []Candidate {
+	var candidates []Candidate
+	for _, cmd := range d.Commands {
+		candidates = append(candidates, Candidate{Value: cmd.Name, Description: cmd.Summary})
+	}
+	for _, alias := range sortedAliases(d.Aliases) {
+		target := d.Aliases[alias]
+		if targetCmd := d.GetCommandByName(target); targetCmd != nil {
+			candidates = append(candidates, Candidate{
+				Value:       alias,
+				Description: fmt.Sprintf("Alias for %s - %s", target, targetCmd.Summary),
+			})
+		}
+	}
+	for _, name := range d.ProjectCommands {
+		description := d.projectDescriptions[name]
+		if description == "" {
+			description = "Project-specific command"
+		}
+		candidates = append(candidates, Candidate{Value: name, Description: description})
+	}
+	return candidates
+}
+
+// typeCandidates returns the candidates for a value of the given type, using
+// values for ArgChoice
+func (d *CompletionData) typeCandidates(argType cli.ArgumentType, values []string) []Candidate {
+	switch argType {
+	case cli.ArgBranch:
+		return branchCandidates()
+	case cli.ArgWorktreeBranch:
+		return worktreeCandidates()
+	case cli.ArgProject:
+		return valueCandidates(d.ProjectCommands, "Project-specific command")
+	case cli.ArgChoice:
+		return valueCandidates(values, "")
+	default:
+		return nil
+	}
+}
+
+// worktreeCandidates lists branches checked out in a worktree, described by path
+func worktreeCandidates() []Candidate {
+	output, err := exec.Command("git", "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil
+	}
+
+	var candidates []Candidate
+	var path string
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			path = strings.TrimPrefix(line, "worktree ")
+		case strings.HasPrefix(line, "branch "):
+			branch := strings.TrimPrefix(strings.TrimPrefix(line, "branch "), "refs/heads/")
+			candidates = append(candidates, Candidate{Value: branch, Description: path})
+		}
+	}
+	return candidates
+}
+
+// branchCandidates lists local branches followed by remote-tracking branches
+func branchCandidates() []Candidate {
+	output, err := exec.Command("git", "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes").Output()
+	if err != nil {
+		return nil
+	}
+	return parseBranchRefs(string(output))
+}
+
+// parseBranchRefs turns full ref names into branch candidates, skipping
+// symbolic remote HEADs
+func parseBranchRefs(output string) []Candidate {
+	var local, remote []Candidate
+	for _, ref := range strings.Split(strings.TrimSpace(output), "\n") {
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			local = append(local, Candidate{Value: strings.TrimPrefix(ref, "refs/heads/"), Description: "Local branch"})
+		case strings.HasPrefix(ref, "refs/remotes/") && !strings.HasSuffix(ref, "/HEAD"):
+			remote = append(remote, Candidate{Value: strings.TrimPrefix(ref, "refs/remotes/"), Description: "Remote branch"})
+		}
+	}
+	return append(local, remote...)
+}
+
+// positionalIndexes returns the indexes of the positional arguments in words,
+// skipping flags and the values of flags that take one
+func positionalIndexes(cmd *cli.Command, words []string) []int {
+	var indexes []int
+	for i := 0; i < len(words); i++ {
+		if strings.HasPrefix(words[i], "-") {
+			if flag := cmd.FindFlag(words[i]); flag != nil && flag.HasValue {
+				i++
+			}
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+func countPositional(cmd *cli.Command, words []string) int {
+	return len(positionalIndexes(cmd, words))
+}
+
+// firstPositional returns the index of the first positional argument in
+// words, or -1 if there is none
+func firstPositional(cmd *cli.Command, words []string) int {
+	if indexes := positionalIndexes(cmd, words); len(indexes) > 0 {
+		return indexes[0]
+	}
+	return -1
+}
+
+func valueCandidates(values []string, description string) []Candidate {
+	candidates := make([]Candidate, 0, len(values))
+	for _, value := range values {
+		candidates = append(candidates, Candidate{Value: value, Description: description})
+	}
+	return candidates
+}
+
+// filterCandidates keeps the candidates starting with prefix, dropping duplicates
+func filterCandidates(candidates []Candidate, prefix string) []Candidate {
+	seen := make(map[string]bool)
+	var filtered []Candidate
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(candidate.Value, prefix) || seen[candidate.Value] {
+			continue
+		}
+		seen[candidate.Value] = true
+		filtered = append(filtered, candidate)
+	}
+	return filtered
+}
+
+func sortedAliases(aliases map[string]string) []string {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedProjectCommands returns the project command names in order
+func sortedProjectCommands(commands map[string]string) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
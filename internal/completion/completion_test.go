@@ -4,50 +4,101 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/tobiase/worktree-utils/internal/cli"
 	"github.com/tobiase/worktree-utils/internal/config"
 )
 
-func TestGetCompletionData(t *testing.T) {
-	data := GetCompletionData(nil)
-
-	// Test core commands are present
-	expectedCommands := []string{
-		"list", "add", "rm", "integrate", "go", "new", "env-copy",
-		"project", "setup", "update", "version", "help",
-		"completion", "shell-init",
-	}
-
-	if len(data.Commands) < len(expectedCommands) {
-		t.Errorf("Expected at least %d commands, got %d", len(expectedCommands), len(data.Commands))
-	}
-
-	commandNames := make(map[string]bool)
-	for _, cmd := range data.Commands {
-		commandNames[cmd.Name] = true
-	}
-
-	for _, expected := range expectedCommands {
-		if !commandNames[expected] {
-			t.Errorf("Expected command '%s' not found", expected)
-		}
-	}
-
-	// Test aliases
-	expectedAliases := map[string]string{
-		"ls":     "list",
-		"switch": "go",
-		"s":      "go",
-	}
-
-	for alias, target := range expectedAliases {
-		if data.Aliases[alias] != target {
-			t.Errorf("Expected alias '%s' -> '%s', got '%s'", alias, target, data.Aliases[alias])
-		}
-	}
+// testRegistry returns a small command set exercising aliases, flag values
+// and nested subcommands
+func testRegistry() *cli.Registry {
+	shells := []string{"bash", "zsh", "fish", "nu", "sh"}
+	return cli.NewRegistry(
+		&cli.Command{Name: "list", Aliases: []string{"ls"}, Summary: "List all worktrees"},
+		&cli.Command{
+			Name:    "go",
+			Aliases: []string{"switch", "s"},
+			Summary: "Switch to a worktree",
+			Args:    []cli.Argument{{Name: "branch", Type: cli.ArgWorktreeBranch}},
+		},
+		&cli.Command{
+			Name:    "rm",
+			Summary: "Remove a worktree",
+			Flags: []cli.Flag{
+				{Name: "--branch", Description: "Remove the associated Git branch"},
+				{Name: "--force", Description: "Force branch deletion"},
+			},
+		},
+		&cli.Command{
+			Name:    "new",
+			Summary: "Create and switch to a new worktree",
+			Flags:   []cli.Flag{{Name: "--base", HasValue: true, Value: cli.ArgBranch}},
+			Args:    []cli.Argument{{Name: "branch", Type: cli.ArgString}},
+		},
+		&cli.Command{
+			Name:    "recent",
+			Summary: "Show recently active branches",
+			Flags:   []cli.Flag{{Name: "-n", HasValue: true}},
+		},
+		&cli.Command{
+			Name:    "setup",
+			Summary: "Install wt",
+			Flags: []cli.Flag{
+				{Name: "--completion", HasValue: true, Value: cli.ArgChoice, Values: []string{"auto", "bash", "zsh", "fish", "none"}},
+			},
+		},
+		&cli.Command{
+			Name:    "completion",
+			Summary: "Generate shell completion scripts",
+			Args:    []cli.Argument{{Name: "shell", Type: cli.ArgChoice, Values: []string{"bash", "zsh", "fish"}}},
+		},
+		&cli.Command{
+			Name:    "shell-init",
+			Summary: "Output shell initialization code",
+			Flags:   []cli.Flag{{Name: "--shell", HasValue: true, Value: cli.ArgChoice, Values: shells}},
+			Args:    []cli.Argument{{Name: "shell", Type: cli.ArgChoice, Values: shells}},
+		},
+		&cli.Command{
+			Name:    "env",
+			Summary: "Manage .env files across worktrees",
+			Subcommands: []*cli.Command{
+				{
+					Name:    "sync",
+					Summary: "Copy .env files to another worktree",
+					Flags:   []cli.Flag{{Name: "--recursive", Description: "Include .env files in subdirectories"}},
+					Args:    []cli.Argument{{Name: "branch", Type: cli.ArgWorktreeBranch}},
+				},
+				{Name: "diff", Summary: "Compare .env files with another worktree"},
+				{Name: "list", Summary: "List .env files in the current worktree"},
+			},
+		},
+		&cli.Command{
+			Name:    "project",
+			Summary: "Project configuration commands",
+			Subcommands: []*cli.Command{
+				{Name: "init", Summary: "Create a project config", Args: []cli.Argument{{Name: "name", Type: cli.ArgString}}},
+				{
+					Name:    "setup",
+					Summary: "Worktree setup automation",
+					Subcommands: []*cli.Command{
+						{Name: "run", Summary: "Run the setup steps"},
+						{Name: "show", Summary: "Show the setup steps"},
+					},
+				},
+			},
+		},
+		&cli.Command{
+			Name:    "venv",
+			Summary: "Manage shared virtualenvs",
+			Subcommands: []*cli.Command{
+				{Name: "gc", Summary: "Remove unused virtualenvs", Flags: []cli.Flag{{Name: "--dry-run", Short: "-n", Description: "List only"}}},
+			},
+		},
+		&cli.Command{Name: "__complete", Hidden: true},
+	)
 }
 
 func TestGetCommandByName(t *testing.T) {
-	data := GetCompletionData(nil)
+	data := GetCompletionData(testRegistry(), nil)
 
 	testCases := []struct {
 		name        string
@@ -82,11 +133,11 @@ func TestGetCommandByName(t *testing.T) {
 }
 
 func TestGetAllCommandNames(t *testing.T) {
-	data := GetCompletionData(nil)
+	data := GetCompletionData(testRegistry(), nil)
 	names := data.GetAllCommandNames()
 
 	// Should include core commands
-	expectedNames := []string{"list", "add", "rm", "go", "new", "completion"}
+	expectedNames := []string{"list", "rm", "go", "new", "completion"}
 	for _, expected := range expectedNames {
 		found := false
 		for _, name := range names {
@@ -117,7 +168,7 @@ func TestGetAllCommandNames(t *testing.T) {
 }
 
 func TestNormalizeCommandName(t *testing.T) {
-	data := GetCompletionData(nil)
+	data := GetCompletionData(testRegistry(), nil)
 
 	testCases := []struct {
 		input    string
@@ -170,216 +221,129 @@ func TestSplitCompletionLine(t *testing.T) {
 	}
 }
 
-func TestGenerateBashCompletion(t *testing.T) {
-	data := GetCompletionData(nil)
-	bash := GenerateBashCompletion(nil)
-
-	// Test basic structure
-	requiredParts := []string{
-		"#!/bin/bash",
-		"_wt_completion()",
-		"_init_completion",
-		"complete -F _wt_completion wt",
-		"_wt_complete_branches()",
-	}
-
-	for _, part := range requiredParts {
-		if !strings.Contains(bash, part) {
-			t.Errorf("Bash completion missing required part: %s", part)
-		}
-	}
-
-	// Test that all commands are included
-	for _, cmd := range data.Commands {
-		if !strings.Contains(bash, cmd.Name) {
-			t.Errorf("Bash completion missing command: %s", cmd.Name)
-		}
-	}
-
-	// Test that aliases are included
-	for alias := range data.Aliases {
-		if !strings.Contains(bash, alias) {
-			t.Errorf("Bash completion missing alias: %s", alias)
-		}
-	}
-}
-
-func TestGenerateZshCompletion(t *testing.T) {
-	data := GetCompletionData(nil)
-	zsh := GenerateZshCompletion(nil)
-
-	// Test basic structure
-	requiredParts := []string{
-		"#compdef wt",
-		"_wt()",
-		"_arguments -C",
-		"_wt_commands()",
-		"_wt_branches()",
-	}
-
-	for _, part := range requiredParts {
-		if !strings.Contains(zsh, part) {
-			t.Errorf("Zsh completion missing required part: %s", part)
-		}
-	}
-
-	// Test that commands are included with descriptions
-	for _, cmd := range data.Commands {
-		// Check command name is present
-		if !strings.Contains(zsh, cmd.Name) {
-			t.Errorf("Zsh completion missing command: %s", cmd.Name)
-		}
-		// Check description is present
-		if cmd.Description != "" && !strings.Contains(zsh, cmd.Description) {
-			t.Errorf("Zsh completion missing description for %s: %s", cmd.Name, cmd.Description)
-		}
-	}
-}
-
-func TestCommandFlags(t *testing.T) {
-	data := GetCompletionData(nil)
-
-	// Test specific commands have expected flags
-	testCases := []struct {
-		command       string
-		expectedFlags []string
+func TestGenerateShellShims(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        string
+		requiredParts []string
 	}{
-		{"new", []string{"--base"}},
-		{"env-copy", []string{"--recursive"}},
-		{"setup", []string{"--check", "--uninstall"}},
-		{"update", []string{"--check", "--force"}},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.command, func(t *testing.T) {
-			cmd := data.GetCommandByName(tc.command)
-			if cmd == nil {
-				t.Fatalf("Command '%s' not found", tc.command)
-			}
-
-			flagNames := make([]string, len(cmd.Flags))
-			for i, flag := range cmd.Flags {
-				flagNames[i] = flag.Name
-			}
-
-			for _, expectedFlag := range tc.expectedFlags {
-				found := false
-				for _, flag := range flagNames {
-					if flag == expectedFlag {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("Command '%s' missing expected flag: %s", tc.command, expectedFlag)
+		{
+			name:   "bash",
+			script: GenerateBashCompletion(),
+			requiredParts: []string{
+				"#!/bin/bash",
+				"_wt_completion()",
+				`"${WT_BIN:-wt-bin}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}"`,
+				"complete -o default -F _wt_completion wt",
+			},
+		},
+		{
+			name:   "zsh",
+			script: GenerateZshCompletion(),
+			requiredParts: []string{
+				"#compdef wt",
+				"_wt()",
+				`"${WT_BIN:-wt-bin}" __complete "${(@)words[2,CURRENT]}"`,
+				"_describe",
+				"_files",
+				"compdef _wt wt",
+			},
+		},
+		{
+			name:   "fish",
+			script: GenerateFishCompletion(),
+			requiredParts: []string{
+				"function __wt_complete",
+				"$wt_bin __complete $tokens[2..-1]",
+				"__fish_complete_path (commandline -ct)",
+				"complete -c wt -e",
+				"complete -c wt -f -a '(__wt_complete)'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, part := range tt.requiredParts {
+				if !strings.Contains(tt.script, part) {
+					t.Errorf("%s completion missing required part: %s", tt.name, part)
 				}
 			}
-		})
-	}
-}
-
-func TestCommandArguments(t *testing.T) {
-	data := GetCompletionData(nil)
-
-	// Test commands that should have worktree branch arguments
-	worktreeBranchCommands := []string{"rm", "go", "env-copy"}
-	for _, cmdName := range worktreeBranchCommands {
-		t.Run(cmdName, func(t *testing.T) {
-			cmd := data.GetCommandByName(cmdName)
-			if cmd == nil {
-				t.Fatalf("Command '%s' not found", cmdName)
-			}
-
-			if len(cmd.Args) == 0 {
-				t.Errorf("Command '%s' should have arguments", cmdName)
-				return
-			}
-
-			firstArg := cmd.Args[0]
-			if firstArg.Type != ArgWorktreeBranch && cmdName != "go" { // go is optional
-				t.Errorf("Command '%s' first argument should be worktree branch type, got %v", cmdName, firstArg.Type)
+			// Candidates come from __complete, never from the generated script
+			if strings.Contains(tt.script, "git branch") || strings.Contains(tt.script, "wt list") {
+				t.Errorf("%s completion should not parse branches itself:\n%s", tt.name, tt.script)
 			}
 		})
 	}
 }
 
-func TestGetCompletionCandidates(t *testing.T) {
-	// Create test data with mock branches
-	data := &CompletionData{
-		AvailableBranches: []string{"main", "feature-1", "feature-2"},
-		ProjectCommands:   []string{"api", "frontend", "backend"},
-	}
+func TestComplete(t *testing.T) {
+	data := GetCompletionData(testRegistry(), nil)
+	data.ProjectCommands = []string{"api", "dash"}
+	data.projectDescriptions = map[string]string{"api": "Go to API"}
 
-	testCases := []struct {
-		argType  ArgumentType
-		expected []string
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
 	}{
-		{ArgBranch, []string{"main", "feature-1", "feature-2"}},
-		{ArgProject, []string{"api", "frontend", "backend"}},
-		{ArgString, []string{}},
-		{ArgFile, []string{}},
-		// Note: ArgWorktreeBranch is tested separately since it calls git commands
-	}
-
-	for _, tc := range testCases {
-		t.Run(string(rune(tc.argType)), func(t *testing.T) {
-			result := data.GetCompletionCandidates([]string{}, tc.argType)
-
-			if len(result) != len(tc.expected) {
-				t.Errorf("Expected %d candidates, got %d", len(tc.expected), len(result))
-				return
+		{"commands by prefix", []string{"sh"}, []string{"shell-init\tOutput shell initialization code"}},
+		{"alias with description", []string{"swi"}, []string{"switch\tAlias for go - Switch to a worktree"}},
+		{"project commands", []string{"a"}, []string{"api\tGo to API"}},
+		{"hidden commands", []string{"__"}, nil},
+		{"project command without description", []string{"da"}, []string{"dash\tProject-specific command"}},
+		{"flags", []string{"rm", "--b"}, []string{"--branch\tRemove the associated Git branch"}},
+		{"subcommand values", []string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		{"flag values", []string{"shell-init", "--shell", "n"}, []string{"nu"}},
+		{"past the last argument", []string{"completion", "bash", ""}, nil},
+		{"value flags are skipped when counting arguments", []string{"shell-init", "--shell", "fish", ""}, []string{"bash", "zsh", "fish", "nu", "sh"}},
+		{"unknown command", []string{"nope", ""}, nil},
+		{"choice flag values", []string{"setup", "--completion", ""}, []string{"auto", "bash", "zsh", "fish", "none"}},
+		{"free-form flag value", []string{"recent", "-n", ""}, nil},
+		{"subcommands", []string{"env", ""}, []string{
+			"sync\tCopy .env files to another worktree",
+			"diff\tCompare .env files with another worktree",
+			"list\tList .env files in the current worktree",
+		}},
+		{"subcommands by prefix", []string{"project", "s"}, []string{"setup\tWorktree setup automation"}},
+		{"nested subcommands", []string{"project", "setup", ""}, []string{
+			"run\tRun the setup steps",
+			"show\tShow the setup steps",
+		}},
+		{"subcommand flags", []string{"env", "sync", "--r"}, []string{"--recursive\tInclude .env files in subdirectories"}},
+		{"flags of a subcommand", []string{"venv", "gc", "--"}, []string{"--dry-run\tList only"}},
+		{"past the last subcommand argument", []string{"project", "init", "myapp", ""}, nil},
+		{"unknown subcommand", []string{"env", "nope", ""}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, candidate := range data.Complete(tt.words) {
+				got = append(got, candidate.String())
 			}
-
-			for i, expected := range tc.expected {
-				if result[i] != expected {
-					t.Errorf("Expected candidate %d to be '%s', got '%s'", i, expected, result[i])
-				}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Complete(%q) = %q, want %q", tt.words, got, tt.want)
 			}
 		})
 	}
-
-	// Test ArgWorktreeBranch separately (may fail if not in git repo)
-	t.Run("ArgWorktreeBranch", func(t *testing.T) {
-		result := data.GetCompletionCandidates([]string{}, ArgWorktreeBranch)
-		// In test environment, this might return empty list due to git command failure
-		// That's expected and acceptable - we just need to ensure it's not nil
-		if result == nil {
-			t.Error("Expected slice (even if empty), got nil")
-		}
-		// If we get a slice, it's valid regardless of length
-		t.Logf("Got %d worktree branches", len(result))
-	})
 }
 
-func TestParseWorktreeBranches(t *testing.T) {
-	testOutput := `worktree /Users/user/repo
-HEAD 1234567
-
-worktree /Users/user/repo-worktrees/feature-1
-HEAD 2345678
-branch refs/heads/feature-1
+func TestParseBranchRefs(t *testing.T) {
+	output := "refs/heads/feature\nrefs/heads/main\nrefs/remotes/origin/HEAD\nrefs/remotes/origin/main\nrefs/remotes/origin/review\n"
 
-worktree /Users/user/repo-worktrees/feature-2
-HEAD 3456789
-branch refs/heads/feature-2
-
-worktree /Users/user/repo-worktrees/main-copy
-HEAD 4567890
-branch refs/heads/main
-`
-
-	result := parseWorktreeBranches(testOutput)
-	expected := []string{"feature-1", "feature-2", "main"}
-
-	if len(result) != len(expected) {
-		t.Errorf("Expected %d branches, got %d: %v", len(expected), len(result), result)
-		return
+	var got []string
+	for _, candidate := range parseBranchRefs(output) {
+		got = append(got, candidate.String())
 	}
-
-	for i, expectedBranch := range expected {
-		if result[i] != expectedBranch {
-			t.Errorf("Expected branch %d to be '%s', got '%s'", i, expectedBranch, result[i])
-		}
+	want := []string{
+		"feature\tLocal branch",
+		"main\tLocal branch",
+		"origin/main\tRemote branch",
+		"origin/review\tRemote branch",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parseBranchRefs() = %q, want %q", got, want)
 	}
 }
 
@@ -392,22 +356,11 @@ func TestProjectCommandsIntegration(t *testing.T) {
 
 	// For testing, we'll manually set the current project
 	// This is a bit of a hack since we can't easily create a full project setup
-	data := GetCompletionData(configMgr)
+	data := GetCompletionData(testRegistry(), configMgr)
 
 	// Verify that completion data structure supports project commands
 	// Note: ProjectCommands will be empty slice when no project is loaded, which is expected
 
-	// Test completion generation includes project command handling
-	bash := GenerateBashCompletion(configMgr)
-	if !strings.Contains(bash, "_wt_complete_project_commands") && len(data.ProjectCommands) == 0 {
-		t.Log("No project commands found (expected when no project is loaded)")
-	}
-
-	zsh := GenerateZshCompletion(configMgr)
-	if !strings.Contains(zsh, "_wt_commands") {
-		t.Error("Zsh completion should contain command completion function")
-	}
-
 	// Verify the structure can handle project commands when they exist
 	testData := &CompletionData{
 		Commands:        data.Commands,
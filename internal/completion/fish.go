@@ -0,0 +1,35 @@
+package completion
+
+import "strings"
+
+// GenerateFishCompletion generates a fish completion script that asks
+// 'wt-bin __complete' for candidates. Fish reads the value<TAB>description
+// lines natively.
+func GenerateFishCompletion() string {
+	var builder strings.Builder
+
+	// Header
+	builder.WriteString("# Fish completion for wt (worktree-utils)\n")
+	builder.WriteString("# Generated automatically - do not edit manually\n")
+	builder.WriteString("# Load with: wt completion fish | source\n\n")
+
+	builder.WriteString("function __wt_complete\n")
+	builder.WriteString("    set -l wt_bin wt-bin\n")
+	builder.WriteString("    set -q WT_BIN; and set wt_bin $WT_BIN\n")
+	builder.WriteString("    set -l tokens (commandline -opc) (commandline -ct)\n")
+	builder.WriteString("    set -l candidates ($wt_bin " + CompleteCommand + " $tokens[2..-1] 2>/dev/null)\n")
+	builder.WriteString("    if test (count $candidates) -gt 0\n")
+	builder.WriteString("        printf '%s\\n' $candidates\n")
+	builder.WriteString("    else\n")
+	builder.WriteString("        # Nothing from wt, e.g. for a file argument: complete paths\n")
+	builder.WriteString("        __fish_complete_path (commandline -ct)\n")
+	builder.WriteString("    end\n")
+	builder.WriteString("end\n\n")
+
+	// Clear previously loaded completions; __wt_complete falls back to paths
+	// itself, so fish's own file completion stays off
+	builder.WriteString("complete -c wt -e\n")
+	builder.WriteString("complete -c wt -f -a '(__wt_complete)'\n")
+
+	return builder.String()
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -18,23 +21,43 @@ type NavigationCommand struct {
 
 // ProjectConfig represents configuration for a specific project
 type ProjectConfig struct {
-	Name       string                       `yaml:"name"`
-	Match      ProjectMatch                 `yaml:"match"`
-	Commands   map[string]NavigationCommand `yaml:"commands"`
-	Settings   ProjectSettings              `yaml:"settings"`
+	Name       string                       `yaml:"name,omitempty"`
+	Extends    string                       `yaml:"extends,omitempty"` // Profile name or file this config builds on
+	Include    []string                     `yaml:"include,omitempty"` // Profiles merged in order after extends
+	Match      ProjectMatch                 `yaml:"match,omitempty"`
+	Commands   map[string]NavigationCommand `yaml:"commands,omitempty"`
+	Settings   ProjectSettings              `yaml:"settings,omitempty"`
 	Virtualenv *VirtualenvConfig            `yaml:"virtualenv,omitempty"`
 	Setup      *SetupConfig                 `yaml:"setup,omitempty"`
+	Runtimes   []RuntimeConfig              `yaml:"runtimes,omitempty"`
+	Env        map[string]string            `yaml:"env,omitempty"` // Exported by 'wt go'; may reference $WT_BRANCH, $WT_WORKTREE, $WT_PROJECT
+	OnEnter    *EnterHook                   `yaml:"on_enter,omitempty"`
+	OnLeave    *LeaveHook                   `yaml:"on_leave,omitempty"`
+}
+
+// EnterHook describes what 'wt go' applies in the shell after entering a worktree
+type EnterHook struct {
+	Virtualenv bool              `yaml:"virtualenv,omitempty"` // Activate the worktree's virtualenv
+	Runtimes   bool              `yaml:"runtimes,omitempty"`   // Activate the project's runtimes
+	Env        map[string]string `yaml:"env,omitempty"`        // Exported alongside the project env block
+	Run        []string          `yaml:"run,omitempty"`        // Shell commands evaluated in the calling shell
+}
+
+// LeaveHook describes what 'wt go' runs in the shell before leaving a worktree
+type LeaveHook struct {
+	Run []string `yaml:"run,omitempty"` // Shell commands evaluated in the calling shell
 }
 
 // ProjectMatch defines how to match a project
 type ProjectMatch struct {
-	Paths   []string `yaml:"paths"`
-	Remotes []string `yaml:"remotes"`
+	Paths   []string `yaml:"paths,omitempty"`
+	Remotes []string `yaml:"remotes,omitempty"`
 }
 
 // ProjectSettings contains project-specific settings
 type ProjectSettings struct {
-	WorktreeBase string `yaml:"worktree_base"`
+	WorktreeBase string `yaml:"worktree_base,omitempty"`
+	SyncStrategy string `yaml:"sync_strategy,omitempty"` // "rebase" (default) or "merge" for wt sync
 }
 
 // VirtualenvConfig contains virtualenv configuration
@@ -42,6 +65,15 @@ type VirtualenvConfig struct {
 	Name         string `yaml:"name"`                    // Directory name (e.g., .venv, venv)
 	Python       string `yaml:"python,omitempty"`        // Python executable (defaults to python3)
 	AutoCommands bool   `yaml:"auto_commands,omitempty"` // Auto-add venv commands
+	Shared       bool   `yaml:"shared,omitempty"`        // Link worktrees to a venv cached by lockfile hash
+}
+
+// RuntimeConfig describes a per-worktree language runtime
+type RuntimeConfig struct {
+	Type    string `yaml:"type"`              // venv, uv, poetry, conda or node
+	Name    string `yaml:"name,omitempty"`    // Environment directory (venv, uv) or conda env name (defaults to the worktree directory name)
+	Python  string `yaml:"python,omitempty"`  // Python executable or version
+	Version string `yaml:"version,omitempty"` // Node version (defaults to .nvmrc or .node-version)
 }
 
 // CopyFileConfig represents a file copy operation during worktree setup
@@ -61,17 +93,49 @@ type SetupConfig struct {
 	CopyFiles         []CopyFileConfig `yaml:"copy_files,omitempty"`
 	Commands          []SetupCommand   `yaml:"commands,omitempty"`
 	CreateDirectories []string         `yaml:"create_directories,omitempty"`
+	CreateRuntimes    bool             `yaml:"create_runtimes,omitempty"` // Create the project's runtimes in new worktrees
 }
 
-// Config represents the global wt configuration
+// Config represents the global wt configuration in ~/.config/wt/config.yaml
 type Config struct {
-	Projects map[string]string `yaml:"projects"` // name -> path to project config
+	Projects map[string]string `yaml:"projects,omitempty"` // name -> path to project config
+	Update   UpdateSettings    `yaml:"update,omitempty"`
+}
+
+// UpdateSettings controls the background update check
+type UpdateSettings struct {
+	Notify        bool   `yaml:"notify,omitempty"`         // Check for new releases in the background and print a notice
+	Channel       string `yaml:"channel,omitempty"`        // Release channel to watch: stable (default) or beta
+	CheckInterval string `yaml:"check_interval,omitempty"` // Minimum time between checks, e.g. "12h" (default 24h)
+
+	Source   UpdateSource `yaml:"source,omitempty"`    // Where releases are published (default: GitHub)
+	Proxy    string       `yaml:"proxy,omitempty"`     // HTTP proxy for update requests
+	CABundle string       `yaml:"ca_bundle,omitempty"` // PEM file of extra certificate authorities to trust
+}
+
+// UpdateSource points wt update at a mirror or self-hosted release server
+type UpdateSource struct {
+	Type string `yaml:"type,omitempty"` // github, gitea, gitlab or manifest
+	URL  string `yaml:"url,omitempty"`  // API base URL, or the manifest URL
+	Repo string `yaml:"repo,omitempty"` // owner/name, or the GitLab project path or ID
 }
 
+// globalConfigFile is the global configuration file in the config directory
+const globalConfigFile = "config.yaml"
+
+// ProjectSchemaURL is the published JSON Schema for project configs
+const ProjectSchemaURL = "https://raw.githubusercontent.com/tobiase/worktree-utils/main/schema/project.schema.json"
+
 // Manager handles configuration loading and project detection
 type Manager struct {
 	configDir      string
 	currentProject *ProjectConfig
+	currentFile    string // Config file currentProject was loaded from
+
+	repoConfig     *RepoConfig    // .wt.yaml merged into currentProject
+	trustedProject *ProjectConfig // currentProject with the .wt.yaml commands, applied once trusted
+
+	coreCommands []string // Built-in command names a .wt.yaml or profile must not reuse
 }
 
 // NewManager creates a new configuration manager
@@ -87,86 +151,241 @@ func NewManager() (*Manager, error) {
 	}, nil
 }
 
-// LoadProject loads configuration for the current directory
+// SetCoreCommands sets the built-in command names and aliases that project
+// commands in a .wt.yaml or profile are checked against, as 'wt project
+// validate' does
+func (m *Manager) SetCoreCommands(names []string) {
+	m.coreCommands = names
+}
+
+// LoadProject loads configuration for the current directory. A .wt.yaml
+// committed in the repository is the base that the matching personal config
+// in the projects directory overrides. Shell commands from .wt.yaml are left
+// out until the user trusts them; see TrustRepoConfig.
 func (m *Manager) LoadProject(currentPath string, gitRemote string) error {
 	// Ensure config directory exists
-	projectsDir := filepath.Join(m.configDir, "projects")
+	projectsDir := m.projectsDir()
 	if err := os.MkdirAll(projectsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %v", err)
 	}
 
+	m.currentProject, m.currentFile = nil, ""
+	m.repoConfig, m.trustedProject = nil, nil
+
 	// Load all project configs and find a match
-	entries, err := os.ReadDir(projectsDir)
+	files, err := m.ListProjects()
+	if err != nil {
+		return err
+	}
+
+	// A config that would match but is invalid is reported unless another
+	// config matches
+	var personal *ProjectConfig
+	var invalid error
+	for _, file := range files {
+		if file.Err != nil {
+			if invalid == nil && file.Project != nil && m.matchesProject(file.Project, currentPath, gitRemote) {
+				invalid = file.Err
+			}
+			continue // Skip invalid configs
+		}
+
+		if m.matchesProject(file.Project, currentPath, gitRemote) {
+			personal = file.Project
+			m.currentFile = file.Path
+			invalid = nil
+			break
+		}
+	}
+
+	// An invalid .wt.yaml is reported, but the personal config still applies
+	repo, repoPath, err := m.loadRepoConfig(currentPath)
+	if err != nil && invalid == nil {
+		invalid = err
+	}
+
+	switch {
+	case repo != nil:
+		m.applyRepoConfig(repo, repoPath, personal)
+	case personal != nil:
+		m.setCurrentProject(personal)
+	}
+	return invalid
+}
+
+// applyRepoConfig makes repo, overridden by personal if not nil, the current
+// project, holding back its shell commands unless they are trusted
+func (m *Manager) applyRepoConfig(repo *ProjectConfig, path string, personal *ProjectConfig) {
+	info := &RepoConfig{
+		Path:     path,
+		Commands: repoCommands(repo),
+		repoDir:  filepath.Dir(path),
+	}
+	info.hash = commandsHash(info.Commands)
+	if len(info.Commands) == 0 {
+		info.Trusted = true
+	} else {
+		info.Trusted, info.Declined = m.trustState(info)
+	}
+	m.repoConfig = info
+	if m.currentFile == "" {
+		m.currentFile = path
+	}
+
+	override := personal
+	if override == nil {
+		override = &ProjectConfig{}
+	}
+	if info.Trusted {
+		m.setCurrentProject(mergeProject(repo, override))
+		return
+	}
+	m.trustedProject = mergeProject(repo, override)
+	m.setCurrentProject(mergeProject(withoutCommands(repo), override))
+}
+
+// setCurrentProject makes project the current one and adds the commands its
+// virtualenv and runtimes settings imply
+func (m *Manager) setCurrentProject(project *ProjectConfig) {
+	m.currentProject = project
+	// Auto-register virtualenv commands if configured
+	if project.Virtualenv != nil && project.Virtualenv.AutoCommands {
+		m.registerVirtualenvCommands(project)
+	}
+	if len(project.Runtimes) > 0 {
+		m.registerRuntimeCommands(project)
+	}
+}
+
+// GetRepoConfig returns the .wt.yaml merged into the current project, or nil
+func (m *Manager) GetRepoConfig() *RepoConfig {
+	return m.repoConfig
+}
+
+// ProjectFile is a config file in the projects directory. When Err is a
+// *ConfigError, Project holds what could be decoded leniently so the config
+// can still be listed and matched; it is nil if the file doesn't parse.
+type ProjectFile struct {
+	Path    string
+	Project *ProjectConfig
+	Err     error
+}
+
+// Name returns the project's name, or the file name when it has none
+func (f ProjectFile) Name() string {
+	if f.Project != nil && f.Project.Name != "" {
+		return f.Project.Name
+	}
+	return strings.TrimSuffix(filepath.Base(f.Path), ".yaml")
+}
+
+// ListProjects loads every project config in file name order, the order
+// LoadProject tries them in. Configs that fail to load have Err set.
+func (m *Manager) ListProjects() ([]ProjectFile, error) {
+	entries, err := os.ReadDir(m.projectsDir())
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil // No projects configured yet
+			return nil, nil // No projects configured yet
 		}
-		return err
+		return nil, err
 	}
 
+	var files []ProjectFile
 	for _, entry := range entries {
 		if !strings.HasSuffix(entry.Name(), ".yaml") {
 			continue
 		}
-
-		configPath := filepath.Join(projectsDir, entry.Name())
-		project, err := m.loadProjectConfig(configPath)
+		path := filepath.Join(m.projectsDir(), entry.Name())
+		project, err := m.loadProjectConfig(path)
 		if err != nil {
-			continue // Skip invalid configs
+			project = nil
+			var lenient ProjectConfig
+			if isConfigError(err) && readLenient(path, &lenient) == nil {
+				project = &lenient
+			}
 		}
+		files = append(files, ProjectFile{Path: path, Project: project, Err: err})
+	}
+	return files, nil
+}
 
-		if m.matchesProject(project, currentPath, gitRemote) {
-			m.currentProject = project
-			// Auto-register virtualenv commands if configured
-			if project.Virtualenv != nil && project.Virtualenv.AutoCommands {
-				m.registerVirtualenvCommands(project)
-			}
-			return nil
+// FindProject returns the config of the project with the given name, matched
+// against both the name field and the file name
+func (m *Manager) FindProject(name string) (ProjectFile, error) {
+	files, err := m.ListProjects()
+	if err != nil {
+		return ProjectFile{}, err
+	}
+	for _, file := range files {
+		if file.Name() == name || strings.TrimSuffix(filepath.Base(file.Path), ".yaml") == name {
+			return file, nil
 		}
 	}
+	return ProjectFile{}, fmt.Errorf("no project named '%s'", name)
+}
+
+func (m *Manager) projectsDir() string {
+	return filepath.Join(m.configDir, "projects")
+}
 
-	return nil // No matching project found
+// GetProfilesDir returns the directory of profiles that configs extend or
+// include by name
+func (m *Manager) GetProfilesDir() string {
+	return filepath.Join(m.configDir, profilesDir)
 }
 
-// loadProjectConfig loads a single project configuration file
+// loadProjectConfig loads a single project configuration file. Unknown
+// fields and values of the wrong type are a *ConfigError rather than being
+// ignored.
 func (m *Manager) loadProjectConfig(path string) (*ProjectConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, errs := validateYAML(data, reflect.TypeOf(ProjectConfig{})); len(errs) > 0 {
+		return nil, &ConfigError{Path: path, Errors: errs}
+	}
+
 	var config ProjectConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
 
-	return &config, nil
+	resolved, err := m.resolveProject(&config, path)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Errors: []ValidationError{{Message: err.Error()}}}
+	}
+	return resolved, nil
+}
+
+// LoadProjectFile reads a project config, or a repository's .wt.yaml, with
+// the profiles it extends and includes applied
+func (m *Manager) LoadProjectFile(path string) (*ProjectConfig, error) {
+	return m.loadProjectConfig(path)
+}
+
+// readLenient decodes what it can of a config file, ignoring unknown fields
+func readLenient(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+func isConfigError(err error) bool {
+	var configErr *ConfigError
+	return errors.As(err, &configErr)
 }
 
 // matchesProject checks if current directory matches a project configuration
 func (m *Manager) matchesProject(project *ProjectConfig, currentPath, gitRemote string) bool {
-	// Check path matches
-	for _, pattern := range project.Match.Paths {
-		// Handle wildcards in paths
-		if strings.HasSuffix(pattern, "/*") {
-			prefix := strings.TrimSuffix(pattern, "/*")
-			if strings.HasPrefix(currentPath, prefix) {
-				return true
-			}
-		} else if currentPath == pattern || strings.HasPrefix(currentPath, pattern+"/") {
+	for _, check := range ExplainMatch(project, currentPath, gitRemote) {
+		if check.Matched {
 			return true
 		}
 	}
-
-	// Check remote matches
-	if gitRemote != "" {
-		for _, remote := range project.Match.Remotes {
-			if gitRemote == remote {
-				return true
-			}
-		}
-	}
-
 	return false
 }
 
@@ -175,6 +394,22 @@ func (m *Manager) GetCurrentProject() *ProjectConfig {
 	return m.currentProject
 }
 
+// GetProjectIncludingUntrusted returns the current project as it would be
+// once the .wt.yaml is trusted, with the commands GetCurrentProject holds
+// back. It returns the current project when nothing is held back.
+func (m *Manager) GetProjectIncludingUntrusted() *ProjectConfig {
+	if m.trustedProject != nil {
+		return m.trustedProject
+	}
+	return m.currentProject
+}
+
+// GetCurrentProjectFile returns the config file the current project was
+// loaded from, or "" when no project matched
+func (m *Manager) GetCurrentProjectFile() string {
+	return m.currentFile
+}
+
 // GetCommand returns a command from the current project
 func (m *Manager) GetCommand(name string) (*NavigationCommand, bool) {
 	if m.currentProject == nil {
@@ -185,9 +420,23 @@ func (m *Manager) GetCommand(name string) (*NavigationCommand, bool) {
 	return &cmd, exists
 }
 
+// GetCommandNames returns the names of the current project's commands, sorted
+func (m *Manager) GetCommandNames() []string {
+	if m.currentProject == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(m.currentProject.Commands))
+	for name := range m.currentProject.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // SaveProjectConfig saves a project configuration
 func (m *Manager) SaveProjectConfig(project *ProjectConfig) error {
-	projectsDir := filepath.Join(m.configDir, "projects")
+	projectsDir := m.projectsDir()
 	if err := os.MkdirAll(projectsDir, 0755); err != nil {
 		return err
 	}
@@ -199,7 +448,26 @@ func (m *Manager) SaveProjectConfig(project *ProjectConfig) error {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	// Point YAML language servers at the schema for completion and checks
+	header := "# yaml-language-server: $schema=" + ProjectSchemaURL + "\n"
+	return os.WriteFile(configPath, append([]byte(header), data...), 0644)
+}
+
+// LoadGlobalConfig reads config.yaml from the config directory. A missing
+// file is not an error and yields the defaults.
+func (m *Manager) LoadGlobalConfig() (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(filepath.Join(m.configDir, globalConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", globalConfigFile, err)
+	}
+	return cfg, nil
 }
 
 // GetConfigDir returns the configuration directory
@@ -235,6 +503,33 @@ func (m *Manager) registerVirtualenvCommands(project *ProjectConfig) {
 	}
 }
 
+// registerRuntimeCommands adds activate/mkenv/rmenv commands for the project's
+// runtimes, keeping commands the project already defines under those names
+func (m *Manager) registerRuntimeCommands(project *ProjectConfig) {
+	if project.Commands == nil {
+		project.Commands = make(map[string]NavigationCommand)
+	}
+
+	commands := map[string]NavigationCommand{
+		"activate": {Description: "Activate runtimes", Type: "runtime", Target: "activate"},
+		"mkenv":    {Description: "Create runtimes", Type: "runtime", Target: "create"},
+		"rmenv":    {Description: "Remove runtimes", Type: "runtime", Target: "remove"},
+	}
+	for name, cmd := range commands {
+		if _, exists := project.Commands[name]; !exists {
+			project.Commands[name] = cmd
+		}
+	}
+}
+
+// GetRuntimes returns the current project's runtimes
+func (m *Manager) GetRuntimes() []RuntimeConfig {
+	if m.currentProject == nil {
+		return nil
+	}
+	return m.currentProject.Runtimes
+}
+
 // GetVirtualenvConfig returns the virtualenv configuration for the current project
 func (m *Manager) GetVirtualenvConfig() *VirtualenvConfig {
 	if m.currentProject == nil {
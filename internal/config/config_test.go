@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/tobiase/worktree-utils/test/helpers"
@@ -101,6 +103,47 @@ commands:
 				}
 			},
 		},
+		{
+			name: "project with runtimes and hooks",
+			yamlContent: `name: web
+runtimes:
+  - type: uv
+    python: "3.12"
+  - type: node
+on_enter:
+  runtimes: true
+  run:
+    - echo entered
+on_leave:
+  run:
+    - echo left
+setup:
+  create_runtimes: true
+`,
+			wantErr: false,
+			check: func(t *testing.T, pc *ProjectConfig) {
+				if len(pc.Runtimes) != 2 || pc.Runtimes[0].Type != "uv" || pc.Runtimes[0].Python != "3.12" || pc.Runtimes[1].Type != "node" {
+					t.Errorf("Unexpected runtimes: %+v", pc.Runtimes)
+				}
+				if pc.OnEnter == nil || !pc.OnEnter.Runtimes || len(pc.OnEnter.Run) != 1 {
+					t.Errorf("Unexpected on_enter: %+v", pc.OnEnter)
+				}
+				if pc.OnLeave == nil || len(pc.OnLeave.Run) != 1 || pc.OnLeave.Run[0] != "echo left" {
+					t.Errorf("Unexpected on_leave: %+v", pc.OnLeave)
+				}
+				if pc.Setup == nil || !pc.Setup.CreateRuntimes {
+					t.Error("Expected setup.create_runtimes to be true")
+				}
+			},
+		},
+		{
+			name: "unknown field is rejected",
+			yamlContent: `name: typo
+settings:
+  worktree-base: /home/user/worktrees
+`,
+			wantErr: true,
+		},
 		{
 			name: "invalid yaml",
 			yamlContent: `name: invalid
@@ -328,6 +371,33 @@ func TestRegisterVirtualenvCommands(t *testing.T) {
 	}
 }
 
+func TestRegisterRuntimeCommands(t *testing.T) {
+	project := &ProjectConfig{
+		Name:     "node-project",
+		Runtimes: []RuntimeConfig{{Type: "node"}},
+		Commands: map[string]NavigationCommand{
+			"activate": {Description: "Go to activation scripts", Target: "scripts/activate"},
+		},
+	}
+
+	manager := &Manager{}
+	manager.registerRuntimeCommands(project)
+
+	if cmd := project.Commands["activate"]; cmd.Type != "" || cmd.Target != "scripts/activate" {
+		t.Errorf("Expected existing 'activate' command to be kept, got %+v", cmd)
+	}
+	for name, target := range map[string]string{"mkenv": "create", "rmenv": "remove"} {
+		cmd, exists := project.Commands[name]
+		if !exists {
+			t.Errorf("Expected '%s' command to be registered", name)
+			continue
+		}
+		if cmd.Type != "runtime" || cmd.Target != target {
+			t.Errorf("Command '%s' = %+v, want runtime/%s", name, cmd, target)
+		}
+	}
+}
+
 func TestLoadProject(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -426,6 +496,39 @@ match:
 	}
 }
 
+func TestLoadProjectReportsInvalidMatch(t *testing.T) {
+	dir := t.TempDir()
+	helpers.CreateFiles(t, dir, map[string]string{
+		"projects/mine.yaml": `name: mine
+match:
+  paths: [/home/user/mine]
+setup:
+  copy_file:
+    - source: .env
+`,
+		"projects/other.yaml": `name: other
+match:
+  paths: [/home/user/other]
+bogus: true
+`,
+	})
+	manager := &Manager{configDir: dir}
+
+	err := manager.LoadProject("/home/user/mine", "")
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || !strings.Contains(err.Error(), `mine.yaml: line 5: unknown field "copy_file" in setup`) {
+		t.Errorf("LoadProject() error = %v, want the invalid matching config", err)
+	}
+	if manager.GetCurrentProject() != nil {
+		t.Error("An invalid config should not be loaded")
+	}
+
+	// Invalid configs for other directories are not reported
+	if err := manager.LoadProject("/home/user/elsewhere", ""); err != nil {
+		t.Errorf("LoadProject() elsewhere error = %v", err)
+	}
+}
+
 func TestGetCommand(t *testing.T) {
 	manager := &Manager{
 		currentProject: &ProjectConfig{
@@ -536,6 +639,11 @@ func TestSaveProjectConfig(t *testing.T) {
 		if loaded.Settings.WorktreeBase != project.Settings.WorktreeBase {
 			t.Errorf("Loaded worktree_base = %q, want %q", loaded.Settings.WorktreeBase, project.Settings.WorktreeBase)
 		}
+
+		data, _ := os.ReadFile(configPath)
+		if !strings.HasPrefix(string(data), "# yaml-language-server: $schema="+ProjectSchemaURL+"\n") {
+			t.Errorf("Saved config should reference the JSON schema, got:\n%s", data)
+		}
 	})
 }
 
@@ -601,3 +709,153 @@ func TestGetVirtualenvConfig(t *testing.T) {
 // =============================================================================
 // PHASE 2: CONFIG ROBUSTNESS EDGE CASE TESTS
 // =============================================================================
+
+func TestLoadGlobalConfig(t *testing.T) {
+	helpers.WithTempDir(t, func(dir string) {
+		manager := &Manager{configDir: dir}
+
+		// A missing file gives the defaults
+		cfg, err := manager.LoadGlobalConfig()
+		if err != nil {
+			t.Fatalf("LoadGlobalConfig() error = %v", err)
+		}
+		if cfg.Update.Notify {
+			t.Error("Update notices should be off by default")
+		}
+
+		content := "update:\n  notify: true\n  channel: beta\n  check_interval: 12h\n"
+		if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err = manager.LoadGlobalConfig()
+		if err != nil {
+			t.Fatalf("LoadGlobalConfig() error = %v", err)
+		}
+		want := UpdateSettings{Notify: true, Channel: "beta", CheckInterval: "12h"}
+		if cfg.Update != want {
+			t.Errorf("Update = %+v, want %+v", cfg.Update, want)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("update: [\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := manager.LoadGlobalConfig(); err == nil {
+			t.Error("Expected an error for invalid YAML")
+		}
+	})
+}
+
+func TestExplainMatch(t *testing.T) {
+	project := &ProjectConfig{
+		Name: "app",
+		Match: ProjectMatch{
+			Paths:   []string{"/src/app", "/src/app-worktrees/*"},
+			Remotes: []string{"git@github.com:user/app.git"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		currentPath string
+		gitRemote   string
+		wantMatched []bool
+		wantReason  []string
+	}{
+		{
+			name:        "inside the repository",
+			currentPath: "/src/app/cmd",
+			wantMatched: []bool{true, false, false},
+			wantReason:  []string{"/src/app/cmd is inside /src/app", "does not start with /src/app-worktrees", "no git remote"},
+		},
+		{
+			name:        "worktree by wildcard",
+			currentPath: "/src/app-worktrees/feature",
+			gitRemote:   "git@github.com:user/app.git",
+			wantMatched: []bool{false, true, true},
+			wantReason:  []string{"is not /src/app or inside it", "starts with /src/app-worktrees", "origin is git@github.com:user/app.git"},
+		},
+		{
+			name:        "sibling with a common prefix",
+			currentPath: "/src/application",
+			gitRemote:   "git@github.com:user/other.git",
+			wantMatched: []bool{false, false, false},
+			wantReason:  []string{"is not /src/app or inside it", "does not start with", "origin is git@github.com:user/other.git"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := ExplainMatch(project, tt.currentPath, tt.gitRemote)
+			if len(checks) != len(tt.wantMatched) {
+				t.Fatalf("got %d checks, want %d", len(checks), len(tt.wantMatched))
+			}
+			for i, check := range checks {
+				if check.Matched != tt.wantMatched[i] || !strings.Contains(check.Reason, tt.wantReason[i]) {
+					t.Errorf("check %d (%s %s) = %v %q, want %v containing %q",
+						i, check.Kind, check.Pattern, check.Matched, check.Reason, tt.wantMatched[i], tt.wantReason[i])
+				}
+			}
+
+			manager := &Manager{}
+			wantMatch := false
+			for _, matched := range tt.wantMatched {
+				wantMatch = wantMatch || matched
+			}
+			if got := manager.matchesProject(project, tt.currentPath, tt.gitRemote); got != wantMatch {
+				t.Errorf("matchesProject() = %v, want %v", got, wantMatch)
+			}
+		})
+	}
+}
+
+func TestListAndFindProjects(t *testing.T) {
+	dir := t.TempDir()
+	manager := &Manager{configDir: dir}
+
+	// A missing projects directory is not an error
+	if files, err := manager.ListProjects(); err != nil || len(files) != 0 {
+		t.Fatalf("ListProjects() without projects = %v, %v", files, err)
+	}
+
+	helpers.CreateFiles(t, dir, map[string]string{
+		"projects/b-app.yaml":  "name: app\nmatch:\n  paths: [/src/app]\n",
+		"projects/a-typo.yaml": "name: typo\nmatch:\n  paths: [/src/typo]\nbogus: true\n",
+		"projects/broken.yaml": "[[[",
+		"projects/notes.txt":   "not a config",
+	})
+
+	files, err := manager.ListProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	if got := strings.Join(names, ","); got != "typo,app,broken" {
+		t.Errorf("ListProjects() names = %s, want typo,app,broken in file name order", got)
+	}
+	if files[0].Err == nil || files[0].Project == nil || files[0].Project.Match.Paths[0] != "/src/typo" {
+		t.Errorf("invalid config should keep its lenient decode: %+v", files[0])
+	}
+	if files[2].Err == nil || files[2].Project != nil {
+		t.Errorf("unparsable config = %+v, want an error and no project", files[2])
+	}
+
+	for _, name := range []string{"app", "b-app"} {
+		file, err := manager.FindProject(name)
+		if err != nil || filepath.Base(file.Path) != "b-app.yaml" {
+			t.Errorf("FindProject(%q) = %v, %v", name, file.Path, err)
+		}
+	}
+	if _, err := manager.FindProject("missing"); err == nil {
+		t.Error("FindProject() of an unknown project should fail")
+	}
+
+	if err := manager.LoadProject("/src/app/cmd", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := manager.GetCurrentProjectFile(); got != filepath.Join(dir, "projects", "b-app.yaml") {
+		t.Errorf("GetCurrentProjectFile() = %q", got)
+	}
+}
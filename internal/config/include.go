@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// profilesDir holds the shared profiles configs extend or include by name,
+// e.g. extends: node-service reads profiles/node-service.yaml
+const profilesDir = "profiles"
+
+// resolveProject applies the profiles a config extends and includes. The
+// extended profile comes first, then each include in order, then the config
+// itself, merged with mergeProject. Name and match are never inherited.
+// path is the file project was read from.
+func (m *Manager) resolveProject(project *ProjectConfig, path string) (*ProjectConfig, error) {
+	return m.resolveChain(project, path, []string{filepath.Clean(path)})
+}
+
+func (m *Manager) resolveChain(project *ProjectConfig, path string, chain []string) (*ProjectConfig, error) {
+	if project.Extends == "" && len(project.Include) == 0 {
+		return project, nil
+	}
+
+	type reference struct{ kind, ref string }
+	var refs []reference
+	if project.Extends != "" {
+		refs = append(refs, reference{"extends", project.Extends})
+	}
+	for _, ref := range project.Include {
+		refs = append(refs, reference{"include", ref})
+	}
+
+	resolved := &ProjectConfig{}
+	for _, r := range refs {
+		profilePath := m.profilePath(r.ref, filepath.Dir(path))
+		for i, seen := range chain {
+			if seen == profilePath {
+				cycle := append(append([]string(nil), chain[i:]...), profilePath)
+				return nil, fmt.Errorf("include cycle: %s", cycleString(cycle))
+			}
+		}
+
+		profile, err := m.readProfile(profilePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", r.kind, r.ref, err)
+		}
+		profile, err = m.resolveChain(profile, profilePath, append(chain[:len(chain):len(chain)], profilePath))
+		if err != nil {
+			return nil, err
+		}
+		resolved = mergeProject(resolved, profile)
+	}
+
+	resolved = mergeProject(resolved, project)
+	resolved.Name = project.Name
+	resolved.Match = project.Match
+	return resolved, nil
+}
+
+// profilePath resolves an extends or include reference. References with a
+// slash or a .yaml extension are files, relative to dir, the directory of
+// the config referring to them; anything else names a profile in the
+// profiles directory.
+func (m *Manager) profilePath(ref, dir string) string {
+	switch {
+	case strings.HasPrefix(ref, "~/"):
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ref[2:])
+		}
+	case filepath.IsAbs(ref):
+		return filepath.Clean(ref)
+	case strings.ContainsRune(ref, '/') || strings.HasSuffix(ref, ".yaml") || strings.HasSuffix(ref, ".yml"):
+		return filepath.Join(dir, ref)
+	}
+	return filepath.Join(m.GetProfilesDir(), ref+".yaml")
+}
+
+// readProfile reads and validates a profile without resolving its own
+// extends and includes
+func (m *Manager) readProfile(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no profile at %s", path)
+		}
+		return nil, err
+	}
+	if errs := ValidateProfileYAML(data, m.coreCommands); len(errs) > 0 {
+		return nil, &ConfigError{Path: path, Errors: errs}
+	}
+	var profile ProjectConfig
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// cycleString shows an include cycle by file name, e.g. a.yaml -> b.yaml -> a.yaml
+func cycleString(chain []string) string {
+	names := make([]string, len(chain))
+	for i, path := range chain {
+		names[i] = filepath.Base(path)
+	}
+	return strings.Join(names, " -> ")
+}
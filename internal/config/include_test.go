@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/test/helpers"
+)
+
+func TestResolveProfiles(t *testing.T) {
+	dir := t.TempDir()
+	helpers.CreateFiles(t, dir, map[string]string{
+		"profiles/node-service.yaml": `name: ignored
+include: [base]
+commands:
+  web:
+    target: web
+setup:
+  commands:
+    - command: npm ci
+runtimes:
+  - type: node
+    version: "18"
+`,
+		"profiles/base.yaml": `env:
+  LOG_LEVEL: info
+setup:
+  copy_files:
+    - source: .env.example
+      target: .env
+`,
+		"projects/shared/docker.yaml": `setup:
+  commands:
+    - command: docker compose pull
+`,
+		"projects/app.yaml": `name: app
+extends: node-service
+include: [shared/docker.yaml]
+match:
+  paths: [/src/app]
+env:
+  LOG_LEVEL: debug
+setup:
+  commands:
+    - command: make
+runtimes:
+  - type: node
+    version: "20"
+`,
+	})
+	manager := &Manager{configDir: dir}
+
+	project, err := manager.LoadProjectFile(filepath.Join(dir, "projects", "app.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Name != "app" || !reflect.DeepEqual(project.Match.Paths, []string{"/src/app"}) {
+		t.Errorf("name and match should come from the config itself: %+v", project)
+	}
+	if project.Extends != "" || project.Include != nil {
+		t.Errorf("resolved config still has references: %q %v", project.Extends, project.Include)
+	}
+	if project.Commands["web"].Target != "web" || project.Env["LOG_LEVEL"] != "debug" {
+		t.Errorf("maps not merged: %+v %+v", project.Commands, project.Env)
+	}
+	var commands []string
+	for _, cmd := range project.Setup.Commands {
+		commands = append(commands, cmd.Command)
+	}
+	if got := strings.Join(commands, ", "); got != "npm ci, docker compose pull, make" {
+		t.Errorf("setup commands = %s, want extends, then includes, then the config", got)
+	}
+	if len(project.Setup.CopyFiles) != 1 || len(project.Runtimes) != 1 || project.Runtimes[0].Version != "20" {
+		t.Errorf("setup = %+v, runtimes = %+v", project.Setup, project.Runtimes)
+	}
+
+	// Matching uses the resolved config
+	if err := manager.LoadProject("/src/app", ""); err != nil {
+		t.Fatal(err)
+	}
+	if current := manager.GetCurrentProject(); current == nil || current.Commands["web"].Target != "web" {
+		t.Errorf("GetCurrentProject() = %+v", current)
+	}
+}
+
+func TestResolveProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		extends string // What projects/app.yaml extends
+		files   map[string]string
+		want    string
+	}{
+		{
+			name:    "cycle",
+			extends: "a",
+			files: map[string]string{
+				"profiles/a.yaml": "include: [b]\n",
+				"profiles/b.yaml": "extends: a\n",
+			},
+			want: "include cycle: a.yaml -> b.yaml -> a.yaml",
+		},
+		{
+			name:    "self reference",
+			extends: "app.yaml",
+			files:   map[string]string{},
+			want:    "include cycle: app.yaml -> app.yaml",
+		},
+		{
+			name:    "missing profile",
+			extends: "a",
+			files:   map[string]string{"profiles/a.yaml": "include: [missing]\n"},
+			want:    `include "missing": no profile at`,
+		},
+		{
+			name:    "invalid profile",
+			extends: "a",
+			files:   map[string]string{"profiles/a.yaml": "setup:\n  copy_file: []\n"},
+			want:    `extends "a": ` + "%s/profiles/a.yaml: line 2: unknown field \"copy_file\" in setup",
+		},
+		{
+			name:    "profile command shadowing a built-in",
+			extends: "a",
+			files:   map[string]string{"profiles/a.yaml": "commands:\n  list:\n    target: docs\n"},
+			want:    `command "list" clashes with the built-in 'wt list'`,
+		},
+		{
+			name:    "match in a profile",
+			extends: "a",
+			files:   map[string]string{"profiles/a.yaml": "match:\n  paths: [/src]\n"},
+			want:    "line 1: match is not used in a profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.files["projects/app.yaml"] = "name: app\nextends: " + tt.extends + "\nmatch:\n  paths: [/src/app]\n"
+			helpers.CreateFiles(t, dir, tt.files)
+			manager := &Manager{configDir: dir, coreCommands: []string{"list", "ls"}}
+
+			_, err := manager.LoadProjectFile(filepath.Join(dir, "projects", "app.yaml"))
+			var configErr *ConfigError
+			want := strings.Replace(tt.want, "%s", dir, 1)
+			if !errors.As(err, &configErr) || !strings.Contains(err.Error(), want) {
+				t.Errorf("LoadProjectFile() error = %v, want %q", err, want)
+			}
+
+			// A broken chain is reported for the directory the config matches
+			if err := manager.LoadProject("/src/app", ""); !errors.As(err, &configErr) {
+				t.Errorf("LoadProject() error = %v, want a *ConfigError", err)
+			}
+		})
+	}
+}
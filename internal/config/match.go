@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchCheck is the outcome of comparing one match pattern of a project
+// against the current directory or git remote
+type MatchCheck struct {
+	Kind    string // "path" or "remote"
+	Pattern string
+	Matched bool
+	Reason  string
+}
+
+// ExplainMatch evaluates every path and remote pattern of a project in the
+// order LoadProject uses. The project matches if any check matched.
+func ExplainMatch(project *ProjectConfig, currentPath, gitRemote string) []MatchCheck {
+	var checks []MatchCheck
+
+	for _, pattern := range project.Match.Paths {
+		check := MatchCheck{Kind: "path", Pattern: pattern}
+		// Handle wildcards in paths
+		if strings.HasSuffix(pattern, "/*") {
+			prefix := strings.TrimSuffix(pattern, "/*")
+			check.Matched = strings.HasPrefix(currentPath, prefix)
+			if check.Matched {
+				check.Reason = fmt.Sprintf("%s starts with %s", currentPath, prefix)
+			} else {
+				check.Reason = fmt.Sprintf("%s does not start with %s", currentPath, prefix)
+			}
+		} else {
+			switch {
+			case currentPath == pattern:
+				check.Matched = true
+				check.Reason = "current directory is " + pattern
+			case strings.HasPrefix(currentPath, pattern+"/"):
+				check.Matched = true
+				check.Reason = fmt.Sprintf("%s is inside %s", currentPath, pattern)
+			default:
+				check.Reason = fmt.Sprintf("%s is not %s or inside it", currentPath, pattern)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	for _, remote := range project.Match.Remotes {
+		check := MatchCheck{Kind: "remote", Pattern: remote}
+		switch {
+		case gitRemote == "":
+			check.Reason = "no git remote"
+		case gitRemote == remote:
+			check.Matched = true
+			check.Reason = "origin is " + remote
+		default:
+			check.Reason = fmt.Sprintf("origin is %s", gitRemote)
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
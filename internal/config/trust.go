@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// trustFile records the .wt.yaml commands the user approved
+const trustFile = "trust.yaml"
+
+// trustStore maps a repository's primary worktree to the hash of the
+// commands approved for it, or declined at the prompt. Changing any command
+// changes the hash, so the user is asked again.
+type trustStore struct {
+	Repositories map[string]string `yaml:"repositories,omitempty"`
+	Declined     map[string]string `yaml:"declined,omitempty"`
+}
+
+func (m *Manager) loadTrustStore() (*trustStore, error) {
+	store := &trustStore{}
+	data, err := os.ReadFile(filepath.Join(m.configDir, trustFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", trustFile, err)
+	}
+	return store, nil
+}
+
+func (m *Manager) saveTrustStore(store *trustStore) error {
+	data, err := yaml.Marshal(store)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.configDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.configDir, trustFile), data, 0600)
+}
+
+// trustState reports whether the repository's commands were approved, or
+// declined, as they are now
+func (m *Manager) trustState(repo *RepoConfig) (trusted, declined bool) {
+	store, err := m.loadTrustStore()
+	if err != nil {
+		return false, false
+	}
+	return store.Repositories[repo.repoDir] == repo.hash, store.Declined[repo.repoDir] == repo.hash
+}
+
+// TrustRepoConfig approves the commands of the current .wt.yaml and applies
+// them to the current project
+func (m *Manager) TrustRepoConfig() error {
+	repo := m.repoConfig
+	if repo == nil || repo.Trusted {
+		return nil
+	}
+
+	store, err := m.loadTrustStore()
+	if err != nil {
+		return err
+	}
+	if store.Repositories == nil {
+		store.Repositories = make(map[string]string)
+	}
+	store.Repositories[repo.repoDir] = repo.hash
+	delete(store.Declined, repo.repoDir)
+	if err := m.saveTrustStore(store); err != nil {
+		return err
+	}
+
+	repo.Trusted, repo.Declined = true, false
+	m.setCurrentProject(m.trustedProject)
+	m.trustedProject = nil
+	return nil
+}
+
+// DeclineRepoConfig remembers that the user declined the commands of the
+// current .wt.yaml, so they aren't offered again until they change
+func (m *Manager) DeclineRepoConfig() error {
+	repo := m.repoConfig
+	if repo == nil || repo.Trusted || repo.Declined {
+		return nil
+	}
+
+	store, err := m.loadTrustStore()
+	if err != nil {
+		return err
+	}
+	if store.Declined == nil {
+		store.Declined = make(map[string]string)
+	}
+	store.Declined[repo.repoDir] = repo.hash
+	if err := m.saveTrustStore(store); err != nil {
+		return err
+	}
+	repo.Declined = true
+	return nil
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/suggest"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a problem in a config file. Line is 0 when the
+// problem has no single position.
+type ValidationError struct {
+	Line    int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
+
+// ConfigError is a config file that failed validation
+type ConfigError struct {
+	Path   string
+	Errors []ValidationError
+}
+
+func (e *ConfigError) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Path, e.Errors[0])
+	if len(e.Errors) > 1 {
+		msg += fmt.Sprintf(" (and %d more problems)", len(e.Errors)-1)
+	}
+	return msg
+}
+
+// ValidateProjectYAML checks a project config file: YAML syntax, unknown
+// fields and value types against the ProjectConfig schema, then the rules the
+// schema cannot express (see checkProject). coreCommands are the built-in
+// command names and aliases project commands must not shadow.
+func ValidateProjectYAML(data []byte, coreCommands []string) []ValidationError {
+	return validateProject(data, coreCommands, "")
+}
+
+// ValidateRepoYAML checks a repository's .wt.yaml like ValidateProjectYAML.
+// The name is optional and match is unused, since the repository is found by
+// its location.
+func ValidateRepoYAML(data []byte, coreCommands []string) []ValidationError {
+	return validateProject(data, coreCommands, RepoConfigFile+"; the repository is found by its location")
+}
+
+// ValidateProfileYAML checks a profile that configs extend or include. Its
+// name is optional and match is unused, since neither is inherited.
+func ValidateProfileYAML(data []byte, coreCommands []string) []ValidationError {
+	return validateProject(data, coreCommands, "a profile; it is not inherited")
+}
+
+// validateProject checks a project config. unusedMatch, if set, explains
+// why the config's kind takes no name and match.
+func validateProject(data []byte, coreCommands []string, unusedMatch string) []ValidationError {
+	root, errs := validateYAML(data, reflect.TypeOf(ProjectConfig{}))
+	if root == nil {
+		return errs
+	}
+
+	// Decode leniently so problems are reported together with schema errors
+	var project ProjectConfig
+	_ = root.Decode(&project)
+	switch {
+	case unusedMatch != "" && (len(project.Match.Paths) > 0 || len(project.Match.Remotes) > 0):
+		errs = append(errs, ValidationError{Line: lineOf(root, "match"), Message: "match is not used in " + unusedMatch})
+	case unusedMatch == "" && project.Name == "":
+		errs = append(errs, ValidationError{Message: "name is required"})
+	}
+	errs = append(errs, checkProject(&project, root, coreCommands)...)
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return errs
+}
+
+// ValidateGlobalYAML checks config.yaml against the Config schema
+func ValidateGlobalYAML(data []byte) []ValidationError {
+	_, errs := validateYAML(data, reflect.TypeOf(Config{}))
+	return errs
+}
+
+// yamlLinePattern finds the line number yaml.v3 puts in its messages
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// validateYAML checks data against the schema of type schema and returns its
+// document node, or nil when it is empty or not valid YAML
+func validateYAML(data []byte, schema reflect.Type) (*yaml.Node, []ValidationError) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []ValidationError{yamlError(err.Error())}
+	}
+	if len(root.Content) == 0 {
+		return nil, nil // Empty file
+	}
+	doc := root.Content[0]
+
+	var errs []ValidationError
+	checkFields(doc, schema, "", &errs)
+
+	// Decoding reports values of the wrong type
+	target := reflect.New(schema).Interface()
+	if err := doc.Decode(target); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				errs = append(errs, yamlError(msg))
+			}
+		} else {
+			errs = append(errs, yamlError(err.Error()))
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return doc, errs
+}
+
+// yamlError turns a yaml.v3 message into a ValidationError with its line
+func yamlError(msg string) ValidationError {
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return ValidationError{Line: line, Message: strings.TrimPrefix(msg, m[0])}
+	}
+	return ValidationError{Message: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// checkFields reports mapping keys that no field of t accepts, recursing
+// into nested structs, maps and lists. path names the enclosing field.
+func checkFields(node *yaml.Node, t reflect.Type, path string, errs *[]ValidationError) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return // Decoding reports the type mismatch
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				*errs = append(*errs, ValidationError{Line: key.Line, Message: unknownFieldMessage(key.Value, path, fields)})
+				continue
+			}
+			checkFields(value, field.Type, joinPath(path, key.Value), errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of struct type t to their fields
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// minPrefixLength is the shortest key suggested a field it is a prefix of;
+// shorter keys would match any field starting with the same letters
+const minPrefixLength = 3
+
+// unknownFieldMessage describes an unknown key, suggesting the closest field
+// it likely meant, e.g. worktree_base for worktree-base or channel for chanel
+func unknownFieldMessage(key, path string, fields map[string]reflect.StructField) string {
+	where := ""
+	if path != "" {
+		where = " in " + path
+	}
+	msg := fmt.Sprintf("unknown field %q%s", key, where)
+
+	normalized := normalizeFieldName(key)
+	best, bestDistance := "", -1
+	for name := range fields {
+		n := normalizeFieldName(name)
+		distance := suggest.Distance(normalized, n)
+		prefix := len(normalized) >= minPrefixLength && (strings.HasPrefix(n, normalized) || strings.HasPrefix(normalized, n))
+		if prefix {
+			distance = min(distance, 1) // Rank a truncated key like a single typo
+		} else if distance > 2 || len(normalized) < 2*distance {
+			continue // Too far, or changes most of a short key
+		}
+		if bestDistance < 0 || distance < bestDistance || distance == bestDistance && name < best {
+			best, bestDistance = name, distance
+		}
+	}
+	if best != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", best)
+	}
+	return msg
+}
+
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
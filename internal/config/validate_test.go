@@ -0,0 +1,200 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateProjectYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string // "line N: substring" of each expected error
+	}{
+		{
+			name: "valid",
+			yaml: `name: myproject
+match:
+  paths: [/home/user/myproject]
+commands:
+  api:
+    description: API
+    target: services/api
+settings:
+  worktree_base: /home/user/worktrees
+setup:
+  copy_files:
+    - source: .env
+      target: .env
+`,
+		},
+		{
+			name: "empty file",
+			yaml: "",
+		},
+		{
+			name: "missing name",
+			yaml: "match:\n  paths: [/src/app]\n",
+			want: []string{"name is required"},
+		},
+		{
+			name: "unknown fields with suggestions",
+			yaml: `name: myproject
+settings:
+  worktree-base: /tmp
+setup:
+  copy_files:
+    - source: .env
+      dest: .env
+bogus: true
+`,
+			want: []string{
+				`line 3: unknown field "worktree-base" in settings (did you mean "worktree_base"?)`,
+				`line 6: setup.copy_files[0] needs a target`,
+				`line 7: unknown field "dest" in setup.copy_files[0]`,
+				`line 8: unknown field "bogus"`,
+			},
+		},
+		{
+			name: "wrong types",
+			yaml: `name: myproject
+match:
+  paths: /home/user/myproject
+virtualenv:
+  auto_commands: sometimes
+`,
+			want: []string{
+				"line 3: cannot unmarshal",
+				"line 5: cannot unmarshal",
+			},
+		},
+		{
+			name: "syntax error",
+			yaml: "name: myproject\ncommands:\n  api: [unclosed\n",
+			want: []string{"did not find expected"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateProjectYAML([]byte(tt.yaml), nil)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateProjectYAML() = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, errs[i].Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGlobalYAML(t *testing.T) {
+	errs := ValidateGlobalYAML([]byte("update:\n  notify: true\n  chanel: beta\n"))
+	if len(errs) != 1 || errs[0].Line != 3 || !strings.Contains(errs[0].Message, `did you mean "channel"?`) {
+		t.Errorf("ValidateGlobalYAML() = %v, want an unknown field on line 3", errs)
+	}
+}
+
+func TestUnknownFieldMessage(t *testing.T) {
+	fields := map[string]reflect.StructField{}
+	for _, name := range []string{"env", "extends", "name", "runtimes", "settings", "setup"} {
+		fields[name] = reflect.StructField{}
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"setings", `unknown field "setings" (did you mean "settings"?)`},
+		{"stup", `unknown field "stup" (did you mean "setup"?)`},
+		{"sett", `unknown field "sett" (did you mean "settings"?)`},
+		{"runtime", `unknown field "runtime" (did you mean "runtimes"?)`},
+		{"Extends", `unknown field "Extends" (did you mean "extends"?)`},
+		{"s", `unknown field "s"`},
+		{"se", `unknown field "se"`},
+		{"bogus", `unknown field "bogus"`},
+	}
+	for _, tt := range tests {
+		if got := unknownFieldMessage(tt.key, "", fields); got != tt.want {
+			t.Errorf("unknownFieldMessage(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestValidateProjectYAMLSemantics(t *testing.T) {
+	data := `name: myproject
+commands:
+  list:
+    target: docs
+  up:
+    target: ../sibling
+  venv:
+    target: .venv
+  api:
+    description: no target
+settings:
+  worktree_base: worktrees
+  sync_strategy: squash
+virtualenv:
+  name: /opt/venv
+  auto_commands: true
+setup:
+  copy_files:
+    - source: /etc/hosts
+      target: .env
+  commands:
+    - directory: ../other
+      command: make
+    - directory: frontend
+  create_directories:
+    - tmp
+    - ../../outside
+`
+	want := []string{
+		`line 3: command "list" clashes with the built-in 'wt list'`,
+		`line 6: command "up" target "../sibling" must be a relative path inside the repository`,
+		`line 7: command "venv" is replaced by the one virtualenv.auto_commands adds`,
+		`line 9: command "api" needs a target directory`,
+		`line 12: settings.worktree_base "worktrees" must be an absolute path`,
+		`line 13: settings.sync_strategy must be rebase or merge, not "squash"`,
+		`line 15: virtualenv.name "/opt/venv" must be a relative path inside the worktree`,
+		`line 19: setup.copy_files[0].source "/etc/hosts" must be a relative path inside the repository`,
+		`line 22: setup.commands[0].directory "../other" must be a relative path inside the worktree`,
+		`line 24: setup.commands[1] has no command`,
+		`line 27: setup.create_directories[1] "../../outside" must be a relative path inside the worktree`,
+	}
+
+	errs := ValidateProjectYAML([]byte(data), []string{"list", "ls", "go"})
+	if len(errs) != len(want) {
+		t.Fatalf("ValidateProjectYAML() = %v, want %d errors", errs, len(want))
+	}
+	for i := range want {
+		if !strings.HasPrefix(errs[i].Error(), want[i]) {
+			t.Errorf("error %d = %q, want %q", i, errs[i].Error(), want[i])
+		}
+	}
+}
+
+func TestValidateRepoYAML(t *testing.T) {
+	// No name needed
+	if errs := ValidateRepoYAML([]byte("setup:\n  commands:\n    - command: npm ci\n"), nil); len(errs) != 0 {
+		t.Errorf("ValidateRepoYAML() = %v, want no errors", errs)
+	}
+
+	errs := ValidateRepoYAML([]byte("match:\n  paths: [/src/app]\nsetup:\n  copy_files:\n    - source: .env\n      target: ../../.bashrc\n"), nil)
+	want := []string{
+		"line 1: match is not used in .wt.yaml",
+		`line 5: setup.copy_files[0].target "../../.bashrc" must be a relative path inside the worktree`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("ValidateRepoYAML() = %v, want %d errors", errs, len(want))
+	}
+	for i := range want {
+		if !strings.HasPrefix(errs[i].Error(), want[i]) {
+			t.Errorf("error %d = %q, want %q", i, errs[i].Error(), want[i])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package directive passes shell actions (changing directory, running a
+// command, setting or unsetting a variable) from wt to the shell function
+// that wraps it.
+//
+// The wrapper creates a temporary file and passes its path in
+// WT_DIRECTIVE_FILE; wt appends one directive per line and the wrapper
+// applies them after wt exits, so stdout and stderr can stream to the
+// terminal untouched. Without the variable, wt falls back to printing
+// CD:/EXEC:/SETENV:/UNSETENV: prefixes on stdout for wrappers that scrape
+// output.
+package directive
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// EnvVar names the environment variable holding the directive file path
+const EnvVar = "WT_DIRECTIVE_FILE"
+
+// ShellEnvVar names the environment variable the wrapper sets to its shell
+const ShellEnvVar = "WT_SHELL"
+
+// Directive verbs as written to the directive file
+const (
+	VerbCd       = "cd"
+	VerbExec     = "exec"
+	VerbSetenv   = "setenv"
+	VerbUnsetenv = "unsetenv"
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Cd asks the shell wrapper to change to path
+func Cd(path string) error {
+	return write(VerbCd, path, "CD:")
+}
+
+// Exec asks the shell wrapper to evaluate command in the calling shell.
+// Arguments interpolated into command must be quoted with Quote.
+func Exec(command string) error {
+	return write(VerbExec, command, "EXEC:")
+}
+
+// Setenv asks the shell wrapper to export name=value. The wrapper splits on
+// the first '=' and assigns without evaluating, so value needs no quoting.
+func Setenv(name, value string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return write(VerbSetenv, name+"="+value, "SETENV:")
+}
+
+// Unsetenv asks the shell wrapper to unset name
+func Unsetenv(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return write(VerbUnsetenv, name, "UNSETENV:")
+}
+
+// Enabled reports whether the shell wrapper passed a directive file
+func Enabled() bool {
+	return os.Getenv(EnvVar) != ""
+}
+
+// Shell returns the shell the wrapper runs in (bash, zsh, fish, nu or sh),
+// defaulting to bash for wrappers that don't say
+func Shell() string {
+	if shell := os.Getenv(ShellEnvVar); shell != "" {
+		return shell
+	}
+	return "bash"
+}
+
+// Quote quotes s as a single word for the wrapper's shell
+func Quote(s string) string {
+	switch Shell() {
+	case "fish":
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return "'" + strings.ReplaceAll(s, `'`, `\'`) + "'"
+	case "nu":
+		return "r#'" + s + "'#"
+	default:
+		return "'" + strings.ReplaceAll(s, `'`, `'\''`) + "'"
+	}
+}
+
+func write(verb, arg, prefix string) error {
+	if strings.ContainsAny(arg, "\n\x00") {
+		return fmt.Errorf("%s directive cannot contain a newline", verb)
+	}
+
+	path := os.Getenv(EnvVar)
+	if path == "" {
+		fmt.Println(prefix + arg)
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open directive file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%s %s\n", verb, arg); err != nil {
+		return fmt.Errorf("failed to write directive: %v", err)
+	}
+	return nil
+}
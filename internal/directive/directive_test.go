@@ -0,0 +1,99 @@
+package directive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirectiveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "directives")
+	t.Setenv(EnvVar, path)
+
+	if err := Cd("/tmp/some dir"); err != nil {
+		t.Fatalf("Cd() error = %v", err)
+	}
+	if err := Exec(`source "/tmp/venv/bin/activate"`); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if err := Setenv("WT_BRANCH", "feature=x"); err != nil {
+		t.Fatalf("Setenv() error = %v", err)
+	}
+	if err := Unsetenv("WT_PROJECT"); err != nil {
+		t.Fatalf("Unsetenv() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read directive file: %v", err)
+	}
+
+	want := "cd /tmp/some dir\nexec source \"/tmp/venv/bin/activate\"\nsetenv WT_BRANCH=feature=x\nunsetenv WT_PROJECT\n"
+	if string(data) != want {
+		t.Errorf("Directive file = %q, want %q", data, want)
+	}
+}
+
+func TestDirectiveFallback(t *testing.T) {
+	t.Setenv(EnvVar, "")
+
+	r, w, _ := os.Pipe()
+	oldStdout := os.Stdout
+	os.Stdout = w
+
+	setenvErr := Setenv("WT_BRANCH", "main")
+	unsetenvErr := Unsetenv("WT_PROJECT")
+	cdErr := Cd("/tmp/worktree")
+	execErr := Exec("nvm use")
+
+	w.Close()
+	os.Stdout = oldStdout
+	out := make([]byte, 256)
+	n, _ := r.Read(out)
+
+	if cdErr != nil || execErr != nil || setenvErr != nil || unsetenvErr != nil {
+		t.Fatalf("Unexpected errors: %v, %v, %v, %v", cdErr, execErr, setenvErr, unsetenvErr)
+	}
+	// Each directive is a line of its own, so a CD: is never joined to the next
+	want := "SETENV:WT_BRANCH=main\nUNSETENV:WT_PROJECT\nCD:/tmp/worktree\nEXEC:nvm use\n"
+	if string(out[:n]) != want {
+		t.Errorf("Fallback output = %q, want %q", out[:n], want)
+	}
+}
+
+func TestDirectiveRejectsNewlines(t *testing.T) {
+	t.Setenv(EnvVar, filepath.Join(t.TempDir(), "directives"))
+
+	if err := Cd("/tmp/a\nexec rm -rf /"); err == nil || !strings.Contains(err.Error(), "newline") {
+		t.Errorf("Cd() error = %v, want newline error", err)
+	}
+	for _, name := range []string{"BAD NAME", "1ABC", "A=B", ""} {
+		if err := Setenv(name, "x"); err == nil {
+			t.Errorf("Setenv(%q) should reject invalid names", name)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		shell    string
+		input    string
+		expected string
+	}{
+		{"bash", "/tmp/my venv/bin/activate", "'/tmp/my venv/bin/activate'"},
+		{"", "it's", `'it'\''s'`},
+		{"sh", "$(rm -rf /)", "'$(rm -rf /)'"},
+		{"fish", `it's\`, `'it\'s\\'`},
+		{"nu", "it's", `r#'it's'#`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			t.Setenv(ShellEnvVar, tt.shell)
+			if got := Quote(tt.input); got != tt.expected {
+				t.Errorf("Quote(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+)
+
+// checkConfigs validates config.yaml and the project configs; coreCommands
+// are the built-in commands project commands must not shadow
+func checkConfigs(configDir string, coreCommands []string) Result {
+	result := Result{Name: "Configuration"}
+
+	validateFile(&result, configDir, filepath.Join(configDir, "config.yaml"), config.ValidateGlobalYAML)
+
+	projectsDir := filepath.Join(configDir, "projects")
+	entries, err := os.ReadDir(projectsDir)
+	if err != nil && !os.IsNotExist(err) {
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("cannot read %s: %v", projectsDir, err),
+			Fix:     fmt.Sprintf("check the permissions of %s", projectsDir),
+		})
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+		validateFile(&result, configDir, filepath.Join(projectsDir, entry.Name()), func(data []byte) []config.ValidationError {
+			return config.ValidateProjectYAML(data, coreCommands)
+		})
+	}
+
+	return result
+}
+
+func validateFile(result *Result, configDir, path string, validate func([]byte) []config.ValidationError) {
+	name, _ := filepath.Rel(configDir, path)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("cannot read %s: %v", name, err),
+			Fix:     fmt.Sprintf("check the permissions of %s", path),
+		})
+		return
+	}
+
+	errs := validate(data)
+	if len(errs) == 0 {
+		result.OK = append(result.OK, fmt.Sprintf("%s is valid", name))
+		return
+	}
+	for _, e := range errs {
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("%s: %v", name, e),
+			Fix:     fmt.Sprintf("edit %s", path),
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package doctor diagnoses a wt installation, its project configs and the
+// worktrees of the current repository, and repairs what is safe to repair.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Issue is a problem found by a check
+type Issue struct {
+	Problem string
+	Fix     string       // What to do about it
+	Repair  func() error // Safe automatic repair for --fix; nil when the fix is manual
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name   string
+	OK     []string // Things that were checked and are fine
+	Issues []Issue
+}
+
+// Options configures a doctor run
+type Options struct {
+	HomeDir      string
+	ConfigDir    string
+	RepoDir      string   // Repository whose worktrees to check; empty skips the worktree checks
+	WorktreeBase string   // Project worktree_base setting, if any
+	Version      string   // Version of the running binary
+	CoreCommands []string // Built-in command names and aliases
+	Fix          bool     // Apply safe repairs
+}
+
+// Run performs all checks, printing a report to w, and returns the number
+// of problems left unresolved
+func Run(opts Options, w io.Writer) int {
+	results := []Result{
+		checkInstallation(opts),
+		checkConfigs(opts.ConfigDir, opts.CoreCommands),
+		checkShellConfigs(opts.HomeDir),
+		checkGit(),
+	}
+	if opts.RepoDir != "" {
+		results = append(results, checkWorktrees(opts.RepoDir, opts.WorktreeBase))
+	}
+
+	problems, repairable := 0, 0
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\n", result.Name)
+		if len(result.OK) == 0 && len(result.Issues) == 0 {
+			fmt.Fprintln(w, "  - nothing to check")
+		}
+		for _, ok := range result.OK {
+			fmt.Fprintf(w, "  ✓ %s\n", ok)
+		}
+		for _, issue := range result.Issues {
+			fmt.Fprintf(w, "  ✗ %s\n", issue.Problem)
+			if opts.Fix && issue.Repair != nil {
+				if err := issue.Repair(); err != nil {
+					fmt.Fprintf(w, "    repair failed: %v\n", err)
+					problems++
+				} else {
+					fmt.Fprintf(w, "    fixed: %s\n", issue.Fix)
+				}
+				continue
+			}
+			fmt.Fprintf(w, "    fix: %s\n", issue.Fix)
+			problems++
+			if issue.Repair != nil {
+				repairable++
+			}
+		}
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case problems == 0:
+		fmt.Fprintln(w, "No problems found.")
+	case repairable > 0:
+		fmt.Fprintf(w, "%s found; 'wt doctor --fix' can repair %d.\n", plural(problems, "problem"), repairable)
+	default:
+		fmt.Fprintf(w, "%s found.\n", plural(problems, "problem"))
+	}
+	return problems
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// writeFile replaces path with data, creating its directory
+func writeFile(path, data string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(data), 0644)
+}
+
+// displayPath shortens paths in the home directory to ~/...
+func displayPath(homeDir, path string) string {
+	if homeDir != "" {
+		if rel, err := filepath.Rel(homeDir, path); err == nil && filepath.IsLocal(rel) {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
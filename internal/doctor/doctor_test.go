@@ -0,0 +1,236 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/internal/setup"
+)
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := writeFile(path, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// problems returns the Problem of each issue
+func problems(result Result) []string {
+	var out []string
+	for _, issue := range result.Issues {
+		out = append(out, issue.Problem)
+	}
+	return out
+}
+
+func TestCheckConfigs(t *testing.T) {
+	configDir := t.TempDir()
+	writeTestFile(t, filepath.Join(configDir, "config.yaml"), "update:\n  notify: true\n")
+	writeTestFile(t, filepath.Join(configDir, "projects", "good.yaml"), "name: good\nmatch:\n  paths: [/src/good]\n")
+	writeTestFile(t, filepath.Join(configDir, "projects", "bad.yaml"), "name: bad\nsettings:\n  worktree-base: /tmp\n")
+	writeTestFile(t, filepath.Join(configDir, "projects", "notes.txt"), "not: [yaml")
+
+	result := checkConfigs(configDir, []string{"list"})
+
+	got := problems(result)
+	if len(got) != 1 || !strings.HasPrefix(got[0], filepath.Join("projects", "bad.yaml")+": line 3: unknown field") {
+		t.Errorf("checkConfigs() problems = %q, want one for bad.yaml line 3", got)
+	}
+	if len(result.OK) != 2 {
+		t.Errorf("checkConfigs() OK = %q, want config.yaml and good.yaml", result.OK)
+	}
+}
+
+func TestCheckInstallationRegeneratesStaleFiles(t *testing.T) {
+	homeDir := t.TempDir()
+	configDir := filepath.Join(homeDir, ".config", "wt")
+	binDir := filepath.Join(homeDir, ".local", "bin")
+	writeTestFile(t, filepath.Join(binDir, "wt-bin"), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(binDir, "wt-bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	bashPath := filepath.Join(configDir, "completion.bash")
+	writeTestFile(t, filepath.Join(configDir, "init.sh"), setup.InitScript())
+	writeTestFile(t, bashPath, "# completion from an older wt\n")
+
+	opts := Options{HomeDir: homeDir, ConfigDir: configDir, Version: "v1.2.0"}
+	result := checkInstallation(opts)
+
+	got := problems(result)
+	if len(got) != 1 || !strings.Contains(got[0], "completion.bash is out of date for wt v1.2.0") {
+		t.Fatalf("checkInstallation() problems = %q, want a stale bash completion", got)
+	}
+	// The zsh completion was never installed, which is not a problem
+	for _, ok := range result.OK {
+		if strings.Contains(ok, "_wt") {
+			t.Errorf("Missing zsh completion should be skipped, got %q", ok)
+		}
+	}
+
+	opts.Fix = true
+	Run(opts, &bytes.Buffer{})
+	data, err := os.ReadFile(bashPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := setup.CompletionFiles(configDir)[bashPath]; string(data) != want {
+		t.Error("--fix should regenerate the stale completion")
+	}
+	if result := checkInstallation(opts); len(result.Issues) != 0 {
+		t.Errorf("Problems left after --fix: %q", problems(result))
+	}
+}
+
+func TestCheckShellConfigsDuplicateInit(t *testing.T) {
+	homeDir := t.TempDir()
+	bashrc := filepath.Join(homeDir, ".bashrc")
+	writeTestFile(t, bashrc, strings.Join([]string{
+		"export EDITOR=vim",
+		"",
+		"# worktree-utils",
+		"source <(wt-bin shell-init)",
+		"# source <(wt-bin shell-init) is commented out",
+		"alias ll='ls -l'",
+		"",
+		"# worktree-utils",
+		"source <(wt-bin shell-init)",
+		"source ~/.config/wt/init.sh",
+		"",
+	}, "\n"))
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# worktree-utils\nsource <(wt-bin shell-init)\n")
+
+	result := checkShellConfigs(homeDir)
+
+	got := problems(result)
+	if len(got) != 1 || !strings.Contains(got[0], "initialized 3 times in ~/.bashrc (lines 4, 9, 10)") {
+		t.Fatalf("checkShellConfigs() problems = %q", got)
+	}
+	if len(result.OK) != 1 || !strings.Contains(result.OK[0], ".zshrc") {
+		t.Errorf("checkShellConfigs() OK = %q, want .zshrc", result.OK)
+	}
+
+	if err := result.Issues[0].Repair(); err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	data, _ := os.ReadFile(bashrc)
+	want := "export EDITOR=vim\n\n# worktree-utils\nsource <(wt-bin shell-init)\n# source <(wt-bin shell-init) is commented out\nalias ll='ls -l'\n\n"
+	if string(data) != want {
+		t.Errorf("repaired .bashrc = %q, want %q", data, want)
+	}
+	if _, err := os.Stat(bashrc + ".wt-backup"); err != nil {
+		t.Errorf("Expected a backup of the original: %v", err)
+	}
+}
+
+func TestCheckGit(t *testing.T) {
+	oldOutput := gitVersionOutput
+	defer func() { gitVersionOutput = oldOutput }()
+
+	tests := []struct {
+		output   string
+		problems int
+	}{
+		{"git version 2.39.2\n", 0},
+		{"git version 2.39.2.windows.1\n", 0},
+		{"git version 2.37.1 (Apple Git-137.1)\n", 0},
+		{"git version 2.15.0\n", 1},
+		{"git version 1.9.5\n", 2},
+		{"unexpected\n", 1},
+	}
+
+	for _, tt := range tests {
+		gitVersionOutput = func() (string, error) { return tt.output, nil }
+		if got := checkGit(); len(got.Issues) != tt.problems {
+			t.Errorf("checkGit() for %q = %q, want %d problems", tt.output, problems(got), tt.problems)
+		}
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestCheckWorktrees(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	if err := os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(t, repo, "init", "-q")
+	git(t, repo, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial")
+
+	base := filepath.Join(root, "repo-worktrees")
+	git(t, repo, "worktree", "add", "-q", "-b", "good", filepath.Join(base, "good"))
+	git(t, repo, "worktree", "add", "-q", "-b", "moved", filepath.Join(root, "elsewhere"))
+	git(t, repo, "worktree", "add", "-q", "-b", "gone", filepath.Join(base, "gone"))
+	if err := os.RemoveAll(filepath.Join(base, "gone")); err != nil {
+		t.Fatal(err)
+	}
+
+	result := checkWorktrees(repo, "")
+
+	got := problems(result)
+	if len(got) != 2 {
+		t.Fatalf("checkWorktrees() problems = %q, want 2", got)
+	}
+	if !strings.Contains(strings.Join(got, "\n"), "(gone) no longer exists") {
+		t.Errorf("Expected the deleted worktree to be reported, got %q", got)
+	}
+	var moved *Issue
+	for i := range result.Issues {
+		if strings.Contains(result.Issues[i].Problem, "branch moved is checked out in") {
+			moved = &result.Issues[i]
+		}
+	}
+	if moved == nil || moved.Repair != nil || !strings.HasPrefix(moved.Fix, "git worktree move") {
+		t.Errorf("Expected a manual move for the misplaced worktree, got %+v", moved)
+	}
+
+	// A project worktree_base replaces the default layout
+	if got := problems(checkWorktrees(repo, root)); len(got) != 3 {
+		t.Errorf("checkWorktrees() with a custom base = %q, want 3 problems", got)
+	}
+
+	var out bytes.Buffer
+	if remaining := Run(Options{HomeDir: root, ConfigDir: filepath.Join(root, "config"), RepoDir: repo, Fix: true}, &out); remaining == 0 {
+		t.Fatalf("The misplaced worktree should remain after --fix:\n%s", out.String())
+	}
+	if got := problems(checkWorktrees(repo, "")); len(got) != 1 || !strings.Contains(got[0], "branch moved") {
+		t.Errorf("After --fix problems = %q, want only the misplaced worktree", got)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	homeDir := t.TempDir()
+	configDir := filepath.Join(homeDir, ".config", "wt")
+	writeTestFile(t, filepath.Join(configDir, "projects", "bad.yaml"), "nme: typo\n")
+
+	var out bytes.Buffer
+	remaining := Run(Options{HomeDir: homeDir, ConfigDir: configDir}, &out)
+
+	report := out.String()
+	if remaining == 0 {
+		t.Fatalf("Run() found no problems:\n%s", report)
+	}
+	for _, want := range []string{
+		"✗ ~/.config/wt/init.sh is missing",
+		"fix: regenerate ~/.config/wt/init.sh",
+		`bad.yaml: line 1: unknown field "nme" (did you mean "name"?)`,
+		"'wt doctor --fix' can repair 1.",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+}
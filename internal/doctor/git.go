@@ -0,0 +1,194 @@
+package doctor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gitFeature is a git capability wt relies on and the version that added it
+type gitFeature struct {
+	major, minor int
+	what         string
+}
+
+var gitFeatures = []gitFeature{
+	{2, 7, "git worktree list --porcelain (listing worktrees)"},
+	{2, 17, "git worktree remove (wt rm)"},
+}
+
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// gitVersionOutput runs git --version; a variable so tests can fake old gits
+var gitVersionOutput = func() (string, error) {
+	out, err := exec.Command("git", "--version").Output()
+	return string(out), err
+}
+
+// checkGit checks that git is installed and new enough for what wt uses
+func checkGit() Result {
+	result := Result{Name: "Git"}
+
+	out, err := gitVersionOutput()
+	if err != nil {
+		result.Issues = append(result.Issues, Issue{
+			Problem: "git is not installed or not in PATH",
+			Fix:     "install git 2.17 or newer",
+		})
+		return result
+	}
+
+	// "git version 2.39.2", "git version 2.39.2.windows.1", ...
+	m := gitVersionPattern.FindStringSubmatch(out)
+	if m == nil {
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("cannot parse git version %q", strings.TrimSpace(out)),
+			Fix:     "install git 2.17 or newer",
+		})
+		return result
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+
+	for _, feature := range gitFeatures {
+		if major > feature.major || major == feature.major && minor >= feature.minor {
+			result.OK = append(result.OK, fmt.Sprintf("git %s.%s supports %s", m[1], m[2], feature.what))
+			continue
+		}
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("git %s.%s lacks %s, added in %d.%d", m[1], m[2], feature.what, feature.major, feature.minor),
+			Fix:     fmt.Sprintf("upgrade git to %d.%d or newer", feature.major, feature.minor),
+		})
+	}
+	return result
+}
+
+// worktreeEntry is one worktree from git worktree list --porcelain
+type worktreeEntry struct {
+	path   string
+	branch string // Empty for detached and bare worktrees
+	bare   bool
+	locked bool
+}
+
+// listWorktrees lists all worktrees of repo, including ones whose directory
+// is gone; the primary worktree comes first
+func listWorktrees(repo string) ([]worktreeEntry, error) {
+	out, err := exec.Command("git", "-C", repo, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %v", err)
+	}
+
+	var entries []worktreeEntry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			entries = append(entries, worktreeEntry{path: strings.TrimPrefix(line, "worktree ")})
+		case len(entries) == 0:
+			continue
+		case strings.HasPrefix(line, "branch refs/heads/"):
+			entries[len(entries)-1].branch = strings.TrimPrefix(line, "branch refs/heads/")
+		case line == "bare":
+			entries[len(entries)-1].bare = true
+		case line == "locked" || strings.HasPrefix(line, "locked "):
+			entries[len(entries)-1].locked = true
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// checkWorktrees finds worktrees whose directory is gone and branches checked
+// out outside the <base>/<branch> layout wt creates
+func checkWorktrees(repo, worktreeBase string) Result {
+	result := Result{Name: "Worktrees"}
+
+	entries, err := listWorktrees(repo)
+	if err != nil {
+		result.Issues = append(result.Issues, Issue{
+			Problem: err.Error(),
+			Fix:     "run wt doctor inside a git repository",
+		})
+		return result
+	}
+	if len(entries) == 0 {
+		return result
+	}
+
+	primary := entries[0].path
+	if worktreeBase == "" {
+		worktreeBase = filepath.Join(filepath.Dir(primary), filepath.Base(primary)+"-worktrees")
+	}
+
+	missing, misplaced := 0, 0
+	for i, entry := range entries {
+		if _, err := os.Stat(entry.path); os.IsNotExist(err) {
+			missing++
+			issue := Issue{
+				Problem: fmt.Sprintf("worktree %s no longer exists", describeWorktree(entry)),
+				Fix:     "git worktree prune",
+				Repair: func() error {
+					return exec.Command("git", "-C", primary, "worktree", "prune").Run()
+				},
+			}
+			if entry.locked {
+				issue.Fix = fmt.Sprintf("git worktree unlock %s && git worktree prune", entry.path)
+				issue.Repair = nil
+			}
+			result.Issues = append(result.Issues, issue)
+			continue
+		}
+
+		if i == 0 || entry.bare || entry.branch == "" {
+			continue
+		}
+		expected := filepath.Join(worktreeBase, entry.branch)
+		if !samePath(entry.path, expected) {
+			misplaced++
+			result.Issues = append(result.Issues, Issue{
+				Problem: fmt.Sprintf("branch %s is checked out in %s, expected %s", entry.branch, entry.path, expected),
+				Fix:     fmt.Sprintf("git worktree move %s %s", entry.path, expected),
+			})
+		}
+	}
+
+	if missing == 0 {
+		result.OK = append(result.OK, fmt.Sprintf("all %d worktree directories exist", len(entries)))
+	}
+	if misplaced == 0 {
+		result.OK = append(result.OK, fmt.Sprintf("worktrees follow the %s/<branch> layout", worktreeBase))
+	}
+	return result
+}
+
+func describeWorktree(entry worktreeEntry) string {
+	if entry.branch == "" {
+		return entry.path
+	}
+	return fmt.Sprintf("%s (%s)", entry.path, entry.branch)
+}
+
+// samePath compares paths after resolving symlinks, so /tmp and /private/tmp
+// match on macOS. Paths that do not exist are resolved through their parent.
+func samePath(a, b string) bool {
+	return resolvePath(a) == resolvePath(b)
+}
+
+func resolvePath(p string) string {
+	p = filepath.Clean(p)
+	if resolved, err := filepath.EvalSymlinks(p); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(p)
+	if parent == p {
+		return p
+	}
+	return filepath.Join(resolvePath(parent), filepath.Base(p))
+}
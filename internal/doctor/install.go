@@ -0,0 +1,91 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tobiase/worktree-utils/internal/setup"
+)
+
+// checkInstallation checks the installed binary and the files setup writes
+// to the config directory. Generated files that differ from what this binary
+// writes are stale and get regenerated.
+func checkInstallation(opts Options) Result {
+	result := Result{Name: "Installation"}
+
+	binDir := filepath.Join(opts.HomeDir, ".local", "bin")
+	binPath := filepath.Join(binDir, "wt-bin")
+	if info, err := os.Stat(binPath); err != nil || info.Mode()&0111 == 0 {
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("no executable wt-bin at %s", displayPath(opts.HomeDir, binPath)),
+			Fix:     "run 'wt setup'",
+		})
+	} else {
+		result.OK = append(result.OK, fmt.Sprintf("wt-bin installed at %s", displayPath(opts.HomeDir, binPath)))
+	}
+
+	if inPath(binDir) {
+		result.OK = append(result.OK, fmt.Sprintf("%s is in PATH", displayPath(opts.HomeDir, binDir)))
+	} else {
+		result.Issues = append(result.Issues, Issue{
+			Problem: fmt.Sprintf("%s is not in PATH", displayPath(opts.HomeDir, binDir)),
+			Fix:     fmt.Sprintf("add 'export PATH=\"%s:$PATH\"' to your shell config", binDir),
+		})
+	}
+
+	initPath := filepath.Join(opts.ConfigDir, "init.sh")
+	result.check(opts, initPath, setup.InitScript(), true)
+
+	// Completion files are optional, so only existing ones are checked
+	completions := setup.CompletionFiles(opts.ConfigDir)
+	paths := make([]string, 0, len(completions))
+	for path := range completions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		result.check(opts, path, completions[path], false)
+	}
+
+	return result
+}
+
+// check compares a generated file with the contents this binary generates
+func (r *Result) check(opts Options, path, want string, required bool) {
+	name := displayPath(opts.HomeDir, path)
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && !required:
+		return
+	case os.IsNotExist(err):
+		r.Issues = append(r.Issues, Issue{
+			Problem: fmt.Sprintf("%s is missing", name),
+			Fix:     fmt.Sprintf("regenerate %s", name),
+			Repair:  func() error { return writeFile(path, want) },
+		})
+	case err != nil:
+		r.Issues = append(r.Issues, Issue{
+			Problem: fmt.Sprintf("cannot read %s: %v", name, err),
+			Fix:     fmt.Sprintf("check the permissions of %s", name),
+		})
+	case string(data) != want:
+		r.Issues = append(r.Issues, Issue{
+			Problem: fmt.Sprintf("%s is out of date for wt %s", name, opts.Version),
+			Fix:     fmt.Sprintf("regenerate %s", name),
+			Repair:  func() error { return writeFile(path, want) },
+		})
+	default:
+		r.OK = append(r.OK, fmt.Sprintf("%s is up to date", name))
+	}
+}
+
+func inPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/setup"
+)
+
+// checkShellConfigs finds shell configs that initialize wt more than once,
+// which defines the wrapper function twice and slows shell startup
+func checkShellConfigs(homeDir string) Result {
+	result := Result{Name: "Shell configuration"}
+
+	for _, path := range setup.ShellConfigFiles(homeDir) {
+		name := displayPath(homeDir, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		lines := strings.Split(string(data), "\n")
+		inits := initLines(lines)
+		switch {
+		case len(inits) == 0:
+			continue
+		case len(inits) == 1:
+			result.OK = append(result.OK, fmt.Sprintf("wt initialized once in %s", name))
+		default:
+			result.Issues = append(result.Issues, Issue{
+				Problem: fmt.Sprintf("wt is initialized %d times in %s (lines %s)", len(inits), name, joinLineNumbers(inits)),
+				Fix:     fmt.Sprintf("remove all but the first initialization from %s", name),
+				Repair:  func() error { return removeDuplicateInits(path) },
+			})
+		}
+	}
+
+	return result
+}
+
+// initLines returns the indexes of the lines that initialize wt
+func initLines(lines []string) []int {
+	var inits []int
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.Contains(trimmed, "wt-bin shell-init") || strings.Contains(trimmed, ".config/wt/init.sh") {
+			inits = append(inits, i)
+		}
+	}
+	return inits
+}
+
+// removeDuplicateInits keeps the first wt initialization in a shell config and
+// drops the others along with the "# worktree-utils" comment setup writes
+// above them. The original is kept next to it with a .wt-backup suffix.
+func removeDuplicateInits(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	drop := make(map[int]bool)
+	for _, i := range initLines(lines)[1:] {
+		drop[i] = true
+		if i > 0 && strings.TrimSpace(lines[i-1]) == "# worktree-utils" {
+			drop[i-1] = true
+		}
+	}
+
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !drop[i] {
+			kept = append(kept, line)
+		}
+	}
+
+	if err := os.WriteFile(path+".wt-backup", data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(kept, "\n")), info.Mode().Perm())
+}
+
+func joinLineNumbers(indexes []int) string {
+	numbers := make([]string, len(indexes))
+	for i, index := range indexes {
+		numbers[i] = fmt.Sprint(index + 1)
+	}
+	return strings.Join(numbers, ", ")
+}
@@ -99,348 +99,13 @@ func HasHelpFlag(args []string, commandName string) bool {
 	return false
 }
 
-// commandHelpMap contains help information for all commands
-var commandHelpMap = map[string]CommandHelp{
-	"list": {
-		Name:        "list",
-		Usage:       "wt list",
-		Description: "List all worktrees with their index, branch name, and path",
-		Examples: []string{
-			"wt list              # Show all worktrees",
-			"wt ls                # Same as above (alias)",
-		},
-		Aliases: []string{"ls"},
-		SeeAlso: []string{"wt go", "wt new"},
-	},
-	"ls": {
-		Name:        "list",
-		Usage:       "wt ls",
-		Description: "List all worktrees with their index, branch name, and path",
-		Examples: []string{
-			"wt ls                # Show all worktrees",
-			"wt list              # Same as above",
-		},
-		Aliases: []string{"list"},
-		SeeAlso: []string{"wt go", "wt new"},
-	},
-	"go": {
-		Name:        "go",
-		Usage:       "wt go [branch|index] [options]",
-		Description: "Switch to a worktree by branch name or index. Supports fuzzy matching.",
-		Examples: []string{
-			"wt go                # Go to repository root",
-			"wt go main           # Switch to main branch worktree",
-			"wt go mai            # Fuzzy match to 'main'",
-			"wt go 0              # Switch to first worktree by index",
-			"wt go feat --fuzzy   # Force interactive selection for 'feat'",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Force interactive selection even for unique matches",
-				Example:     "wt go main -f",
-			},
-		},
-		Aliases: []string{"switch", "s"},
-		SeeAlso: []string{"wt list", "wt new"},
-	},
-	"switch": {
-		Name:        "go",
-		Usage:       "wt switch [branch|index] [options]",
-		Description: "Switch to a worktree by branch name or index. Supports fuzzy matching.",
-		Examples: []string{
-			"wt switch main       # Switch to main branch worktree",
-			"wt s feat            # Short alias for switch with fuzzy matching",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Force interactive selection even for unique matches",
-			},
-		},
-		Aliases: []string{"go", "s"},
-		SeeAlso: []string{"wt list", "wt new"},
-	},
-	"s": {
-		Name:        "go",
-		Usage:       "wt s [branch|index] [options]",
-		Description: "Switch to a worktree by branch name or index. Supports fuzzy matching.",
-		Examples: []string{
-			"wt s main            # Switch to main branch worktree",
-			"wt s 1               # Switch to second worktree",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Force interactive selection even for unique matches",
-			},
-		},
-		Aliases: []string{"go", "switch"},
-		SeeAlso: []string{"wt list", "wt new"},
-	},
-	"new": {
-		Name:        "new",
-		Usage:       "wt new <branch> [options]",
-		Description: "Smart worktree creation that handles all branch states intelligently",
-		Examples: []string{
-			"wt new feature               # Create new branch + worktree",
-			"wt new existing-branch       # Create worktree for existing branch",
-			"wt new feature --base main   # Create new branch from main",
-			"wt new feature --no-switch   # Create worktree without switching to it",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--base <branch>",
-				Description: "Base branch for new branch creation",
-				Example:     "wt new feature --base develop",
-			},
-			{
-				Flag:        "--no-switch",
-				Description: "Create worktree without switching to it",
-				Example:     "wt new feature --no-switch",
-			},
-		},
-		SeeAlso: []string{"wt go", "wt rm"},
-	},
-	"rm": {
-		Name:        "rm",
-		Usage:       "wt rm [branch] [options]",
-		Description: "Remove a worktree. Supports fuzzy matching for branch names.",
-		Examples: []string{
-			"wt rm feature        # Remove worktree for 'feature' branch",
-			"wt rm feat           # Fuzzy match to remove 'feature' branch",
-			"wt rm --fuzzy        # Interactive selection of worktree to remove",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Force interactive selection for worktree to remove",
-				Example:     "wt rm -f",
-			},
-			{
-				Flag:        "--branch",
-				Description: "Remove the associated Git branch once the worktree is gone",
-				Example:     "wt rm feature --branch",
-			},
-			{
-				Flag:        "--force",
-				Description: "Allow branch deletion even if it is not merged (only with --branch)",
-				Example:     "wt rm feature --branch --force",
-			},
-		},
-		SeeAlso: []string{"wt list", "wt new", "wt integrate"},
-	},
-	"integrate": {
-		Name:        "integrate",
-		Usage:       "wt integrate [branch] [options]",
-		Description: "Rebase a worktree branch onto main, fast-forward merge it, then remove the worktree and branch.",
-		Examples: []string{
-			"wt integrate feature-auth          # Rebase, merge, and clean up",
-			"wt integrate feat --fuzzy          # Interactive selection",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Select the worktree interactively",
-				Example:     "wt integrate --fuzzy",
-			},
-		},
-		SeeAlso: []string{"wt rm", "wt list", "wt new"},
-	},
-	"env-copy": {
-		Name:        "env-copy",
-		Usage:       "wt env-copy [branch] [options]",
-		Description: "Copy .env files from current directory to target worktree",
-		Examples: []string{
-			"wt env-copy feature          # Copy .env to feature branch worktree",
-			"wt env-copy feat --recursive # Copy all .env* files recursively",
-			"wt env-copy --fuzzy          # Interactive selection of target",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--recursive",
-				Description: "Copy all .env* files recursively",
-				Example:     "wt env-copy feature --recursive",
-			},
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Force interactive selection of target worktree",
-				Example:     "wt env-copy -f",
-			},
-		},
-		SeeAlso: []string{"wt env", "wt go", "wt list"},
-	},
-	"env": {
-		Name:        "env",
-		Usage:       "wt env <subcommand> [options]",
-		Description: "Unified environment file management across worktrees",
-		Subcommands: []string{
-			"sync     Copy .env files to target worktree(s)",
-			"diff     Show differences between .env files",
-			"list     List all .env files across worktrees",
-		},
-		Examples: []string{
-			"wt env sync feature          # Copy .env files to feature worktree",
-			"wt env sync --all            # Sync .env to all other worktrees",
-			"wt env diff main             # Show differences with main worktree",
-			"wt env list                  # List all .env files across worktrees",
-			"wt env                       # Interactive environment operations",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--all",
-				Description: "Apply operation to all worktrees (sync only)",
-				Example:     "wt env sync --all",
-			},
-			{
-				Flag:        "--recursive",
-				Description: "Include all .env* files recursively",
-				Example:     "wt env sync feature --recursive",
-			},
-			{
-				Flag:        "--fuzzy",
-				ShortFlag:   "-f",
-				Description: "Force interactive selection of target worktree",
-				Example:     "wt env sync -f",
-			},
-		},
-		SeeAlso: []string{"wt env-copy", "wt go", "wt list"},
-	},
-	"project": {
-		Name:        "project",
-		Usage:       "wt project <subcommand> [options]",
-		Description: "Manage project configuration for custom commands and settings",
-		Subcommands: []string{
-			"init     Initialize project configuration",
-			"setup    Manage worktree setup automation",
-		},
-		Examples: []string{
-			"wt project init myproject    # Initialize project configuration",
-			"wt project setup run         # Run setup automation for current worktree",
-			"wt project setup show        # Show configured setup steps",
-		},
-		SeeAlso: []string{"wt new"},
-	},
-	"setup": {
-		Name:        "setup",
-		Usage:       "wt setup [options]",
-		Description: "Install wt with shell integration and completion",
-		Examples: []string{
-			"wt setup                     # Install with auto-detected completion",
-			"wt setup --completion bash   # Install with bash completion",
-			"wt setup --no-completion     # Install without completion",
-			"wt setup --check             # Check installation status",
-			"wt setup --uninstall         # Remove wt from system",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--completion <shell>",
-				Description: "Install completion for specified shell (auto|bash|zsh|none)",
-				Example:     "wt setup --completion zsh",
-			},
-			{
-				Flag:        "--no-completion",
-				Description: "Skip completion installation",
-			},
-			{
-				Flag:        "--check",
-				Description: "Check installation status without installing",
-			},
-			{
-				Flag:        "--uninstall",
-				Description: "Remove wt from system",
-			},
-		},
-		SeeAlso: []string{"wt completion"},
-	},
-	"update": {
-		Name:        "update",
-		Usage:       "wt update [options]",
-		Description: "Check for and install updates from GitHub releases",
-		Examples: []string{
-			"wt update               # Check and install latest version",
-			"wt update --check       # Check for updates without installing",
-			"wt update --force       # Force update even if already latest",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--check",
-				Description: "Check for updates without installing",
-			},
-			{
-				Flag:        "--force",
-				Description: "Force update even if already on latest version",
-			},
-		},
-		SeeAlso: []string{"wt version"},
-	},
-	"completion": {
-		Name:        "completion",
-		Usage:       "wt completion <shell>",
-		Description: "Generate shell completion scripts for bash or zsh",
-		Examples: []string{
-			"wt completion bash >> ~/.bashrc     # Install bash completion",
-			"wt completion zsh >> ~/.zshrc       # Install zsh completion",
-			"eval \"$(wt completion bash)\"        # Load completion in current session",
-		},
-		SeeAlso: []string{"wt setup"},
-	},
-	"version": {
-		Name:        "version",
-		Usage:       "wt version",
-		Description: "Show version information including build details",
-		Examples: []string{
-			"wt version           # Show current version",
-		},
-		SeeAlso: []string{"wt update"},
-	},
-	"recent": {
-		Name:        "recent",
-		Usage:       "wt recent [index] [options]",
-		Description: "Show and navigate to your recently active branches (default: only your branches, multi-line format)",
-		Examples: []string{
-			"wt recent                    # List your 10 most recent branches",
-			"wt recent -n 20              # List your 20 most recent branches",
-			"wt recent --all              # Show all branches regardless of author",
-			"wt recent --others           # Show only other users' branches",
-			"wt recent 2                  # Navigate to your branch at index 2",
-			"wt recent --all 2           # Navigate to branch at index 2 (all branches)",
-		},
-		Flags: []FlagHelp{
-			{
-				Flag:        "--all",
-				Description: "Show all branches regardless of author",
-				Example:     "wt recent --all",
-			},
-			{
-				Flag:        "--others",
-				Description: "Show only branches authored by other users",
-				Example:     "wt recent --others",
-			},
-			{
-				Flag:        "-n",
-				Description: "Number of branches to show (default: 10)",
-				Example:     "wt recent -n 20",
-			},
-			{
-				Flag:        "--verbose",
-				ShortFlag:   "-v",
-				Description: "Show detailed information about skipped branches",
-				Example:     "wt recent --verbose",
-			},
-			{
-				Flag:        "--compact",
-				ShortFlag:   "-c",
-				Description: "Use compact single-line format instead of multi-line",
-				Example:     "wt recent --compact",
-			},
-		},
-		SeeAlso: []string{"wt list", "wt go", "wt new"},
-	},
+// commandHelpMap holds the help registered for each command name and alias
+var commandHelpMap = map[string]CommandHelp{}
+
+// Register makes h the help shown for its command and for each of aliases
+func Register(h CommandHelp, aliases ...string) {
+	commandHelpMap[h.Name] = h
+	for _, alias := range aliases {
+		commandHelpMap[alias] = h
+	}
 }
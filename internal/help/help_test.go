@@ -24,161 +24,78 @@ func captureStdout(f func()) string {
 	return buf.String()
 }
 
+// registerTestPages stands in for the command registry, which registers
+// the real help pages, and restores the previous pages when t finishes
+func registerTestPages(t *testing.T) {
+	t.Helper()
+	saved := commandHelpMap
+	commandHelpMap = map[string]CommandHelp{}
+	t.Cleanup(func() { commandHelpMap = saved })
+
+	Register(CommandHelp{
+		Name:        "list",
+		Usage:       "wt list",
+		Description: "List all worktrees",
+		Examples:    []string{"wt list"},
+		Aliases:     []string{"ls"},
+	}, "ls")
+	Register(CommandHelp{
+		Name:        "new",
+		Usage:       "wt new <branch> [options]",
+		Description: "Create a worktree",
+		Flags:       []FlagHelp{{Flag: "--base <branch>", Description: "Base branch"}},
+		Examples:    []string{"wt new feature"},
+	})
+	Register(CommandHelp{
+		Name:        "go",
+		Usage:       "wt go [branch]",
+		Description: "Switch to a worktree",
+		Subcommands: []string{"here  Stay where you are"},
+		Flags:       []FlagHelp{{Flag: "--fuzzy", ShortFlag: "-f", Description: "Force interactive selection", Example: "wt go -f"}},
+		Aliases:     []string{"switch", "s"},
+		SeeAlso:     []string{"wt list"},
+	}, "switch", "s")
+}
+
 func TestShowCommandHelp(t *testing.T) {
+	registerTestPages(t)
 
-	tests := []struct {
-		name        string
-		command     string
-		wantContain []string
-		wantMissing []string
-	}{
-		{
-			name:    "list command help",
-			command: "list",
-			wantContain: []string{
-				"NAME",
-				"wt list",
-				"List all worktrees",
-				"USAGE",
-				"EXAMPLES",
-				"SEE ALSO",
-			},
-		},
-		{
-			name:    "new command help",
-			command: "new",
-			wantContain: []string{
-				"NAME",
-				"wt new",
-				"Smart worktree creation",
-				"USAGE",
-				"OPTIONS",
-				"EXAMPLES",
-			},
-		},
-		{
-			name:    "go command help",
-			command: "go",
-			wantContain: []string{
-				"NAME",
-				"wt go",
-				"Switch to a worktree",
-				"ALIASES",
-				"OPTIONS",
-				"--fuzzy",
-			},
-		},
-		{
-			name:    "rm command help",
-			command: "rm",
-			wantContain: []string{
-				"NAME",
-				"wt rm",
-				"Remove a worktree",
-				"OPTIONS",
-			},
-		},
-		{
-			name:    "integrate command help",
-			command: "integrate",
-			wantContain: []string{
-				"NAME",
-				"wt integrate",
-				"Rebase a worktree branch",
-				"OPTIONS",
-			},
-		},
-		{
-			name:    "env command help",
-			command: "env",
-			wantContain: []string{
-				"NAME",
-				"wt env",
-				"Unified environment file management",
-				"SUBCOMMANDS",
-				"sync",
-				"diff",
-				"list",
-			},
-		},
-		{
-			name:    "setup command help",
-			command: "setup",
-			wantContain: []string{
-				"NAME",
-				"wt setup",
-				"Install wt with shell integration",
-				"OPTIONS",
-			},
-		},
-		{
-			name:    "project command help",
-			command: "project",
-			wantContain: []string{
-				"NAME",
-				"wt project",
-				"Manage project configuration",
-				"SUBCOMMANDS",
-				"init",
-				"setup",
-			},
-		},
-		{
-			name:    "completion command help",
-			command: "completion",
-			wantContain: []string{
-				"NAME",
-				"wt completion",
-				"Generate shell completion",
-				"USAGE",
-				"bash",
-				"zsh",
-			},
-		},
-		{
-			name:    "update command help",
-			command: "update",
-			wantContain: []string{
-				"NAME",
-				"wt update",
-				"Check for and install updates",
-				"OPTIONS",
-			},
-		},
-		{
-			name:    "version command help",
-			command: "version",
-			wantContain: []string{
-				"NAME",
-				"wt version",
-				"Show version information",
-			},
-		},
-		// Unknown command test removed - it exits and can't be tested this way
+	output := captureStdout(func() {
+		ShowCommandHelp("go")
+	})
+
+	for _, want := range []string{
+		"NAME\n    wt go - Switch to a worktree",
+		"USAGE\n    wt go [branch]",
+		"ALIASES\n    switch, s",
+		"SUBCOMMANDS\n    here  Stay where you are",
+		"OPTIONS\n    --fuzzy, -f",
+		"Example: wt go -f",
+		"SEE ALSO\n    wt list",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("ShowCommandHelp(go) output missing %q:\n%s", want, output)
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			output := captureStdout(func() {
-				ShowCommandHelp(tt.command)
-			})
+	// Sections without content are left out
+	if strings.Contains(output, "EXAMPLES") {
+		t.Errorf("ShowCommandHelp(go) should not show an empty EXAMPLES section")
+	}
+}
 
-			for _, want := range tt.wantContain {
-				if !strings.Contains(output, want) {
-					t.Errorf("ShowCommandHelp(%q) output missing %q", tt.command, want)
-				}
-			}
+func TestRegister(t *testing.T) {
+	registerTestPages(t)
 
-			for _, missing := range tt.wantMissing {
-				if strings.Contains(output, missing) {
-					t.Errorf("ShowCommandHelp(%q) output should not contain %q", tt.command, missing)
-				}
-			}
-		})
+	for _, name := range []string{"go", "switch", "s"} {
+		if got := commandHelpMap[name].Name; got != "go" {
+			t.Errorf("help for %q is for %q, want go", name, got)
+		}
 	}
 }
 
 func TestHasHelpFlag(t *testing.T) {
+	registerTestPages(t)
 
 	tests := []struct {
 		name        string
@@ -242,57 +159,8 @@ func TestHasHelpFlag(t *testing.T) {
 	}
 }
 
-func TestCommandHelpMapContent(t *testing.T) {
-	expectedCommands := []string{
-		"list", "go", "rm", "integrate", "new", "env", "env-copy",
-		"project", "completion", "setup", "update", "version",
-	}
-
-	for _, cmd := range expectedCommands {
-		if _, exists := commandHelpMap[cmd]; !exists {
-			t.Errorf("Expected command %q to be in help map", cmd)
-		}
-	}
-
-	// Verify help content structure
-	listHelp := commandHelpMap["list"]
-	if listHelp.Name != "list" {
-		t.Errorf("list help name = %q, want %q", listHelp.Name, "list")
-	}
-	if listHelp.Usage == "" {
-		t.Error("list help usage should not be empty")
-	}
-	if listHelp.Description == "" {
-		t.Error("list help description should not be empty")
-	}
-	if len(listHelp.Examples) == 0 {
-		t.Error("list help should have examples")
-	}
-}
-
-// Remove ShowUsage test as the function doesn't exist
-
-func TestCommandHelpCompleteness(t *testing.T) {
-
-	// Every command should have complete help information
-	for cmd, help := range commandHelpMap {
-		if help.Name == "" {
-			t.Errorf("Command %q missing Name", cmd)
-		}
-		if help.Usage == "" {
-			t.Errorf("Command %q missing Usage", cmd)
-		}
-		if help.Description == "" {
-			t.Errorf("Command %q missing Description", cmd)
-		}
-		// Most commands should have examples (version might not)
-		if len(help.Examples) == 0 && cmd != "version" {
-			t.Errorf("Command %q missing Examples", cmd)
-		}
-	}
-}
-
 func TestHelpFormatting(t *testing.T) {
+	registerTestPages(t)
 
 	// Test that help output is properly formatted
 	output := captureStdout(func() {
@@ -325,30 +193,8 @@ func TestHelpFormatting(t *testing.T) {
 	}
 }
 
-func TestSubcommandHelp(t *testing.T) {
-
-	// Test commands with subcommands
-	envHelp := commandHelpMap["env"]
-	if len(envHelp.Subcommands) == 0 {
-		t.Error("env command should have subcommands")
-	}
-
-	expectedSubcommands := []string{"sync", "diff", "list"}
-	for _, sub := range expectedSubcommands {
-		found := false
-		for _, subCmd := range envHelp.Subcommands {
-			if strings.Contains(subCmd, sub) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			t.Errorf("env command missing subcommand %q", sub)
-		}
-	}
-}
-
 func TestAliasesInHelp(t *testing.T) {
+	registerTestPages(t)
 
 	// Commands with aliases should show them
 	tests := []struct {
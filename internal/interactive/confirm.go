@@ -0,0 +1,40 @@
+package interactive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// confirmInput and confirmOutput are replaced in tests
+var (
+	confirmInput  io.Reader = os.Stdin
+	confirmOutput io.Writer = os.Stderr
+)
+
+// Confirm asks a yes/no question on stderr and reads the answer from stdin.
+// An empty answer returns defaultYes. Callers check IsInteractiveInput first.
+func Confirm(question string, defaultYes bool) (bool, error) {
+	choices := "[y/N]"
+	if defaultYes {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(confirmOutput, "%s %s ", question, choices)
+
+	answer, err := bufio.NewReader(confirmInput).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(confirmOutput)
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return defaultYes, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,47 @@
+package interactive
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		defaultYes bool
+		want       bool
+		wantErr    bool
+		wantPrompt string
+	}{
+		{name: "yes", input: "y\n", want: true, wantPrompt: "Remove? [y/N] "},
+		{name: "full yes", input: "YES\n", want: true, wantPrompt: "Remove? [y/N] "},
+		{name: "no", input: "n\n", defaultYes: true, want: false, wantPrompt: "Remove? [Y/n] "},
+		{name: "empty uses default no", input: "\n", want: false, wantPrompt: "Remove? [y/N] "},
+		{name: "empty uses default yes", input: "\n", defaultYes: true, want: true, wantPrompt: "Remove? [Y/n] "},
+		{name: "other answer is no", input: "maybe\n", defaultYes: true, want: false, wantPrompt: "Remove? [Y/n] "},
+		{name: "answer without newline", input: "y", want: true, wantPrompt: "Remove? [y/N] "},
+		{name: "closed input", input: "", wantErr: true, wantPrompt: "Remove? [y/N] \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			oldInput, oldOutput := confirmInput, confirmOutput
+			confirmInput, confirmOutput = strings.NewReader(tt.input), &out
+			defer func() { confirmInput, confirmOutput = oldInput, oldOutput }()
+
+			got, err := Confirm("Remove?", tt.defaultYes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Confirm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm() = %v, want %v", got, tt.want)
+			}
+			if out.String() != tt.wantPrompt {
+				t.Errorf("prompt = %q, want %q", out.String(), tt.wantPrompt)
+			}
+		})
+	}
+}
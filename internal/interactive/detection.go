@@ -8,7 +8,14 @@ import (
 
 // IsInteractive returns true if the current session supports interactive features
 func IsInteractive() bool {
-	return isTerminal() && !isDisabled() && !isCIEnvironment()
+	return isTerminal() && !isDisabled() && !IsCIEnvironment()
+}
+
+// IsInteractiveInput returns true if the user can answer prompts even when stdout
+// is captured, e.g. by the shell wrapper. The fuzzy finder draws on the
+// controlling terminal, so only stdin needs to be a terminal.
+func IsInteractiveInput() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) && !isDisabled() && !IsCIEnvironment()
 }
 
 // isTerminal checks if both stdin and stdout are connected to a terminal
@@ -23,8 +30,8 @@ func isDisabled() bool {
 		os.Getenv("NO_COLOR") != "" // Respect NO_COLOR convention
 }
 
-// isCIEnvironment checks if running in a CI/automated environment
-func isCIEnvironment() bool {
+// IsCIEnvironment checks if running in a CI/automated environment
+func IsCIEnvironment() bool {
 	ciVars := []string{
 		"CI",
 		"CONTINUOUS_INTEGRATION",
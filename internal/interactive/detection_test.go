@@ -182,8 +182,8 @@ func TestIsCIEnvironment(t *testing.T) {
 			// Set CI variable
 			os.Setenv(ciVar, "true")
 
-			if !isCIEnvironment() {
-				t.Errorf("isCIEnvironment() should return true when %s is set", ciVar)
+			if !IsCIEnvironment() {
+				t.Errorf("IsCIEnvironment() should return true when %s is set", ciVar)
 			}
 		})
 	}
@@ -205,8 +205,8 @@ func TestIsCIEnvironment(t *testing.T) {
 			}
 		}()
 
-		if isCIEnvironment() {
-			t.Error("isCIEnvironment() should return false when no CI variables are set")
+		if IsCIEnvironment() {
+			t.Error("IsCIEnvironment() should return false when no CI variables are set")
 		}
 	})
 }
@@ -22,6 +22,9 @@ type SelectOptions struct {
 	Header      string
 	PreviewFunc func(int, int, int) string // index, width, height -> preview content
 	Multi       bool                       // Allow multiple selections
+	// CapturedOutput allows the finder when only stdin is a terminal, for
+	// commands whose stdout is read by the shell wrapper
+	CapturedOutput bool
 }
 
 // SelectResult contains the result of a selection operation
@@ -36,7 +39,7 @@ func Select(items []string, opts SelectOptions) (*SelectResult, error) {
 		return nil, errors.New("no items to select from")
 	}
 
-	if !IsInteractive() {
+	if !IsInteractive() && !(opts.CapturedOutput && IsInteractiveInput()) {
 		return selectWithFallback(items, opts)
 	}
 
@@ -0,0 +1,282 @@
+// Package runtimes manages per-worktree language environments: Python
+// virtualenvs created with venv, uv, poetry or conda, and node versions
+// selected with nvm.
+//
+// Every action returns a shell command rather than running the tool itself, so
+// the shell wrapper can evaluate it through an exec directive. This is what
+// lets shell functions such as nvm and conda activate work alongside ordinary
+// binaries.
+package runtimes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/directive"
+)
+
+// Runtime types accepted in a project's runtimes list
+const (
+	TypeVenv   = "venv"
+	TypeUV     = "uv"
+	TypePoetry = "poetry"
+	TypeConda  = "conda"
+	TypeNode   = "node"
+)
+
+// Actions that can be performed on a runtime
+const (
+	ActionCreate   = "create"
+	ActionActivate = "activate"
+	ActionRemove   = "remove"
+)
+
+// Runtime is a language environment belonging to one worktree
+type Runtime interface {
+	// Type returns the runtime type, e.g. "uv"
+	Type() string
+	// Create returns the command that creates the environment
+	Create() (string, error)
+	// Activate returns the command that activates it in the calling shell
+	Activate() (string, error)
+	// Remove returns the command that deletes it
+	Remove() (string, error)
+}
+
+// Types returns the supported runtime types
+func Types() []string {
+	return []string{TypeConda, TypeNode, TypePoetry, TypeUV, TypeVenv}
+}
+
+// New returns the runtime described by cfg for the worktree at dir
+func New(cfg config.RuntimeConfig, dir string) (Runtime, error) {
+	switch cfg.Type {
+	case TypeVenv, TypeUV:
+		path, err := EnvPath(dir, cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &pythonEnv{tool: cfg.Type, path: path, python: cfg.Python}, nil
+	case TypePoetry:
+		return &poetryEnv{dir: dir, python: cfg.Python}, nil
+	case TypeConda:
+		name := cfg.Name
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+		return &condaEnv{name: name, python: cfg.Python}, nil
+	case TypeNode:
+		return &nodeVersion{dir: dir, version: cfg.Version}, nil
+	case "":
+		return nil, fmt.Errorf("runtime type is required (%s)", strings.Join(Types(), ", "))
+	default:
+		return nil, fmt.Errorf("unknown runtime type '%s' (%s)", cfg.Type, strings.Join(Types(), ", "))
+	}
+}
+
+// Commands returns the command performing action for each runtime of the
+// worktree at dir, in configuration order
+func Commands(configs []config.RuntimeConfig, dir, action string) ([]string, error) {
+	var commands []string
+	for _, cfg := range configs {
+		rt, err := New(cfg, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		var command string
+		switch action {
+		case ActionCreate:
+			command, err = rt.Create()
+		case ActionActivate:
+			command, err = rt.Activate()
+		case ActionRemove:
+			command, err = rt.Remove()
+		default:
+			return nil, fmt.Errorf("unknown runtime action '%s'", action)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", rt.Type(), err)
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// EnvPath returns the environment directory name inside root, defaulting to
+// .venv, and rejects names that escape root
+func EnvPath(root, name string) (string, error) {
+	if name == "" {
+		name = ".venv"
+	}
+
+	path := filepath.Join(root, name)
+
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("invalid virtualenv path: must be within repository")
+	}
+	return path, nil
+}
+
+// ActivateVenv returns the command sourcing the activate script of the
+// virtualenv at path in the wrapper's shell
+func ActivateVenv(path string) (string, error) {
+	activateScript := filepath.Join(path, "bin", "activate")
+	sourceCmd := "source"
+	switch directive.Shell() {
+	case "fish":
+		activateScript += ".fish"
+	case "sh":
+		sourceCmd = "."
+	}
+	if _, err := os.Stat(activateScript); os.IsNotExist(err) {
+		return "", fmt.Errorf("virtualenv not found at %s", path)
+	}
+	// Quote the path for the wrapper's shell to prevent injection
+	return sourceCmd + " " + directive.Quote(activateScript), nil
+}
+
+// pythonEnv is a virtualenv inside the worktree, created by python -m venv or uv
+type pythonEnv struct {
+	tool   string
+	path   string
+	python string
+}
+
+func (e *pythonEnv) Type() string { return e.tool }
+
+func (e *pythonEnv) Create() (string, error) {
+	if e.tool == TypeUV {
+		command := "uv venv"
+		if e.python != "" {
+			command += " --python " + directive.Quote(e.python)
+		}
+		return command + " " + directive.Quote(e.path), nil
+	}
+
+	python := e.python
+	if python == "" {
+		python = "python3"
+	}
+	return directive.Quote(python) + " -m venv " + directive.Quote(e.path), nil
+}
+
+func (e *pythonEnv) Activate() (string, error) {
+	return ActivateVenv(e.path)
+}
+
+func (e *pythonEnv) Remove() (string, error) {
+	return "rm -rf " + directive.Quote(e.path), nil
+}
+
+// poetryEnv is the virtualenv poetry manages for the worktree's pyproject.toml
+type poetryEnv struct {
+	dir    string
+	python string
+}
+
+func (e *poetryEnv) Type() string { return TypePoetry }
+
+func (e *poetryEnv) poetry() string {
+	return "poetry -C " + directive.Quote(e.dir)
+}
+
+func (e *poetryEnv) Create() (string, error) {
+	python := e.python
+	if python == "" {
+		python = "python3"
+	}
+	return e.poetry() + " env use " + directive.Quote(python), nil
+}
+
+func (e *poetryEnv) Activate() (string, error) {
+	infoPath := e.poetry() + " env info --path"
+	switch directive.Shell() {
+	case "fish":
+		return "source (" + infoPath + ")/bin/activate.fish", nil
+	case "sh":
+		return `. "$(` + infoPath + `)/bin/activate"`, nil
+	default:
+		return `source "$(` + infoPath + `)/bin/activate"`, nil
+	}
+}
+
+func (e *poetryEnv) Remove() (string, error) {
+	return e.poetry() + " env remove --all", nil
+}
+
+// condaEnv is a named conda environment
+type condaEnv struct {
+	name   string
+	python string
+}
+
+func (e *condaEnv) Type() string { return TypeConda }
+
+func (e *condaEnv) Create() (string, error) {
+	command := "conda create -y -n " + directive.Quote(e.name)
+	if e.python != "" {
+		command += " " + directive.Quote("python="+e.python)
+	}
+	return command, nil
+}
+
+func (e *condaEnv) Activate() (string, error) {
+	return "conda activate " + directive.Quote(e.name), nil
+}
+
+func (e *condaEnv) Remove() (string, error) {
+	return "conda env remove -y -n " + directive.Quote(e.name), nil
+}
+
+// nodeVersion is a node version selected with nvm, read from the config or
+// from the worktree's .nvmrc or .node-version
+type nodeVersion struct {
+	dir     string
+	version string
+}
+
+func (n *nodeVersion) Type() string { return TypeNode }
+
+func (n *nodeVersion) resolve() (string, error) {
+	if n.version != "" {
+		return n.version, nil
+	}
+	for _, name := range []string{".nvmrc", ".node-version"} {
+		data, err := os.ReadFile(filepath.Join(n.dir, name))
+		if err != nil {
+			continue
+		}
+		if version := strings.TrimSpace(string(data)); version != "" {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("no node version: set 'version' or add .nvmrc or .node-version to %s", n.dir)
+}
+
+func (n *nodeVersion) Create() (string, error) {
+	version, err := n.resolve()
+	if err != nil {
+		return "", err
+	}
+	return "nvm install " + directive.Quote(version), nil
+}
+
+func (n *nodeVersion) Activate() (string, error) {
+	version, err := n.resolve()
+	if err != nil {
+		return "", err
+	}
+	return "nvm use " + directive.Quote(version), nil
+}
+
+func (n *nodeVersion) Remove() (string, error) {
+	version, err := n.resolve()
+	if err != nil {
+		return "", err
+	}
+	return "nvm uninstall " + directive.Quote(version), nil
+}
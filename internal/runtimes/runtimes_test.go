@@ -0,0 +1,214 @@
+package runtimes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/internal/config"
+	"github.com/tobiase/worktree-utils/internal/directive"
+)
+
+func TestCommands(t *testing.T) {
+	t.Setenv(directive.ShellEnvVar, "bash")
+
+	dir := t.TempDir()
+	venvBin := filepath.Join(dir, ".venv", "bin")
+	if err := os.MkdirAll(venvBin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(venvBin, "activate"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".nvmrc"), []byte("20.11\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	q := func(s string) string { return "'" + s + "'" }
+
+	tests := []struct {
+		name    string
+		runtime config.RuntimeConfig
+		want    map[string]string
+	}{
+		{
+			name:    "venv",
+			runtime: config.RuntimeConfig{Type: "venv"},
+			want: map[string]string{
+				ActionCreate:   q("python3") + " -m venv " + q(filepath.Join(dir, ".venv")),
+				ActionActivate: "source " + q(filepath.Join(venvBin, "activate")),
+				ActionRemove:   "rm -rf " + q(filepath.Join(dir, ".venv")),
+			},
+		},
+		{
+			name:    "uv",
+			runtime: config.RuntimeConfig{Type: "uv", Python: "3.12"},
+			want: map[string]string{
+				ActionCreate:   "uv venv --python " + q("3.12") + " " + q(filepath.Join(dir, ".venv")),
+				ActionActivate: "source " + q(filepath.Join(venvBin, "activate")),
+				ActionRemove:   "rm -rf " + q(filepath.Join(dir, ".venv")),
+			},
+		},
+		{
+			name:    "poetry",
+			runtime: config.RuntimeConfig{Type: "poetry"},
+			want: map[string]string{
+				ActionCreate:   "poetry -C " + q(dir) + " env use " + q("python3"),
+				ActionActivate: `source "$(poetry -C ` + q(dir) + ` env info --path)/bin/activate"`,
+				ActionRemove:   "poetry -C " + q(dir) + " env remove --all",
+			},
+		},
+		{
+			name:    "conda defaults to the worktree name",
+			runtime: config.RuntimeConfig{Type: "conda", Python: "3.11"},
+			want: map[string]string{
+				ActionCreate:   "conda create -y -n " + q(filepath.Base(dir)) + " " + q("python=3.11"),
+				ActionActivate: "conda activate " + q(filepath.Base(dir)),
+				ActionRemove:   "conda env remove -y -n " + q(filepath.Base(dir)),
+			},
+		},
+		{
+			name:    "node reads .nvmrc",
+			runtime: config.RuntimeConfig{Type: "node"},
+			want: map[string]string{
+				ActionCreate:   "nvm install " + q("20.11"),
+				ActionActivate: "nvm use " + q("20.11"),
+				ActionRemove:   "nvm uninstall " + q("20.11"),
+			},
+		},
+		{
+			name:    "node version from config",
+			runtime: config.RuntimeConfig{Type: "node", Version: "lts/iron"},
+			want: map[string]string{
+				ActionActivate: "nvm use " + q("lts/iron"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for action, want := range tt.want {
+				got, err := Commands([]config.RuntimeConfig{tt.runtime}, dir, action)
+				if err != nil {
+					t.Fatalf("Commands(%s) error = %v", action, err)
+				}
+				if !reflect.DeepEqual(got, []string{want}) {
+					t.Errorf("Commands(%s) = %q, want %q", action, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandsErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		runtime config.RuntimeConfig
+		action  string
+		wantErr string
+	}{
+		{"unknown type", config.RuntimeConfig{Type: "rbenv"}, ActionCreate, "unknown runtime type 'rbenv'"},
+		{"missing type", config.RuntimeConfig{}, ActionCreate, "runtime type is required"},
+		{"unknown action", config.RuntimeConfig{Type: "venv"}, "freeze", "unknown runtime action"},
+		{"missing virtualenv", config.RuntimeConfig{Type: "venv"}, ActionActivate, "virtualenv not found"},
+		{"venv outside worktree", config.RuntimeConfig{Type: "uv", Name: "../shared"}, ActionCreate, "must be within repository"},
+		{"node without version", config.RuntimeConfig{Type: "node"}, ActionActivate, "no node version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Commands([]config.RuntimeConfig{tt.runtime}, dir, tt.action)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Commands() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		envName string
+		want    string
+		wantErr bool
+	}{
+		{"default", "", "/repo/.venv", false},
+		{"nested", "envs/dev", "/repo/envs/dev", false},
+		{"parent", "../shared", "", true},
+		{"sibling with the root as prefix", "../repo-evil", "", true},
+		{"absolute", "/tmp/venv", "/repo/tmp/venv", false}, // Joined below root
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EnvPath("/repo", tt.envName)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("EnvPath(%q) = %q, %v; want %q, error %v", tt.envName, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActivateVenvShells(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"activate", "activate.fish"} {
+		if err := os.WriteFile(filepath.Join(bin, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]string{
+		"bash": "source '" + filepath.Join(bin, "activate") + "'",
+		"sh":   ". '" + filepath.Join(bin, "activate") + "'",
+		"fish": "source '" + filepath.Join(bin, "activate.fish") + "'",
+	}
+	for shell, want := range tests {
+		t.Setenv(directive.ShellEnvVar, shell)
+		got, err := ActivateVenv(dir)
+		if err != nil {
+			t.Fatalf("ActivateVenv() for %s error = %v", shell, err)
+		}
+		if got != want {
+			t.Errorf("ActivateVenv() for %s = %q, want %q", shell, got, want)
+		}
+	}
+}
+
+// TestSchemaRuntimeTypes keeps the runtime types in the published JSON Schema
+// in sync with the supported ones
+func TestSchemaRuntimeTypes(t *testing.T) {
+	data, err := os.ReadFile("../../schema/project.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties struct {
+			Runtimes struct {
+				Items struct {
+					Properties struct {
+						Type struct {
+							Enum []string `json:"enum"`
+						} `json:"type"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"runtimes"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	got := schema.Properties.Runtimes.Items.Properties.Type.Enum
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, Types()) {
+		t.Errorf("schema runtime types = %v, want %v", got, Types())
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"strings"
 
 	"github.com/tobiase/worktree-utils/internal/completion"
-	"github.com/tobiase/worktree-utils/internal/config"
 )
 
 const initScript = `# worktree-utils shell initialization
@@ -35,16 +34,25 @@ if command -v wt-bin &> /dev/null; then
 fi
 `
 
+// fishConfigTemplate is written to fish's conf.d; %s is replaced with the
+// completion line, if any
+const fishConfigTemplate = `# worktree-utils shell initialization
+if type -q wt-bin
+    wt-bin shell-init fish | source
+%send
+`
+
 // CompletionOptions controls which completion scripts to install
 type CompletionOptions struct {
 	Install bool
-	Shell   string // "auto", "bash", "zsh", "none"
+	Shell   string // "auto", "bash", "zsh", "fish", "none"
 }
 
 const (
 	shellAuto = "auto"
 	shellBash = "bash"
 	shellZsh  = "zsh"
+	shellFish = "fish"
 )
 
 // detectUserShell determines the user's primary shell
@@ -52,6 +60,8 @@ func detectUserShell() string {
 	shell := os.Getenv("SHELL")
 	if strings.Contains(shell, shellZsh) {
 		return shellZsh
+	} else if strings.Contains(shell, shellFish) {
+		return shellFish
 	} else if strings.Contains(shell, shellBash) {
 		return shellBash
 	}
@@ -66,24 +76,17 @@ func generateCompletionFiles(configDir string) error {
 
 // generateCompletionFilesForShells creates completion script files for specified shells
 func generateCompletionFilesForShells(configDir string, shells []string) error {
-	// Create config manager for completion generation
-	configMgr, err := config.NewManager()
-	if err != nil {
-		// If config fails, create completion without project integration
-		configMgr = nil
-	}
-
 	for _, shell := range shells {
 		switch shell {
 		case "bash":
-			bashCompletion := completion.GenerateBashCompletion(configMgr)
+			bashCompletion := completion.GenerateBashCompletion()
 			bashPath := filepath.Join(configDir, "completion.bash")
 			if err := os.WriteFile(bashPath, []byte(bashCompletion), 0644); err != nil {
 				return fmt.Errorf("failed to write bash completion: %v", err)
 			}
 
 		case "zsh":
-			zshCompletion := completion.GenerateZshCompletion(configMgr)
+			zshCompletion := completion.GenerateZshCompletion()
 
 			// Create zsh completions directory
 			zshCompletionDir := filepath.Join(configDir, "completions")
@@ -102,6 +105,26 @@ func generateCompletionFilesForShells(configDir string, shells []string) error {
 	return nil
 }
 
+// CompletionFiles returns the completion scripts setup installs in configDir,
+// keyed by path, as this binary generates them
+func CompletionFiles(configDir string) map[string]string {
+	return map[string]string{
+		filepath.Join(configDir, "completion.bash"):    completion.GenerateBashCompletion(),
+		filepath.Join(configDir, "completions", "_wt"): completion.GenerateZshCompletion(),
+	}
+}
+
+// InitScript returns the init.sh contents setup installs
+func InitScript() string {
+	return initScript
+}
+
+// ShellConfigFiles returns the existing shell startup files in homeDir that
+// setup may add wt initialization to
+func ShellConfigFiles(homeDir string) []string {
+	return detectShellConfigs(homeDir)
+}
+
 // shellConfig represents a shell configuration file and its completion
 type shellConfig struct {
 	path           string
@@ -129,7 +152,7 @@ func installCompletion(configDir string, opts CompletionOptions) error {
 
 // validateShellOption checks if the shell option is supported
 func validateShellOption(shell string) error {
-	validShells := []string{shellAuto, shellBash, shellZsh}
+	validShells := []string{shellAuto, shellBash, shellZsh, shellFish}
 	for _, validShell := range validShells {
 		if shell == validShell {
 			return nil
@@ -253,10 +276,20 @@ func SetupWithOptions(currentBinaryPath string, completionOpts CompletionOptions
 
 	// Add shell function to shell configs
 	shellConfigs := detectShellConfigs(homeDir)
-	if len(shellConfigs) == 0 {
+	useFish := usesFish(homeDir)
+	if len(shellConfigs) == 0 && !useFish {
 		return fmt.Errorf("no shell configuration files found")
 	}
 
+	if useFish {
+		fishPath := fishConfigPath(homeDir)
+		if err := writeFishConfig(fishPath, completionOpts); err != nil {
+			fmt.Printf("Warning: failed to write %s: %v\n", fishPath, err)
+		} else {
+			fmt.Printf("✓ Updated %s\n", fishPath)
+		}
+	}
+
 	shellFunctionLine := "source <(wt-bin shell-init)"
 
 	for _, configFile := range shellConfigs {
@@ -321,6 +354,14 @@ func Uninstall() error {
 		fmt.Printf("✓ Removed config directory\n")
 	}
 
+	// Remove fish initialization
+	fishPath := fishConfigPath(homeDir)
+	if err := os.Remove(fishPath); err == nil {
+		fmt.Printf("✓ Removed %s\n", fishPath)
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove %s: %v\n", fishPath, err)
+	}
+
 	fmt.Printf("\n✓ Uninstall complete!\n")
 	fmt.Printf("\nPlease manually remove the wt initialization line from your shell config files:\n")
 	fmt.Printf("  ~/.bashrc, ~/.zshrc, etc.\n")
@@ -401,6 +442,13 @@ func Check() error {
 		}
 	}
 
+	fishPath := fishConfigPath(homeDir)
+	if _, err := os.Stat(fishPath); err == nil {
+		fmt.Printf("✓ wt configured in %s\n", fishPath)
+	} else if usesFish(homeDir) {
+		fmt.Printf("✗ wt not configured in %s\n", fishPath)
+	}
+
 	return nil
 }
 
@@ -474,6 +522,34 @@ func detectShellConfigs(homeDir string) []string {
 	return configs
 }
 
+// fishConfigPath returns the conf.d file that initializes wt for fish
+func fishConfigPath(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "fish", "conf.d", "wt.fish")
+}
+
+// usesFish reports whether fish is the login shell or has a config directory
+func usesFish(homeDir string) bool {
+	if strings.Contains(os.Getenv("SHELL"), shellFish) {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(homeDir, ".config", "fish"))
+	return err == nil && info.IsDir()
+}
+
+// writeFishConfig writes the fish conf.d file, including completion if enabled for fish
+func writeFishConfig(path string, completionOpts CompletionOptions) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	completionLine := ""
+	if completionOpts.Install && (completionOpts.Shell == shellAuto || completionOpts.Shell == shellFish) {
+		completionLine = "    wt-bin completion fish | source\n"
+	}
+
+	return os.WriteFile(path, []byte(fmt.Sprintf(fishConfigTemplate, completionLine)), 0644)
+}
+
 // addToShellConfig adds a line to shell config if not already present
 func addToShellConfig(configFile, line string) error {
 	// Check if already configured
@@ -177,6 +177,29 @@ func TestSetup(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "successful installation with fish",
+			env: func() *testEnv {
+				env := newTestEnv(t)
+				env.shell = "/usr/bin/fish"
+				env.files["mock-wt-bin"] = []byte("#!/bin/sh\necho mock")
+				// No POSIX shell config files
+				return env
+			}(),
+			binaryPath: "mock-wt-bin",
+			wantError:  false,
+			checkResult: func(t *testing.T, env *testEnv) {
+				content, err := os.ReadFile(filepath.Join(env.homeDir, ".config", "fish", "conf.d", "wt.fish"))
+				if err != nil {
+					t.Fatalf("Fish config not written: %v", err)
+				}
+				for _, want := range []string{"wt-bin shell-init fish | source", "wt-bin completion fish | source"} {
+					if !strings.Contains(string(content), want) {
+						t.Errorf("Fish config missing %q:\n%s", want, content)
+					}
+				}
+			},
+		},
 		{
 			name: "no shell configs found",
 			env: func() *testEnv {
@@ -866,9 +889,14 @@ func TestDetectUserShell(t *testing.T) {
 			shell:    "/usr/local/bin/bash",
 			expected: "bash",
 		},
+		{
+			name:     "fish shell",
+			shell:    "/usr/local/bin/fish",
+			expected: "fish",
+		},
 		{
 			name:     "unknown shell defaults to bash",
-			shell:    "/bin/fish",
+			shell:    "/bin/tcsh",
 			expected: "bash",
 		},
 		{
@@ -1002,7 +1030,7 @@ func TestInstallCompletion(t *testing.T) {
 			name: "unsupported shell",
 			opts: CompletionOptions{
 				Install: true,
-				Shell:   "fish",
+				Shell:   "tcsh",
 			},
 			wantErr:    true,
 			checkFiles: []string{},
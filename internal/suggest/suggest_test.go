@@ -0,0 +1,24 @@
+package suggest
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"list", "list", 0},
+		{"lsit", "list", 1}, // Transposition
+		{"chanel", "channel", 1},
+		{"pahts", "paths", 1},
+		{"setp", "setup", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckStateFile is where the background update check caches its result,
+// relative to the wt config directory
+const CheckStateFile = "update-check.json"
+
+// DefaultCheckInterval is how often the background check runs by default
+const DefaultCheckInterval = 24 * time.Hour
+
+// CheckState is the cached result of the last background update check
+type CheckState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Channel   string    `json:"channel"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// LoadCheckState reads the cached check result. A missing or unreadable
+// cache is treated as never having checked.
+func LoadCheckState(path string) CheckState {
+	var state CheckState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CheckState{}
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return CheckState{}
+	}
+	return state
+}
+
+// SaveCheckState writes the cached check result, creating its directory
+func SaveCheckState(path string, state CheckState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a concurrent reader never sees half a file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Due reports whether a new check should run: the last one is older than
+// interval, or watched another channel
+func (s CheckState) Due(channel string, interval time.Duration, now time.Time) bool {
+	return s.Channel != channel || now.Sub(s.CheckedAt) >= interval
+}
+
+// Notice returns a one-line message when the cached latest release is newer
+// than currentVersion, or "" when there is nothing to say
+func (s CheckState) Notice(currentVersion string) string {
+	if s.Latest == "" || !isNewer(s.Latest, currentVersion) {
+		return ""
+	}
+	command := "wt update"
+	if s.Channel == ChannelBeta {
+		command += " --channel beta"
+	}
+	return fmt.Sprintf("A new version of wt is available: %s → %s (run '%s')", currentVersion, s.Latest, command)
+}
+
+// BeginCheck records that a check of channel is starting, so other wt
+// processes wait for the next interval instead of starting their own. The
+// time is kept even if the check then fails, so an unreachable GitHub is
+// retried at the next interval rather than on every command.
+func BeginCheck(path, channel string) error {
+	state := LoadCheckState(path)
+	state.CheckedAt = time.Now()
+	if state.Channel != channel {
+		state.Channel = channel
+		state.Latest = ""
+	}
+	return SaveCheckState(path, state)
+}
+
+// RunCheck looks up the newest release on channel and caches it at path
+func RunCheck(path, currentVersion, channel string) error {
+	release, _, err := CheckChannel(currentVersion, channel)
+	if err != nil {
+		return err
+	}
+
+	state := LoadCheckState(path)
+	state.CheckedAt = time.Now()
+	state.Channel = channel
+	state.Latest = release.TagName
+	return SaveCheckState(path, state)
+}
@@ -0,0 +1,103 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckStateDue(t *testing.T) {
+	now := time.Now()
+	state := CheckState{CheckedAt: now.Add(-2 * time.Hour), Channel: ChannelStable}
+
+	if state.Due(ChannelStable, 24*time.Hour, now) {
+		t.Error("A check two hours ago should not be due daily")
+	}
+	if !state.Due(ChannelStable, time.Hour, now) {
+		t.Error("A check two hours ago should be due hourly")
+	}
+	if !state.Due(ChannelBeta, 24*time.Hour, now) {
+		t.Error("Switching channel should make a check due")
+	}
+	if !(CheckState{}).Due(ChannelStable, 24*time.Hour, now) {
+		t.Error("Never having checked should make a check due")
+	}
+}
+
+func TestCheckStateNotice(t *testing.T) {
+	tests := []struct {
+		state   CheckState
+		current string
+		want    string
+	}{
+		{CheckState{Channel: ChannelStable, Latest: "v1.2.0"}, "v1.1.0", "v1.1.0 → v1.2.0 (run 'wt update')"},
+		{CheckState{Channel: ChannelBeta, Latest: "v1.2.0-beta.1"}, "v1.1.0", "(run 'wt update --channel beta')"},
+		{CheckState{Channel: ChannelStable, Latest: "v1.2.0"}, "v1.2.0", ""},
+		{CheckState{Channel: ChannelStable, Latest: "v1.2.0"}, "v1.3.0", ""},
+		{CheckState{Channel: ChannelStable}, "v1.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		got := tt.state.Notice(tt.current)
+		if tt.want == "" && got != "" || tt.want != "" && !strings.Contains(got, tt.want) {
+			t.Errorf("Notice(%q) for %+v = %q, want %q", tt.current, tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wt", CheckStateFile)
+
+	if state := LoadCheckState(path); !state.CheckedAt.IsZero() {
+		t.Errorf("Missing state should be empty, got %+v", state)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if state := LoadCheckState(path); !state.CheckedAt.IsZero() {
+		t.Errorf("Corrupt state should be empty, got %+v", state)
+	}
+
+	want := CheckState{CheckedAt: time.Now().Round(0).UTC(), Channel: ChannelBeta, Latest: "v2.0.0-beta.1"}
+	if err := SaveCheckState(path, want); err != nil {
+		t.Fatalf("SaveCheckState() error = %v", err)
+	}
+	got := LoadCheckState(path)
+	if !got.CheckedAt.Equal(want.CheckedAt) || got.Channel != want.Channel || got.Latest != want.Latest {
+		t.Errorf("LoadCheckState() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	releasesServer(t, []Release{
+		{TagName: "v1.3.0-beta.1", Prerelease: true},
+		{TagName: "v1.2.0"},
+	})
+	path := filepath.Join(t.TempDir(), CheckStateFile)
+
+	if err := SaveCheckState(path, CheckState{Channel: ChannelBeta, Latest: "v1.3.0-beta.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Switching channel forgets the other channel's result straight away
+	if err := BeginCheck(path, ChannelStable); err != nil {
+		t.Fatalf("BeginCheck() error = %v", err)
+	}
+	state := LoadCheckState(path)
+	if state.Channel != ChannelStable || state.Latest != "" || state.Due(ChannelStable, time.Hour, time.Now()) {
+		t.Errorf("BeginCheck() left %+v", state)
+	}
+
+	if err := RunCheck(path, "v1.0.0", ChannelStable); err != nil {
+		t.Fatalf("RunCheck() error = %v", err)
+	}
+	if state := LoadCheckState(path); state.Latest != "v1.2.0" {
+		t.Errorf("RunCheck() cached %+v, want latest v1.2.0", state)
+	}
+}
@@ -0,0 +1,127 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// backupSuffix names the previous binary kept next to the executable
+const backupSuffix = ".previous"
+
+// smokeTestTimeout bounds each command run against a new binary
+const smokeTestTimeout = 10 * time.Second
+
+// smokeTest runs a freshly extracted binary before it replaces the current
+// one and returns what it reports as its version. It can be overridden for
+// testing.
+var smokeTest = runSmokeTest
+
+// runSmokeTest checks that the binary at path starts and can print its
+// version and shell integration
+func runSmokeTest(path string) (string, error) {
+	var versionOutput string
+	for _, args := range [][]string{{"version"}, {"shell-init"}} {
+		ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+		cmd := exec.CommandContext(ctx, path, args...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		cancel()
+		if err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				return "", fmt.Errorf("'%s' failed: %w", strings.Join(args, " "), err)
+			}
+			return "", fmt.Errorf("'%s' failed: %w: %s", strings.Join(args, " "), err, msg)
+		}
+		if args[0] == "version" {
+			versionOutput = strings.TrimSpace(stdout.String())
+		}
+	}
+	return versionOutput, nil
+}
+
+// backupBinary keeps a copy of the binary at exePath as its backup,
+// replacing any older one. The executable itself is left in place, so a
+// failure here never leaves wt missing.
+func backupBinary(exePath string) error {
+	backup := exePath + backupSuffix
+	tmp := backup + ".tmp"
+	_ = os.Remove(tmp)
+
+	// A hard link is instant; fall back to copying across filesystems
+	if err := os.Link(exePath, tmp); err != nil {
+		if err := copyFile(exePath, tmp); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, backup)
+}
+
+// Rollback restores the binary replaced by the last update. The binary
+// being replaced becomes the new backup, so rolling back twice returns to
+// where you started. It returns the restored binary's version output.
+func Rollback() (string, error) {
+	exePath, err := executablePath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	backup := exePath + backupSuffix
+	if _, err := os.Stat(backup); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no previous version to roll back to")
+		}
+		return "", err
+	}
+
+	restored, err := smokeTest(backup)
+	if err != nil {
+		return "", fmt.Errorf("previous version failed its smoke test: %w", err)
+	}
+
+	// Set the current binary aside, move the backup into place, then make
+	// the set-aside binary the backup
+	swap := backup + ".swap"
+	_ = os.Remove(swap)
+	if err := os.Link(exePath, swap); err != nil {
+		if err := copyFile(exePath, swap); err != nil {
+			return "", fmt.Errorf("failed to save current binary: %w", err)
+		}
+	}
+	defer os.Remove(swap)
+
+	if err := os.Rename(backup, exePath); err != nil {
+		return "", fmt.Errorf("failed to restore previous binary: %w", err)
+	}
+	if err := os.Rename(swap, backup); err != nil {
+		return "", fmt.Errorf("failed to keep replaced binary: %w", err)
+	}
+
+	return restored, nil
+}
+
+// copyFile copies src to dst with the executable bit set
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,123 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installFixture points executablePath at a fresh "old binary" and returns
+// its path
+func installFixture(t *testing.T) string {
+	t.Helper()
+	exePath := filepath.Join(t.TempDir(), "wt-bin")
+	if err := os.WriteFile(exePath, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	oldExecutable := executablePath
+	executablePath = func() (string, error) { return exePath, nil }
+	t.Cleanup(func() { executablePath = oldExecutable })
+	return exePath
+}
+
+func writeArchive(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(path, createTestArchive(t, "wt-bin", []byte(content)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), content, want)
+	}
+}
+
+func TestInstallKeepsBackup(t *testing.T) {
+	exePath := installFixture(t)
+
+	if err := extractAndInstall(writeArchive(t, newScriptBinary)); err != nil {
+		t.Fatalf("extractAndInstall() error = %v", err)
+	}
+
+	assertFileContent(t, exePath, newScriptBinary)
+	assertFileContent(t, exePath+backupSuffix, "old binary")
+}
+
+func TestInstallRejectsBrokenBinary(t *testing.T) {
+	exePath := installFixture(t)
+
+	err := extractAndInstall(writeArchive(t, "#!/bin/sh\necho 'segfault' >&2\nexit 3\n"))
+	if err == nil || !strings.Contains(err.Error(), "smoke test") || !strings.Contains(err.Error(), "segfault") {
+		t.Fatalf("Expected smoke test failure with the binary's output, got %v", err)
+	}
+
+	assertFileContent(t, exePath, "old binary")
+	if _, err := os.Stat(exePath + backupSuffix); !os.IsNotExist(err) {
+		t.Error("No backup should be made when the new binary is rejected")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exePath))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the executable to remain, got %d files", len(entries))
+	}
+}
+
+func TestRollback(t *testing.T) {
+	exePath := installFixture(t)
+
+	oldSmokeTest := smokeTest
+	smokeTest = func(path string) (string, error) {
+		content, err := os.ReadFile(path)
+		return "wt version " + string(content), err
+	}
+	defer func() { smokeTest = oldSmokeTest }()
+
+	if _, err := Rollback(); err == nil || !strings.Contains(err.Error(), "no previous version") {
+		t.Fatalf("Expected missing backup error, got %v", err)
+	}
+
+	if err := os.WriteFile(exePath+backupSuffix, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exePath, []byte("v2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Rollback()
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if restored != "wt version v1" {
+		t.Errorf("Rollback() = %q, want the restored binary's version", restored)
+	}
+	assertFileContent(t, exePath, "v1")
+	assertFileContent(t, exePath+backupSuffix, "v2")
+
+	// Rolling back again undoes the rollback
+	if _, err := Rollback(); err != nil {
+		t.Fatalf("second Rollback() error = %v", err)
+	}
+	assertFileContent(t, exePath, "v2")
+	assertFileContent(t, exePath+backupSuffix, "v1")
+}
+
+func TestRollbackRejectsBrokenBackup(t *testing.T) {
+	exePath := installFixture(t)
+	if err := os.WriteFile(exePath+backupSuffix, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rollback(); err == nil || !strings.Contains(err.Error(), "smoke test") {
+		t.Fatalf("Expected smoke test failure, got %v", err)
+	}
+	assertFileContent(t, exePath, "old binary")
+}
@@ -0,0 +1,359 @@
+package update
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Release source types for Options.Source
+const (
+	SourceGitHub   = "github"
+	SourceGitea    = "gitea"
+	SourceGitLab   = "gitlab"
+	SourceManifest = "manifest"
+)
+
+// defaultRepo is where wt's own releases are published
+const defaultRepo = "tobiase/worktree-utils"
+
+// Source is where releases are looked up
+type Source interface {
+	// Latest returns the newest stable release
+	Latest() (*Release, error)
+	// Releases returns published releases, pre-releases included
+	Releases() ([]Release, error)
+	// Release returns the release with the given tag, or nil if there is none
+	Release(tag string) (*Release, error)
+}
+
+// Options configures where updates come from and how they are fetched
+type Options struct {
+	// Source is github (default), gitea, gitlab or manifest
+	Source string
+	// URL is the API base URL for github, gitea and gitlab sources, or the
+	// manifest URL. GitHub defaults to https://api.github.com.
+	URL string
+	// Repo is owner/name for github and gitea, or the project path or ID
+	// for gitlab. Defaults to wt's own repository.
+	Repo string
+	// Proxy is an HTTP proxy URL; without one the usual proxy environment
+	// variables apply
+	Proxy string
+	// CABundle is a PEM file of extra certificate authorities to trust
+	CABundle string
+}
+
+// source is the configured release source; nil means GitHub at githubAPIURL
+var source Source
+
+// releaseSource returns the source to look up releases in
+func releaseSource() Source {
+	if source != nil {
+		return source
+	}
+	return githubSource{server: "GitHub API", latestURL: githubAPIURL}
+}
+
+// Configure sets the release source and HTTP settings from opts. The zero
+// Options keep the defaults.
+func Configure(opts Options) error {
+	src, err := newSource(opts)
+	if err != nil {
+		return err
+	}
+	client, err := newHTTPClient(opts.Proxy, opts.CABundle)
+	if err != nil {
+		return err
+	}
+	source = src
+	httpClient = client
+	return nil
+}
+
+// newSource creates the release source described by opts, or nil for the
+// default GitHub repository
+func newSource(opts Options) (Source, error) {
+	repo := opts.Repo
+	if repo == "" {
+		repo = defaultRepo
+	}
+	base := strings.TrimSuffix(opts.URL, "/")
+
+	switch opts.Source {
+	case "", SourceGitHub:
+		if base == "" && opts.Repo == "" {
+			return nil, nil
+		}
+		if base == "" {
+			base = "https://api.github.com"
+		}
+		return githubSource{server: "GitHub API", latestURL: base + "/repos/" + repo + "/releases/latest"}, nil
+	case SourceGitea:
+		// Gitea serves GitHub-shaped release JSON under /api/v1
+		if base == "" {
+			return nil, fmt.Errorf("the gitea update source needs a url")
+		}
+		return githubSource{server: "Gitea API", latestURL: base + "/repos/" + repo + "/releases/latest"}, nil
+	case SourceGitLab:
+		if base == "" {
+			return nil, fmt.Errorf("the gitlab update source needs a url")
+		}
+		return gitlabSource{projectURL: base + "/projects/" + url.PathEscape(repo)}, nil
+	case SourceManifest:
+		if base == "" {
+			return nil, fmt.Errorf("the manifest update source needs a url")
+		}
+		return manifestSource{url: opts.URL}, nil
+	}
+	return nil, fmt.Errorf("unknown update source %q (use %s, %s, %s or %s)",
+		opts.Source, SourceGitHub, SourceGitea, SourceGitLab, SourceManifest)
+}
+
+// newHTTPClient creates the client for release lookups, going through proxy
+// and trusting the certificates in caBundle on top of the system ones
+func newHTTPClient(proxy, caBundle string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid update proxy %q", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caBundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	}, nil
+}
+
+// githubSource reads the GitHub releases API, or a server that mirrors it
+// such as Gitea or GitHub Enterprise
+type githubSource struct {
+	server    string
+	latestURL string
+}
+
+func (s githubSource) Latest() (*Release, error) {
+	var release Release
+	found, err := getJSON(s.server, s.latestURL, &release)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s returned status %d", s.server, http.StatusNotFound)
+	}
+	return &release, nil
+}
+
+// maxReleasePages caps how many pages of releases are read, in case a server
+// keeps pointing at a next page
+const maxReleasePages = 20
+
+// Releases reads every page of releases, following the Link header's next
+// URL as GitHub and Gitea send it
+func (s githubSource) Releases() ([]Release, error) {
+	var releases []Release
+	next := s.releasesURL() + "?per_page=100"
+	for page := 0; next != "" && page < maxReleasePages; page++ {
+		var pageReleases []Release
+		found, header, err := getJSONPage(s.server, next, &pageReleases)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("%s returned status %d", s.server, http.StatusNotFound)
+		}
+		releases = append(releases, pageReleases...)
+		next = nextLink(header.Get("Link"))
+	}
+	return releases, nil
+}
+
+func (s githubSource) Release(tag string) (*Release, error) {
+	var release Release
+	found, err := getJSON(s.server, s.releasesURL()+"/tags/"+url.PathEscape(tag), &release)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (s githubSource) releasesURL() string {
+	return strings.TrimSuffix(s.latestURL, "/latest")
+}
+
+// nextLink returns the URL marked rel="next" in a Link header such as
+// `<https://api.github.com/...&page=2>; rel="next", <...>; rel="last"`, or ""
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// gitlabSource reads the GitLab releases API of one project
+type gitlabSource struct {
+	projectURL string
+}
+
+// gitlabRelease is a release as the GitLab API returns it
+type gitlabRelease struct {
+	TagName         string `json:"tag_name"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	ReleasedAt      string `json:"released_at"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Assets          struct {
+		Links []struct {
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+func (r gitlabRelease) release() Release {
+	release := Release{
+		TagName:     r.TagName,
+		Name:        r.Name,
+		Body:        r.Description,
+		Draft:       r.UpcomingRelease,
+		PublishedAt: r.ReleasedAt,
+	}
+	if v, err := ParseVersion(r.TagName); err == nil {
+		release.Prerelease = v.IsPrerelease()
+	}
+	for _, link := range r.Assets.Links {
+		downloadURL := link.DirectAssetURL
+		if downloadURL == "" {
+			downloadURL = link.URL
+		}
+		release.Assets = append(release.Assets, Asset{Name: link.Name, BrowserDownloadURL: downloadURL})
+	}
+	return release
+}
+
+func (s gitlabSource) Latest() (*Release, error) {
+	releases, err := s.Releases()
+	if err != nil {
+		return nil, err
+	}
+	return newestRelease(releases, false)
+}
+
+// Releases reads every page of releases, following the X-Next-Page header
+func (s gitlabSource) Releases() ([]Release, error) {
+	var releases []Release
+	next := "1"
+	for page := 0; next != "" && page < maxReleasePages; page++ {
+		var gitlabReleases []gitlabRelease
+		found, header, err := getJSONPage("GitLab API", s.projectURL+"/releases?per_page=100&page="+url.QueryEscape(next), &gitlabReleases)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("GitLab API returned status %d", http.StatusNotFound)
+		}
+		for _, r := range gitlabReleases {
+			releases = append(releases, r.release())
+		}
+		next = header.Get("X-Next-Page")
+	}
+	return releases, nil
+}
+
+func (s gitlabSource) Release(tag string) (*Release, error) {
+	var r gitlabRelease
+	found, err := getJSON("GitLab API", s.projectURL+"/releases/"+url.PathEscape(tag), &r)
+	if err != nil || !found {
+		return nil, err
+	}
+	release := r.release()
+	return &release, nil
+}
+
+// manifestSource reads a static JSON file listing releases in the GitHub
+// format, so a mirror can be a plain web server. Relative asset URLs are
+// resolved against the manifest URL.
+type manifestSource struct {
+	url string
+}
+
+func (s manifestSource) Latest() (*Release, error) {
+	releases, err := s.Releases()
+	if err != nil {
+		return nil, err
+	}
+	return newestRelease(releases, false)
+}
+
+func (s manifestSource) Releases() ([]Release, error) {
+	var releases []Release
+	found, err := getJSON("release manifest server", s.url, &releases)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("release manifest %s not found", s.url)
+	}
+
+	base, err := url.Parse(s.url)
+	if err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		for j := range releases[i].Assets {
+			asset := &releases[i].Assets[j]
+			ref, err := url.Parse(asset.BrowserDownloadURL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid asset URL %q in release manifest", asset.BrowserDownloadURL)
+			}
+			asset.BrowserDownloadURL = base.ResolveReference(ref).String()
+		}
+	}
+	return releases, nil
+}
+
+func (s manifestSource) Release(tag string) (*Release, error) {
+	releases, err := s.Releases()
+	if err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		if releases[i].TagName == tag {
+			return &releases[i], nil
+		}
+	}
+	return nil, nil
+}
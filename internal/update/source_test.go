@@ -0,0 +1,279 @@
+package update
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configureForTest applies opts and restores the defaults when t ends
+func configureForTest(t *testing.T, opts Options) {
+	t.Helper()
+	oldSource, oldClient := source, httpClient
+	t.Cleanup(func() { source, httpClient = oldSource, oldClient })
+	if err := Configure(opts); err != nil {
+		t.Fatalf("Configure(%+v) error = %v", opts, err)
+	}
+}
+
+func TestConfigureRejectsBadOptions(t *testing.T) {
+	oldSource, oldClient := source, httpClient
+	defer func() { source, httpClient = oldSource, oldClient }()
+
+	badBundle := filepath.Join(t.TempDir(), "bad.pem")
+	if err := os.WriteFile(badBundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"unknown source", Options{Source: "svn"}, "unknown update source"},
+		{"gitea without url", Options{Source: SourceGitea}, "needs a url"},
+		{"gitlab without url", Options{Source: SourceGitLab}, "needs a url"},
+		{"manifest without url", Options{Source: SourceManifest}, "needs a url"},
+		{"bad proxy", Options{Proxy: "::"}, "invalid update proxy"},
+		{"missing CA bundle", Options{CABundle: filepath.Join(t.TempDir(), "none.pem")}, "failed to read CA bundle"},
+		{"empty CA bundle", Options{CABundle: badBundle}, "no certificates found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Configure(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Configure(%+v) = %v, want error containing %q", tt.opts, err, tt.want)
+			}
+		})
+	}
+
+	if err := Configure(Options{}); err != nil || source != nil {
+		t.Errorf("Configure(Options{}) should keep the GitHub default, got %v, %v", source, err)
+	}
+}
+
+func TestGitHubCompatibleSources(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v3/repos/corp/wt/releases/latest", "/api/v1/repos/corp/wt/releases/latest":
+			_ = json.NewEncoder(w).Encode(Release{TagName: "v1.2.0"})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	for _, opts := range []Options{
+		{URL: server.URL + "/api/v3/", Repo: "corp/wt"},
+		{Source: SourceGitea, URL: server.URL + "/api/v1", Repo: "corp/wt"},
+	} {
+		configureForTest(t, opts)
+		release, hasUpdate, err := CheckForUpdate("v1.0.0")
+		if err != nil || release.TagName != "v1.2.0" || !hasUpdate {
+			t.Errorf("CheckForUpdate() with %+v = %v, %v, %v", opts, release, hasUpdate, err)
+		}
+	}
+	if len(requested) != 2 {
+		t.Errorf("Expected one request per source, got %q", requested)
+	}
+}
+
+func TestGitLabSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The project path is escaped into a single path segment
+		if !strings.HasPrefix(r.URL.EscapedPath(), "/api/v4/projects/tools%2Fwt/releases") {
+			w.WriteHeader(404)
+			return
+		}
+		releases := `[
+			{"tag_name": "v1.3.0-rc.1", "description": "rc",
+			 "assets": {"links": [{"name": "checksums.txt", "url": "https://gitlab.corp/rc/checksums.txt"}]}},
+			{"tag_name": "v1.2.0", "description": "stable",
+			 "assets": {"links": [{"name": "checksums.txt", "url": "https://gitlab.corp/other", "direct_asset_url": "https://gitlab.corp/v1.2.0/checksums.txt"}]}},
+			{"tag_name": "v9.0.0", "upcoming_release": true}
+		]`
+		if strings.HasSuffix(r.URL.Path, "/releases/v1.2.0") {
+			releases = `{"tag_name": "v1.2.0", "description": "stable"}`
+		} else if !strings.HasSuffix(r.URL.Path, "/releases") {
+			w.WriteHeader(404)
+			return
+		}
+		_, _ = w.Write([]byte(releases))
+	}))
+	defer server.Close()
+
+	configureForTest(t, Options{Source: SourceGitLab, URL: server.URL + "/api/v4", Repo: "tools/wt"})
+
+	release, _, err := CheckChannel("v1.0.0", ChannelStable)
+	if err != nil {
+		t.Fatalf("CheckChannel(stable) error = %v", err)
+	}
+	if release.TagName != "v1.2.0" || release.Body != "stable" {
+		t.Errorf("stable release = %+v, want v1.2.0", release)
+	}
+	if len(release.Assets) != 1 || release.Assets[0].BrowserDownloadURL != "https://gitlab.corp/v1.2.0/checksums.txt" {
+		t.Errorf("Expected the direct asset URL, got %+v", release.Assets)
+	}
+
+	release, _, err = CheckChannel("v1.0.0", ChannelBeta)
+	if err != nil || release.TagName != "v1.3.0-rc.1" {
+		t.Errorf("CheckChannel(beta) = %v, %v; want v1.3.0-rc.1", release, err)
+	}
+
+	if release, err := GetRelease("1.2.0"); err != nil || release.TagName != "v1.2.0" {
+		t.Errorf("GetRelease(1.2.0) = %v, %v", release, err)
+	}
+}
+
+func TestReleasesFollowPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v3/"):
+			// GitHub links the next page until the last one
+			if page == "" {
+				w.Header().Set("Link", `<http://`+r.Host+`/api/v3/repos/corp/wt/releases?per_page=100&page=2>; rel="next", <http://`+r.Host+`/api/v3/repos/corp/wt/releases?per_page=100&page=2>; rel="last"`)
+				_, _ = w.Write([]byte(`[{"tag_name": "v1.2.0"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"tag_name": "v1.1.0"}]`))
+		case strings.HasPrefix(r.URL.Path, "/api/v4/"):
+			// GitLab sends an empty X-Next-Page on the last page
+			if page == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				_, _ = w.Write([]byte(`[{"tag_name": "v1.2.0"}]`))
+				return
+			}
+			w.Header().Set("X-Next-Page", "")
+			_, _ = w.Write([]byte(`[{"tag_name": "v1.1.0"}]`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	for _, opts := range []Options{
+		{URL: server.URL + "/api/v3", Repo: "corp/wt"},
+		{Source: SourceGitLab, URL: server.URL + "/api/v4", Repo: "corp/wt"},
+	} {
+		configureForTest(t, opts)
+		releases, err := releaseSource().Releases()
+		if err != nil {
+			t.Fatalf("Releases() with %+v error = %v", opts, err)
+		}
+		var tags []string
+		for _, release := range releases {
+			tags = append(tags, release.TagName)
+		}
+		if got := strings.Join(tags, ", "); got != "v1.2.0, v1.1.0" {
+			t.Errorf("Releases() with %+v = %s, want both pages", opts, got)
+		}
+	}
+}
+
+func TestManifestSource(t *testing.T) {
+	newBinary := "#!/bin/sh\necho 'mirrored binary'\n"
+	archive := createTestArchive(t, "wt-bin", []byte(newBinary))
+	assetName := "wt_1.2.0_" + getAssetName()[3:] + ".tar.gz"
+	checksums := checksumsAsset(t, assetName, archive)
+
+	var manifest string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mirror/wt/releases.json":
+			_, _ = w.Write([]byte(manifest))
+		case "/mirror/wt/v1.2.0/" + assetName:
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	data, err := json.Marshal([]Release{
+		{TagName: "v1.1.0"},
+		{
+			TagName: "v1.2.0",
+			Assets: []Asset{
+				{Name: assetName, BrowserDownloadURL: "v1.2.0/" + assetName},
+				checksums,
+			},
+		},
+		{TagName: "v1.3.0-beta.1", Prerelease: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest = string(data)
+
+	configureForTest(t, Options{Source: SourceManifest, URL: server.URL + "/mirror/wt/releases.json"})
+	exePath := installFixture(t)
+
+	release, hasUpdate, err := CheckForUpdate("v1.1.0")
+	if err != nil || release.TagName != "v1.2.0" || !hasUpdate {
+		t.Fatalf("CheckForUpdate() = %v, %v, %v", release, hasUpdate, err)
+	}
+	if want := server.URL + "/mirror/wt/v1.2.0/" + assetName; release.Assets[0].BrowserDownloadURL != want {
+		t.Errorf("relative asset URL resolved to %q, want %q", release.Assets[0].BrowserDownloadURL, want)
+	}
+
+	if err := DownloadAndInstall(release, func(downloaded, total int64) {}); err != nil {
+		t.Fatalf("DownloadAndInstall() from mirror error = %v", err)
+	}
+	assertFileContent(t, exePath, newBinary)
+
+	if release, err := GetRelease("v1.3.0-beta.1"); err != nil || release.TagName != "v1.3.0-beta.1" {
+		t.Errorf("GetRelease(v1.3.0-beta.1) = %v, %v", release, err)
+	}
+	if _, err := GetRelease("v2.0.0"); err == nil {
+		t.Error("Expected a missing release to be reported")
+	}
+}
+
+func TestHTTPClientProxyAndCABundle(t *testing.T) {
+	// A TLS release server only trusted through the CA bundle
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Release{TagName: "v1.2.0"})
+	}))
+	defer tlsServer.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configureForTest(t, Options{URL: tlsServer.URL, CABundle: bundle})
+	if release, _, err := CheckForUpdate("v1.0.0"); err != nil || release.TagName != "v1.2.0" {
+		t.Errorf("CheckForUpdate() through CA bundle = %v, %v", release, err)
+	}
+
+	configureForTest(t, Options{URL: tlsServer.URL})
+	if _, _, err := CheckForUpdate("v1.0.0"); err == nil {
+		t.Error("Expected an untrusted certificate to be rejected without the CA bundle")
+	}
+
+	// Plain HTTP requests go through the proxy with the absolute URL
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_ = json.NewEncoder(w).Encode(Release{TagName: "v1.2.0"})
+	}))
+	defer proxy.Close()
+
+	configureForTest(t, Options{URL: "http://releases.invalid", Proxy: proxy.URL})
+	if _, _, err := CheckForUpdate("v1.0.0"); err != nil {
+		t.Fatalf("CheckForUpdate() through proxy error = %v", err)
+	}
+	if want := "http://releases.invalid/repos/tobiase/worktree-utils/releases/latest"; proxied != want {
+		t.Errorf("proxy saw %q, want %q", proxied, want)
+	}
+}
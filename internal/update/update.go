@@ -18,6 +18,12 @@ import (
 
 const userAgent = "wt-updater"
 
+// Release channels for CheckChannel
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
 var (
 	// githubAPIURL can be overridden for testing
 	githubAPIURL = "https://api.github.com/repos/tobiase/worktree-utils/releases/latest"
@@ -32,6 +38,8 @@ type Release struct {
 	TagName     string  `json:"tag_name"`
 	Name        string  `json:"name"`
 	Body        string  `json:"body"`
+	Draft       bool    `json:"draft"`
+	Prerelease  bool    `json:"prerelease"`
 	PublishedAt string  `json:"published_at"`
 	Assets      []Asset `json:"assets"`
 }
@@ -59,30 +67,102 @@ func (pw *ProgressWriter) Write(p []byte) (int, error) {
 	return n, nil
 }
 
-// CheckForUpdate checks if a new version is available
+// CheckForUpdate checks if a newer stable release is available
 func CheckForUpdate(currentVersion string) (*Release, bool, error) {
-	release, err := getLatestRelease()
+	return CheckChannel(currentVersion, ChannelStable)
+}
+
+// CheckChannel returns the newest release on channel and whether it is newer
+// than currentVersion. The stable channel skips pre-releases; beta considers
+// every published release.
+func CheckChannel(currentVersion, channel string) (*Release, bool, error) {
+	var release *Release
+	var err error
+	switch channel {
+	case ChannelStable, "":
+		release, err = releaseSource().Latest()
+	case ChannelBeta:
+		var releases []Release
+		releases, err = releaseSource().Releases()
+		if err == nil {
+			release, err = newestRelease(releases, true)
+		}
+	default:
+		return nil, false, fmt.Errorf("unknown channel %q (use %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
 	if err != nil {
 		return nil, false, err
 	}
 
-	// Clean version strings for comparison
-	current := strings.TrimPrefix(currentVersion, "v")
-	latest := strings.TrimPrefix(release.TagName, "v")
+	return release, isNewer(release.TagName, currentVersion), nil
+}
+
+// GetRelease returns the release tagged version, with or without a "v" prefix
+func GetRelease(version string) (*Release, error) {
+	if _, err := ParseVersion(version); err != nil {
+		return nil, err
+	}
+
+	tags := []string{version}
+	if strings.HasPrefix(version, "v") {
+		tags = append(tags, strings.TrimPrefix(version, "v"))
+	} else {
+		tags = append([]string{"v" + version}, tags...)
+	}
+
+	for _, tag := range tags {
+		release, err := releaseSource().Release(tag)
+		if err != nil {
+			return nil, err
+		}
+		if release != nil {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("release %s not found", version)
+}
 
-	// Simple version comparison (could be improved)
-	hasUpdate := latest != current && current != "dev"
+// newestRelease returns the highest versioned published release, leaving
+// out pre-releases unless includePrerelease is set
+func newestRelease(releases []Release, includePrerelease bool) (*Release, error) {
+	var newest *Release
+	var newestVersion Version
+	for i := range releases {
+		if releases[i].Draft {
+			continue
+		}
+		v, err := ParseVersion(releases[i].TagName)
+		if err != nil {
+			continue
+		}
+		if !includePrerelease && (releases[i].Prerelease || v.IsPrerelease()) {
+			continue
+		}
+		if newest == nil || v.Compare(newestVersion) > 0 {
+			newest, newestVersion = &releases[i], v
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no published releases found")
+	}
+	return newest, nil
+}
 
-	return release, hasUpdate, nil
+// getJSON decodes the response at url into v, naming server in errors. It
+// reports false without an error when the server answers 404.
+func getJSON(server, url string, v interface{}) (bool, error) {
+	found, _, err := getJSONPage(server, url, v)
+	return found, err
 }
 
-// getLatestRelease fetches the latest release information from GitHub
-func getLatestRelease() (*Release, error) {
+// getJSONPage is getJSON that also returns the response headers, which
+// paginated APIs use to point at the next page
+func getJSONPage(server, url string, v interface{}) (bool, http.Header, error) {
 	client := httpClient
 
-	req, err := http.NewRequest("GET", githubAPIURL, nil)
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return false, nil, err
 	}
 
 	req.Header.Set("User-Agent", userAgent)
@@ -90,23 +170,24 @@ func getLatestRelease() (*Release, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch release info: %w", err)
+		return false, nil, fmt.Errorf("failed to fetch release info: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		return false, nil, fmt.Errorf("%s returned status %d", server, resp.StatusCode)
 	}
 
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, nil, fmt.Errorf("failed to parse release info: %w", err)
 	}
 
-	return &release, nil
+	return true, resp.Header, nil
 }
 
-// DownloadAndInstall downloads and installs the update
 func DownloadAndInstall(release *Release, onProgress func(downloaded, total int64)) error {
 	// Find the appropriate asset for this platform
 	assetName := getAssetName()
@@ -125,6 +206,13 @@ func DownloadAndInstall(release *Release, onProgress func(downloaded, total int6
 		return fmt.Errorf("no release found for %s", assetName)
 	}
 
+	// Fetch the checksums first so nothing is downloaded for a release we
+	// could not verify anyway
+	checksums, err := fetchChecksums(release)
+	if err != nil {
+		return fmt.Errorf("failed to verify update: %w", err)
+	}
+
 	// Create temporary file for download
 	tmpFile, err := os.CreateTemp("", "wt-update-*.tar.gz")
 	if err != nil {
@@ -133,11 +221,17 @@ func DownloadAndInstall(release *Release, onProgress func(downloaded, total int6
 	defer os.Remove(tmpFile.Name())
 	defer tmpFile.Close()
 
-	// Download the asset
-	if err := downloadFile(tmpFile, asset.BrowserDownloadURL, asset.Size, onProgress); err != nil {
+	// Download the asset, hashing it on the way
+	hasher := sha256.New()
+	if err := downloadFile(io.MultiWriter(tmpFile, hasher), asset.BrowserDownloadURL, asset.Size, onProgress); err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
 
+	// Refuse to install anything that does not match the published checksum
+	if err := verifyChecksum(checksums, asset.Name, hex.EncodeToString(hasher.Sum(nil))); err != nil {
+		return fmt.Errorf("failed to verify update: %w", err)
+	}
+
 	// Extract and install
 	if err := extractAndInstall(tmpFile.Name()); err != nil {
 		return fmt.Errorf("failed to install update: %w", err)
@@ -176,8 +270,11 @@ func getAssetName() string {
 
 // downloadFile downloads a file with progress reporting
 func downloadFile(dst io.Writer, url string, size int64, onProgress func(downloaded, total int64)) error {
+	// Same transport (proxy, CA bundle) as API requests, with room for a
+	// large archive on a slow connection
 	client := &http.Client{
-		Timeout: 5 * time.Minute,
+		Timeout:   5 * time.Minute,
+		Transport: httpClient.Transport,
 	}
 
 	resp, err := client.Get(url)
@@ -190,6 +287,11 @@ func downloadFile(dst io.Writer, url string, size int64, onProgress func(downloa
 		return fmt.Errorf("download failed with status %d", resp.StatusCode)
 	}
 
+	// Some release sources do not list asset sizes
+	if size <= 0 {
+		size = resp.ContentLength
+	}
+
 	pw := &ProgressWriter{
 		Total:      size,
 		OnProgress: onProgress,
@@ -279,6 +381,17 @@ func installBinaryFromTar(tr *tar.Reader, exePath string) error {
 		return fmt.Errorf("failed to make binary executable: %w", err)
 	}
 
+	// Never replace a working binary with one that cannot even start
+	if _, err := smokeTest(tmpPath); err != nil {
+		return fmt.Errorf("new binary failed its smoke test: %w", err)
+	}
+
+	if err := backupBinary(exePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to back up current binary: %w", err)
+	}
+
+	// Rename is atomic, so the executable is always either the old binary
+	// or the complete new one
 	if err := os.Rename(tmpPath, exePath); err != nil {
 		return fmt.Errorf("failed to replace binary: %w", err)
 	}
@@ -242,6 +242,7 @@ func TestDownloadAndInstallCorruptedArchive(t *testing.T) {
 				BrowserDownloadURL: server.URL + "/download/corrupted.tar.gz",
 				Size:               1024,
 			},
+			checksumsAsset(t, assetName, []byte("this is not a valid tar.gz file at all")),
 		},
 	}
 
@@ -305,6 +306,7 @@ func TestDownloadAndInstallInconsistentSize(t *testing.T) {
 				BrowserDownloadURL: server.URL + "/download/test.tar.gz",
 				Size:               999999, // Much larger than actual content
 			},
+			checksumsAsset(t, assetName, []byte("small")),
 		},
 	}
 
@@ -375,6 +377,7 @@ func TestDownloadAndInstallReadOnlyExecutable(t *testing.T) {
 				BrowserDownloadURL: server.URL + "/test.tar.gz",
 				Size:               int64(len(archive)),
 			},
+			checksumsAsset(t, assetName, archive),
 		},
 	}
 
@@ -430,6 +433,7 @@ func TestDownloadAndInstallExecutableNotFound(t *testing.T) {
 				BrowserDownloadURL: server.URL + "/test.tar.gz",
 				Size:               int64(len(archive)),
 			},
+			checksumsAsset(t, assetName, archive),
 		},
 	}
 
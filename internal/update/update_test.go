@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,7 +16,8 @@ import (
 	"time"
 )
 
-// Create a mock GitHub release response
+// newScriptBinary is a stand-in binary that passes the smoke test
+const newScriptBinary = "#!/bin/sh\necho 'new binary'\n"
 
 // Create a test tar.gz archive with a binary
 func createTestArchive(t *testing.T, binaryName string, content []byte) []byte {
@@ -115,6 +117,11 @@ func TestCheckForUpdate(t *testing.T) {
 			currentVersion: "1.0.0",
 			wantUpdate:     true,
 		},
+		{
+			name:           "newer than latest",
+			currentVersion: "v1.10.0",
+			wantUpdate:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,6 +151,101 @@ func TestCheckForUpdate(t *testing.T) {
 	}
 }
 
+// releasesServer serves a releases list and per-tag lookups like the GitHub API
+func releasesServer(t *testing.T, releases []Release) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/repos/tobiase/worktree-utils/releases"
+		switch {
+		case r.URL.Path == prefix:
+			_ = json.NewEncoder(w).Encode(releases)
+		case strings.HasPrefix(r.URL.Path, prefix+"/tags/"):
+			tag := strings.TrimPrefix(r.URL.Path, prefix+"/tags/")
+			for _, release := range releases {
+				if release.TagName == tag {
+					_ = json.NewEncoder(w).Encode(release)
+					return
+				}
+			}
+			w.WriteHeader(404)
+		case r.URL.Path == prefix+"/latest":
+			// GitHub's latest release skips drafts and pre-releases
+			_ = json.NewEncoder(w).Encode(releases[len(releases)-1])
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	oldURL := githubAPIURL
+	githubAPIURL = server.URL + "/repos/tobiase/worktree-utils/releases/latest"
+	t.Cleanup(func() { githubAPIURL = oldURL })
+}
+
+func TestCheckChannel(t *testing.T) {
+	releasesServer(t, []Release{
+		{TagName: "v2.0.0", Draft: true},
+		{TagName: "v1.10.0-beta.2", Prerelease: true},
+		{TagName: "v1.10.0-beta.10", Prerelease: true},
+		{TagName: "not-a-version"},
+		{TagName: "v1.9.0"},
+	})
+
+	tests := []struct {
+		channel    string
+		current    string
+		wantTag    string
+		wantUpdate bool
+		wantError  bool
+	}{
+		{channel: ChannelStable, current: "v1.8.0", wantTag: "v1.9.0", wantUpdate: true},
+		{channel: ChannelBeta, current: "v1.9.0", wantTag: "v1.10.0-beta.10", wantUpdate: true},
+		{channel: ChannelBeta, current: "v1.10.0", wantTag: "v1.10.0-beta.10", wantUpdate: false},
+		{channel: "nightly", current: "v1.9.0", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel+" from "+tt.current, func(t *testing.T) {
+			release, hasUpdate, err := CheckChannel(tt.current, tt.channel)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if release.TagName != tt.wantTag || hasUpdate != tt.wantUpdate {
+				t.Errorf("CheckChannel(%q, %q) = %s, %v; want %s, %v",
+					tt.current, tt.channel, release.TagName, hasUpdate, tt.wantTag, tt.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestGetRelease(t *testing.T) {
+	releasesServer(t, []Release{{TagName: "v1.4.2"}, {TagName: "1.5.0"}})
+
+	for _, version := range []string{"v1.4.2", "1.4.2", "1.5.0", "v1.5.0"} {
+		release, err := GetRelease(version)
+		if err != nil {
+			t.Errorf("GetRelease(%q) error = %v", version, err)
+			continue
+		}
+		if strings.TrimPrefix(release.TagName, "v") != strings.TrimPrefix(version, "v") {
+			t.Errorf("GetRelease(%q) = %s", version, release.TagName)
+		}
+	}
+
+	if _, err := GetRelease("v9.9.9"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if _, err := GetRelease("latest"); err == nil {
+		t.Error("Expected an invalid version to be rejected")
+	}
+}
+
 func TestCheckForUpdateErrors(t *testing.T) {
 	// Save original values
 	oldURL := githubAPIURL
@@ -274,6 +376,25 @@ func TestGetAssetName(t *testing.T) {
 	}
 }
 
+// checksumsAsset serves a checksums.txt listing data under name, as a release
+// asset for DownloadAndInstall to verify against
+func checksumsAsset(t *testing.T, name string, data []byte) Asset {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	checksums := fmt.Sprintf("%x  %s\n", sum, name)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(checksums))
+	}))
+	t.Cleanup(server.Close)
+
+	return Asset{
+		Name:               "checksums.txt",
+		BrowserDownloadURL: server.URL + "/checksums.txt",
+		Size:               int64(len(checksums)),
+	}
+}
+
 func TestDownloadAndInstall(t *testing.T) {
 	// Create test archives for both binary names
 	testBinaryContent := []byte("#!/bin/sh\necho 'test binary v2.0.0'")
@@ -312,6 +433,7 @@ func TestDownloadAndInstall(t *testing.T) {
 						BrowserDownloadURL: server.URL + "/download/test-wt-bin.tar.gz",
 						Size:               int64(len(archiveWtBin)),
 					},
+					checksumsAsset(t, fmt.Sprintf("%s.tar.gz", assetName), archiveWtBin),
 				},
 			},
 			wantError: false,
@@ -326,6 +448,7 @@ func TestDownloadAndInstall(t *testing.T) {
 						BrowserDownloadURL: server.URL + "/download/test-wt-bin.tar.gz",
 						Size:               int64(len(archiveWtBin)),
 					},
+					checksumsAsset(t, fmt.Sprintf("wt_2.0.0_%s.tar.gz", assetName[3:]), archiveWtBin),
 				},
 			},
 			wantError: false,
@@ -340,6 +463,7 @@ func TestDownloadAndInstall(t *testing.T) {
 						BrowserDownloadURL: server.URL + "/download/test-worktree-utils.tar.gz",
 						Size:               int64(len(archiveWorktreeUtils)),
 					},
+					checksumsAsset(t, fmt.Sprintf("%s.tar.gz", assetName), archiveWorktreeUtils),
 				},
 			},
 			wantError: false,
@@ -369,6 +493,7 @@ func TestDownloadAndInstall(t *testing.T) {
 						BrowserDownloadURL: server.URL + "/nonexistent.tar.gz",
 						Size:               1024,
 					},
+					checksumsAsset(t, fmt.Sprintf("%s.tar.gz", assetName), archiveWtBin),
 				},
 			},
 			wantError: true,
@@ -553,7 +678,7 @@ func TestExtractAndInstall(t *testing.T) {
 		{
 			name: "successful extraction",
 			archiveFunc: func(t *testing.T) string {
-				archive := createTestArchive(t, "wt-bin", []byte("new binary"))
+				archive := createTestArchive(t, "wt-bin", []byte(newScriptBinary))
 				tempFile := filepath.Join(t.TempDir(), "archive.tar.gz")
 				_ = os.WriteFile(tempFile, archive, 0644)
 				return tempFile
@@ -624,7 +749,7 @@ func TestExtractAndInstall(t *testing.T) {
 				if err != nil {
 					t.Fatal(err)
 				}
-				if string(content) != "new binary" {
+				if string(content) != newScriptBinary {
 					t.Error("Binary was not updated")
 				}
 			}
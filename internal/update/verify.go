@@ -0,0 +1,200 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// checksumsAssetName is the checksum file GoReleaser attaches to a release
+	checksumsAssetName = "checksums.txt"
+	// signatureSuffix names the minisign signature of the checksum file
+	signatureSuffix = ".minisig"
+	// maxChecksumsSize bounds the checksum and signature downloads
+	maxChecksumsSize = 1 << 20
+)
+
+// signingPublicKey is the minisign public key release checksums are signed
+// with, as the base64 line of a minisign .pub file. Release builds set it with
+// -ldflags "-X github.com/tobiase/worktree-utils/internal/update.signingPublicKey=...".
+// When it is empty signatures are not checked, but checksums always are.
+var signingPublicKey = ""
+
+// fetchChecksums downloads the release's checksum file and, when a signing
+// key is built in, checks its minisign signature before trusting it
+func fetchChecksums(release *Release) (map[string]string, error) {
+	asset := findChecksumsAsset(release)
+	if asset == nil {
+		return nil, fmt.Errorf("release has no %s; refusing to install an unverified update", checksumsAssetName)
+	}
+
+	data, err := fetchSmallAsset(asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+
+	if signingPublicKey != "" {
+		sigAsset := findAsset(release, asset.Name+signatureSuffix)
+		if sigAsset == nil {
+			return nil, fmt.Errorf("release has no %s%s; refusing to install an unsigned update", asset.Name, signatureSuffix)
+		}
+		signature, err := fetchSmallAsset(sigAsset.BrowserDownloadURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", sigAsset.Name, err)
+		}
+		if err := verifyMinisign(signingPublicKey, data, signature); err != nil {
+			return nil, fmt.Errorf("%s: %w", asset.Name, err)
+		}
+	}
+
+	return parseChecksums(data)
+}
+
+// fetchSmallAsset downloads an asset of at most maxChecksumsSize bytes
+func fetchSmallAsset(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumsSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxChecksumsSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxChecksumsSize)
+	}
+	return data, nil
+}
+
+// findChecksumsAsset returns the release's checksum file, which may carry a
+// prefix such as "wt_1.2.3_checksums.txt"
+func findChecksumsAsset(release *Release) *Asset {
+	for i := range release.Assets {
+		if strings.HasSuffix(release.Assets[i].Name, checksumsAssetName) {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// findAsset returns the release asset with the given name
+func findAsset(release *Release, name string) *Asset {
+	for i := range release.Assets {
+		if release.Assets[i].Name == name {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// parseChecksums reads "<sha256>  <file>" lines as written by sha256sum and
+// GoReleaser into a map from file name to lowercase hex digest
+func parseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum line %q", line)
+		}
+		sum := strings.ToLower(fields[0])
+		if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("malformed SHA-256 checksum %q", fields[0])
+		}
+		// sha256sum marks files hashed in binary mode with '*'
+		sums[strings.TrimPrefix(fields[1], "*")] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// verifyChecksum checks that sum is the checksum listed for name
+func verifyChecksum(checksums map[string]string, name, sum string) error {
+	expected, ok := checksums[name]
+	if !ok {
+		return fmt.Errorf("%s is not listed in %s", name, checksumsAssetName)
+	}
+	if !strings.EqualFold(expected, sum) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, expected, sum)
+	}
+	return nil
+}
+
+// verifyMinisign checks a minisign signature of message against publicKey.
+// Only pure Ed25519 signatures ("minisign -S -l") are supported; both the
+// signature and its trusted comment must verify.
+func verifyMinisign(publicKey string, message, signature []byte) error {
+	keyID, key, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("malformed minisign signature")
+	}
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		return fmt.Errorf("prehashed minisign signatures are not supported; sign with 'minisign -S -l'")
+	default:
+		return fmt.Errorf("unknown minisign signature algorithm %q", sig[:2])
+	}
+	if !bytes.Equal(sig[2:10], keyID) {
+		return fmt.Errorf("signature was made with a different key")
+	}
+	if !ed25519.Verify(key, message, sig[10:]) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed minisign trusted comment signature")
+	}
+	if !ed25519.Verify(key, append(sig[10:], trustedComment...), globalSig) {
+		return fmt.Errorf("invalid trusted comment signature")
+	}
+	return nil
+}
+
+// parseMinisignPublicKey decodes a minisign public key, given either as the
+// base64 line alone or as the whole .pub file
+func parseMinisignPublicKey(publicKey string) (keyID []byte, key ed25519.PublicKey, err error) {
+	lines := strings.Split(strings.TrimSpace(publicKey), "\n")
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != "Ed" {
+		return nil, nil, fmt.Errorf("malformed minisign public key")
+	}
+	return data[2:10], ed25519.PublicKey(data[10:]), nil
+}
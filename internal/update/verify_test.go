@@ -0,0 +1,267 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testSigner signs like minisign -S -l with a key generated for the test
+type testSigner struct {
+	keyID      []byte
+	privateKey ed25519.PrivateKey
+	publicKey  string
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("wt-test!")
+	return &testSigner{
+		keyID:      keyID,
+		privateKey: priv,
+		publicKey:  base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)),
+	}
+}
+
+func (s *testSigner) sign(message []byte) []byte {
+	sig := ed25519.Sign(s.privateKey, message)
+	trustedComment := "timestamp:1700000000\tfile:checksums.txt"
+	globalSig := ed25519.Sign(s.privateKey, append(append([]byte{}, sig...), trustedComment...))
+
+	var buf bytes.Buffer
+	buf.WriteString("untrusted comment: signature from minisign secret key\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), s.keyID...), sig...)) + "\n")
+	buf.WriteString("trusted comment: " + trustedComment + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(globalSig) + "\n")
+	return buf.Bytes()
+}
+
+func TestParseChecksums(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	data := []byte(sum + "  wt_1.0.0_Linux_x86_64.tar.gz\n" +
+		strings.ToUpper(sum) + " *wt_1.0.0_Darwin_arm64.tar.gz\n\n")
+
+	sums, err := parseChecksums(data)
+	if err != nil {
+		t.Fatalf("parseChecksums() error = %v", err)
+	}
+	for _, name := range []string{"wt_1.0.0_Linux_x86_64.tar.gz", "wt_1.0.0_Darwin_arm64.tar.gz"} {
+		if sums[name] != sum {
+			t.Errorf("sums[%q] = %q, want %q", name, sums[name], sum)
+		}
+	}
+
+	for _, bad := range []string{"not-hex  file", sum + "  two names", "abcd  short"} {
+		if _, err := parseChecksums([]byte(bad)); err == nil {
+			t.Errorf("parseChecksums(%q) expected an error", bad)
+		}
+	}
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	signer := newTestSigner(t)
+	message := []byte("checksums")
+	signature := signer.sign(message)
+
+	if err := verifyMinisign(signer.publicKey, message, signature); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+
+	t.Run("tampered message", func(t *testing.T) {
+		if err := verifyMinisign(signer.publicKey, []byte("checksumz"), signature); err == nil {
+			t.Error("Expected tampered message to be rejected")
+		}
+	})
+
+	t.Run("tampered trusted comment", func(t *testing.T) {
+		tampered := bytes.Replace(signature, []byte("timestamp:"), []byte("timestamp:1"), 1)
+		if err := verifyMinisign(signer.publicKey, message, tampered); err == nil {
+			t.Error("Expected tampered trusted comment to be rejected")
+		}
+	})
+
+	t.Run("different key", func(t *testing.T) {
+		other := newTestSigner(t)
+		if err := verifyMinisign(other.publicKey, message, signature); err == nil {
+			t.Error("Expected signature from another key to be rejected")
+		}
+	})
+
+	t.Run("prehashed signature", func(t *testing.T) {
+		lines := strings.Split(string(signature), "\n")
+		raw, _ := base64.StdEncoding.DecodeString(lines[1])
+		copy(raw, "ED")
+		lines[1] = base64.StdEncoding.EncodeToString(raw)
+		err := verifyMinisign(signer.publicKey, message, []byte(strings.Join(lines, "\n")))
+		if err == nil || !strings.Contains(err.Error(), "prehashed") {
+			t.Errorf("Expected prehashed signature error, got %v", err)
+		}
+	})
+}
+
+// verifiedReleaseServer serves an update archive with a checksum file and,
+// when signature is not nil, its minisign signature
+func verifiedReleaseServer(t *testing.T, archive, checksums, signature []byte) *Release {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/update.tar.gz":
+			_, _ = w.Write(archive)
+		case "/checksums.txt":
+			_, _ = w.Write(checksums)
+		case "/checksums.txt.minisig":
+			_, _ = w.Write(signature)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	release := &Release{
+		TagName: "v2.0.0",
+		Assets: []Asset{
+			{Name: getAssetName() + ".tar.gz", BrowserDownloadURL: server.URL + "/update.tar.gz", Size: int64(len(archive))},
+			{Name: "checksums.txt", BrowserDownloadURL: server.URL + "/checksums.txt", Size: int64(len(checksums))},
+		},
+	}
+	if signature != nil {
+		release.Assets = append(release.Assets, Asset{
+			Name:               "checksums.txt.minisig",
+			BrowserDownloadURL: server.URL + "/checksums.txt.minisig",
+			Size:               int64(len(signature)),
+		})
+	}
+	return release
+}
+
+func TestDownloadAndInstallVerification(t *testing.T) {
+	newBinary := []byte("#!/bin/sh\necho 'verified binary'")
+	archive := createTestArchive(t, "wt-bin", newBinary)
+	assetName := getAssetName() + ".tar.gz"
+	goodChecksums := []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(archive), assetName))
+	badChecksums := []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("something else")), assetName))
+
+	signer := newTestSigner(t)
+
+	tests := []struct {
+		name      string
+		publicKey string
+		release   func(t *testing.T) *Release
+		errorMsg  string
+	}{
+		{
+			name: "checksum matches",
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, goodChecksums, nil)
+			},
+		},
+		{
+			name: "checksum mismatch",
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, badChecksums, nil)
+			},
+			errorMsg: "checksum mismatch",
+		},
+		{
+			name: "asset not listed",
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, []byte(fmt.Sprintf("%x  other.tar.gz\n", sha256.Sum256(archive))), nil)
+			},
+			errorMsg: "not listed",
+		},
+		{
+			name: "no checksum file",
+			release: func(t *testing.T) *Release {
+				release := verifiedReleaseServer(t, archive, goodChecksums, nil)
+				release.Assets = release.Assets[:1]
+				return release
+			},
+			errorMsg: "refusing to install an unverified update",
+		},
+		{
+			name:      "valid signature",
+			publicKey: signer.publicKey,
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, goodChecksums, signer.sign(goodChecksums))
+			},
+		},
+		{
+			name:      "missing signature",
+			publicKey: signer.publicKey,
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, goodChecksums, nil)
+			},
+			errorMsg: "refusing to install an unsigned update",
+		},
+		{
+			name:      "signature over other checksums",
+			publicKey: signer.publicKey,
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, goodChecksums, signer.sign(badChecksums))
+			},
+			errorMsg: "invalid signature",
+		},
+		{
+			name:      "signed by another key",
+			publicKey: newTestSigner(t).publicKey,
+			release: func(t *testing.T) *Release {
+				return verifiedReleaseServer(t, archive, goodChecksums, signer.sign(goodChecksums))
+			},
+			errorMsg: "invalid signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exePath := filepath.Join(t.TempDir(), "wt-bin")
+			if err := os.WriteFile(exePath, []byte("old binary"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			oldExecutable := executablePath
+			executablePath = func() (string, error) { return exePath, nil }
+			oldKey := signingPublicKey
+			signingPublicKey = tt.publicKey
+			defer func() {
+				executablePath = oldExecutable
+				signingPublicKey = oldKey
+			}()
+
+			err := DownloadAndInstall(tt.release(t), func(downloaded, total int64) {})
+
+			content, readErr := os.ReadFile(exePath)
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !bytes.Equal(content, newBinary) {
+					t.Error("Binary was not updated")
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errorMsg, err)
+			}
+			if string(content) != "old binary" {
+				t.Error("Binary was replaced despite failed verification")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package update
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version. Build metadata is dropped since it
+// does not affect precedence.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          []string
+}
+
+// ParseVersion parses "v1.2.3", "1.2.3-beta.1" or "1.2" (missing parts are
+// zero) into a Version
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	rest, _, _ = strings.Cut(rest, "+")
+
+	core, pre, hasPre := strings.Cut(rest, "-")
+	if hasPre {
+		if pre == "" {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		v.Prerelease = strings.Split(pre, ".")
+		for _, id := range v.Prerelease {
+			if id == "" {
+				return Version{}, fmt.Errorf("invalid version %q", s)
+			}
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// IsPrerelease reports whether v has a pre-release suffix such as "-beta.1"
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 as v sorts before, equal to or after other,
+// following semver precedence: 1.0.0-alpha < 1.0.0-beta.2 < 1.0.0-beta.11 < 1.0.0
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	// A release sorts after any of its pre-releases
+	switch {
+	case !v.IsPrerelease() && !other.IsPrerelease():
+		return 0
+	case !v.IsPrerelease():
+		return 1
+	case !other.IsPrerelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := comparePrereleaseID(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.Prerelease) - len(other.Prerelease))
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.IsPrerelease() {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// comparePrereleaseID compares numeric identifiers numerically and others
+// lexically, with numeric identifiers sorting first
+func comparePrereleaseID(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(an - bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// isNewer reports whether tag is a newer version than current. A "dev" build
+// is never updated automatically; any other current version that does not
+// parse, such as a commit hash, is offered every release, as before versions
+// were compared.
+func isNewer(tag, current string) bool {
+	latest, err := ParseVersion(tag)
+	if err != nil {
+		return false
+	}
+	if current == "dev" {
+		return false
+	}
+	installed, err := ParseVersion(current)
+	if err != nil {
+		return true
+	}
+	return latest.Compare(installed) > 0
+}
@@ -0,0 +1,93 @@
+package update
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Version
+		wantErr bool
+	}{
+		{input: "v1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{input: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{input: "v1.2", want: Version{Major: 1, Minor: 2}},
+		{input: "v2.0.0-beta.1", want: Version{Major: 2, Prerelease: []string{"beta", "1"}}},
+		{input: "v1.0.0+build.5", want: Version{Major: 1}},
+		{input: "dev", wantErr: true},
+		{input: "v1.2.3.4", wantErr: true},
+		{input: "v1.0.0-", wantErr: true},
+		{input: "v1.0.0-beta..1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVersion(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseVersion(%q) expected an error, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Each version sorts strictly after the one before it
+	ordered := []string{
+		"v0.9.9",
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.2.0",
+		"v1.10.0",
+		"v2.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			want := sign(i - j)
+			if got := a.Compare(b); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		tag, current string
+		want         bool
+	}{
+		{"v1.2.0", "v1.0.0", true},
+		{"v1.2.0", "1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0-beta.1", "v1.9.0", true},
+		{"v2.0.0", "v2.0.0-beta.1", true},
+		{"v1.2.0", "dev", false},
+		{"v1.2.0", "3f2a9c1", true},
+		{"not-a-version", "v1.0.0", false},
+	}
+
+	for _, tt := range tests {
+		if got := isNewer(tt.tag, tt.current); got != tt.want {
+			t.Errorf("isNewer(%q, %q) = %v, want %v", tt.tag, tt.current, got, tt.want)
+		}
+	}
+}
@@ -65,11 +65,20 @@ func Integrate(branch string) error {
 		return err
 	}
 
+	// Branches stacked on the integrated branch now build on the default branch
+	children, err := reparentStackChildren(repo, branch, defaultBranch)
+	if err != nil {
+		return err
+	}
+
 	if err := RemoveWithOptions(branch, RemoveOptions{DeleteBranch: true}); err != nil {
 		return err
 	}
 
 	fmt.Printf("Integrated %s into %s and removed worktree.\n", branch, defaultBranch)
+	if len(children) > 0 {
+		fmt.Printf("Re-parented %s onto %s. Run 'wt stack restack' to rebase them.\n", strings.Join(children, ", "), defaultBranch)
+	}
 	return nil
 }
 
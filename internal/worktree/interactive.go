@@ -168,3 +168,66 @@ func getGitStatus(worktreePath string) (string, error) {
 
 	return fmt.Sprintf("%d files with changes", len(lines)), nil
 }
+
+// BranchPreview renders preview content for a branch that may or may not have a
+// worktree: recent commits, a diffstat against the default branch and, when a
+// worktree exists, its working directory status.
+func BranchPreview(branch, worktreePath string, width int) string {
+	var preview strings.Builder
+	preview.WriteString(fmt.Sprintf("Branch: %s\n", branch))
+	if worktreePath != "" {
+		preview.WriteString(fmt.Sprintf("Path:   %s\n", worktreePath))
+	} else {
+		preview.WriteString("Path:   (no worktree, one will be created)\n")
+	}
+
+	branchInfo, err := getBranchInfo(branch)
+	if err == nil && branchInfo != "" {
+		preview.WriteString(fmt.Sprintf("Status: %s\n", branchInfo))
+	}
+
+	if worktreePath != "" {
+		gitStatus, err := getGitStatus(worktreePath)
+		if err == nil && gitStatus != "" {
+			preview.WriteString(fmt.Sprintf("Working directory: %s\n", gitStatus))
+		}
+	}
+
+	preview.WriteString("\n")
+
+	gitLog, err := getGitLog(branch, 5)
+	if err != nil {
+		preview.WriteString("Recent commits: (unable to load)\n")
+	} else {
+		preview.WriteString("Recent commits:\n")
+		preview.WriteString(gitLog)
+		preview.WriteString("\n")
+	}
+
+	if repo, err := GetRepoRoot(); err == nil {
+		defaultBranch := detectDefaultBranch(repo)
+		if branch != defaultBranch {
+			if stat, err := getDiffStat(repo, defaultBranch, branch, width); err == nil && stat != "" {
+				preview.WriteString(fmt.Sprintf("\nChanges vs %s:\n", defaultBranch))
+				preview.WriteString(stat)
+			}
+		}
+	}
+
+	return preview.String()
+}
+
+// getDiffStat returns the diffstat of branch against its merge base with base
+func getDiffStat(repo, base, branch string, width int) (string, error) {
+	args := []string{"-C", repo, "diff", "--stat"}
+	if width > 0 {
+		args = []string{"-C", repo, "diff", fmt.Sprintf("--stat=%d", width)}
+	}
+	args = append(args, fmt.Sprintf("%s...%s", base, branch))
+
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
@@ -1,7 +1,11 @@
 package worktree
 
 import (
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/tobiase/worktree-utils/test/helpers"
 )
 
 func TestGetAvailableBranches(t *testing.T) {
@@ -114,3 +118,30 @@ func TestGitStatusFunction(t *testing.T) {
 		t.Error("getGitStatus should error for non-existent path")
 	}
 }
+
+func TestBranchPreview(t *testing.T) {
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	_ = os.Chdir(repo)
+
+	worktreePath, err := helpers.AddTestWorktree(t, repo, "feature-preview")
+	if err != nil {
+		t.Fatalf("Failed to create test worktree: %v", err)
+	}
+	commitFile(t, worktreePath, "preview.txt", "preview\n", "preview work")
+
+	preview := BranchPreview("feature-preview", worktreePath, 80)
+	for _, want := range []string{"Branch: feature-preview", "Path:   " + worktreePath, "• preview work", "Changes vs main:", "preview.txt"} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("Preview missing %q:\n%s", want, preview)
+		}
+	}
+
+	preview = BranchPreview("feature-preview", "", 80)
+	if !strings.Contains(preview, "no worktree") {
+		t.Errorf("Preview should mention the missing worktree:\n%s", preview)
+	}
+}
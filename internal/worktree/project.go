@@ -141,6 +141,13 @@ func createBranchAndWorktree(branch string, baseBranch string, cfg *config.Manag
 		return "", fmt.Errorf("failed to create worktree: %v", err)
 	}
 
+	// Branches created on top of another feature branch form a stack
+	if baseBranch != "" && baseBranch != detectDefaultBranch(repo) && checkBranchExists(baseBranch) {
+		if err := recordStackParent(repo, branch, baseBranch); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
 	return worktreePath, nil
 }
 
@@ -0,0 +1,387 @@
+package worktree
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Stack metadata is stored in the repository's git config so it is shared by
+// every worktree and removed automatically when a branch is deleted:
+//
+//	branch.<name>.wtparent      parent branch the branch was created on
+//	branch.<name>.wtparentbase  parent commit the branch is currently based on
+const (
+	stackParentKey = "wtparent"
+	stackBaseKey   = "wtparentbase"
+)
+
+// StackNode represents a branch in a tree of dependent (stacked) branches
+type StackNode struct {
+	Branch   string
+	Parent   string
+	Base     string
+	Path     string
+	Children []*StackNode
+}
+
+// stackEntry holds the recorded parent information for a single branch
+type stackEntry struct {
+	parent string
+	base   string
+}
+
+// recordStackParent stores the parent relationship for a newly created branch
+func recordStackParent(repo, branch, parent string) error {
+	base, err := revParse(repo, parent)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", parent, err)
+	}
+	return setStackEntry(repo, branch, stackEntry{parent: parent, base: base})
+}
+
+func setStackEntry(repo, branch string, entry stackEntry) error {
+	if err := exec.Command("git", "-C", repo, "config", stackConfigKey(branch, stackParentKey), entry.parent).Run(); err != nil {
+		return fmt.Errorf("failed to record parent of %s: %v", branch, err)
+	}
+	if err := exec.Command("git", "-C", repo, "config", stackConfigKey(branch, stackBaseKey), entry.base).Run(); err != nil {
+		return fmt.Errorf("failed to record base of %s: %v", branch, err)
+	}
+	return nil
+}
+
+func clearStackEntry(repo, branch string) error {
+	for _, key := range []string{stackParentKey, stackBaseKey} {
+		cmd := exec.Command("git", "-C", repo, "config", "--unset", stackConfigKey(branch, key))
+		if err := cmd.Run(); err != nil {
+			// Exit code 5 means the key was not set
+			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 5 {
+				continue
+			}
+			return fmt.Errorf("failed to clear stack info for %s: %v", branch, err)
+		}
+	}
+	return nil
+}
+
+func stackConfigKey(branch, key string) string {
+	return "branch." + branch + "." + key
+}
+
+// loadStackEntries reads all recorded parent relationships from git config
+func loadStackEntries(repo string) (map[string]stackEntry, error) {
+	cmd := exec.Command("git", "-C", repo, "config", "--get-regexp", `^branch\..*\.wtparent(base)?$`)
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit code 1 means no matching keys
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return map[string]stackEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read stack info: %v", err)
+	}
+
+	entries := make(map[string]stackEntry)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		name := strings.TrimPrefix(key, "branch.")
+		switch {
+		case strings.HasSuffix(name, "."+stackBaseKey):
+			branch := strings.TrimSuffix(name, "."+stackBaseKey)
+			entry := entries[branch]
+			entry.base = value
+			entries[branch] = entry
+		case strings.HasSuffix(name, "."+stackParentKey):
+			branch := strings.TrimSuffix(name, "."+stackParentKey)
+			entry := entries[branch]
+			entry.parent = value
+			entries[branch] = entry
+		}
+	}
+
+	for branch, entry := range entries {
+		if entry.parent == "" {
+			delete(entries, branch)
+		}
+	}
+
+	return entries, nil
+}
+
+// GetStacks returns the trees of stacked branches, keyed by their root branch
+func GetStacks() ([]*StackNode, error) {
+	repo, err := GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := loadStackEntries(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	worktrees, err := parseWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	return buildStackTrees(entries, worktrees), nil
+}
+
+// buildStackTrees turns the flat parent map into trees rooted at unstacked branches
+func buildStackTrees(entries map[string]stackEntry, worktrees []Worktree) []*StackNode {
+	paths := make(map[string]string, len(worktrees))
+	for _, wt := range worktrees {
+		paths[wt.Branch] = wt.Path
+	}
+
+	nodes := make(map[string]*StackNode)
+	getNode := func(branch string) *StackNode {
+		if node, ok := nodes[branch]; ok {
+			return node
+		}
+		node := &StackNode{Branch: branch, Path: paths[branch]}
+		nodes[branch] = node
+		return node
+	}
+
+	branches := make([]string, 0, len(entries))
+	for branch := range entries {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	for _, branch := range branches {
+		entry := entries[branch]
+		node := getNode(branch)
+		node.Parent = entry.parent
+		node.Base = entry.base
+		parent := getNode(entry.parent)
+		parent.Children = append(parent.Children, node)
+	}
+
+	var roots []*StackNode
+	for _, branch := range sortedKeys(nodes) {
+		node := nodes[branch]
+		if _, stacked := entries[branch]; !stacked {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+func sortedKeys(nodes map[string]*StackNode) []string {
+	keys := make([]string, 0, len(nodes))
+	for key := range nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ShowStacks prints all stacked branches as a tree
+func ShowStacks() error {
+	repo, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	roots, err := GetStacks()
+	if err != nil {
+		return err
+	}
+
+	if len(roots) == 0 {
+		fmt.Println("No stacked branches. Create one with: wt new <branch> --base <parent-branch>")
+		return nil
+	}
+
+	for i, root := range roots {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(root.Branch)
+		printStackChildren(repo, root, "")
+	}
+	return nil
+}
+
+func printStackChildren(repo string, node *StackNode, indent string) {
+	for i, child := range node.Children {
+		connector, childIndent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			connector, childIndent = "└── ", "    "
+		}
+
+		line := indent + connector + child.Branch
+		if child.Path == "" {
+			line += " (no worktree)"
+		}
+		if needsRestack(repo, child) {
+			line += " [needs restack]"
+		}
+		fmt.Println(line)
+
+		printStackChildren(repo, child, indent+childIndent)
+	}
+}
+
+// needsRestack reports whether a branch is no longer based on its parent's tip
+func needsRestack(repo string, node *StackNode) bool {
+	parentTip, err := revParse(repo, node.Parent)
+	if err != nil || parentTip == node.Base {
+		return false
+	}
+	contained, err := branchMergedInto(repo, parentTip, node.Branch)
+	return err == nil && !contained
+}
+
+// Restack rebases every descendant of branch onto its parent, parents first.
+// An empty branch restacks all recorded stacks.
+func Restack(branch string) error {
+	repo, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	roots, err := GetStacks()
+	if err != nil {
+		return err
+	}
+
+	var start []*StackNode
+	if branch == "" {
+		start = roots
+	} else {
+		node := findStackNode(roots, branch)
+		if node == nil {
+			return fmt.Errorf("branch '%s' is not part of a stack", branch)
+		}
+		start = []*StackNode{node}
+	}
+
+	defaultBranch := detectDefaultBranch(repo)
+	restacked := 0
+	for _, node := range start {
+		count, err := restackChildren(repo, node, defaultBranch)
+		restacked += count
+		if err != nil {
+			return err
+		}
+	}
+
+	if restacked == 0 {
+		fmt.Println("All stacked branches are up to date.")
+	} else {
+		fmt.Printf("✓ Restacked %d branch(es)\n", restacked)
+	}
+	return nil
+}
+
+func findStackNode(nodes []*StackNode, branch string) *StackNode {
+	for _, node := range nodes {
+		if node.Branch == branch {
+			return node
+		}
+		if found := findStackNode(node.Children, branch); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// restackChildren rebases the children of node in depth-first order
+func restackChildren(repo string, node *StackNode, defaultBranch string) (int, error) {
+	restacked := 0
+	for _, child := range node.Children {
+		changed, err := restackBranch(repo, child, defaultBranch)
+		if err != nil {
+			return restacked, err
+		}
+		if changed {
+			restacked++
+		}
+
+		count, err := restackChildren(repo, child, defaultBranch)
+		restacked += count
+		if err != nil {
+			return restacked, err
+		}
+	}
+	return restacked, nil
+}
+
+// restackBranch rebases a single branch onto its parent using --onto semantics
+func restackBranch(repo string, node *StackNode, defaultBranch string) (bool, error) {
+	parentTip, err := revParse(repo, node.Parent)
+	if err != nil {
+		return false, fmt.Errorf("parent branch '%s' of %s not found: %v", node.Parent, node.Branch, err)
+	}
+
+	// The branch may already contain its parent, e.g. after a conflict was resolved by hand
+	if contained, err := branchMergedInto(repo, parentTip, node.Branch); err == nil && contained {
+		return false, finishRestack(repo, node, parentTip, defaultBranch)
+	}
+
+	if node.Path == "" {
+		fmt.Printf("Skipping %s: no worktree (create one with 'wt new %s')\n", node.Branch, node.Branch)
+		return false, nil
+	}
+
+	if err := ensureCleanWorktree(node.Path); err != nil {
+		return false, fmt.Errorf("%s has uncommitted changes: %w", node.Path, err)
+	}
+
+	fmt.Printf("Rebasing %s onto %s...\n", node.Branch, node.Parent)
+	if err := runGitCommandStreaming(node.Path, "rebase", "--onto", node.Parent, node.Base); err != nil {
+		return false, fmt.Errorf("rebase of %s onto %s stopped; resolve the conflicts in %s, run 'git rebase --continue', then run 'wt stack restack' again: %w",
+			node.Branch, node.Parent, node.Path, err)
+	}
+
+	return true, finishRestack(repo, node, parentTip, defaultBranch)
+}
+
+// finishRestack records the new base, or drops the stack entry once the branch
+// sits directly on the default branch
+func finishRestack(repo string, node *StackNode, parentTip, defaultBranch string) error {
+	if node.Parent == defaultBranch {
+		return clearStackEntry(repo, node.Branch)
+	}
+	node.Base = parentTip
+	return setStackEntry(repo, node.Branch, stackEntry{parent: node.Parent, base: parentTip})
+}
+
+// reparentStackChildren moves the children of branch onto newParent, keeping
+// their recorded base so a later restack only replays their own commits
+func reparentStackChildren(repo, branch, newParent string) ([]string, error) {
+	entries, err := loadStackEntries(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []string
+	for child, entry := range entries {
+		if entry.parent != branch {
+			continue
+		}
+		entry.parent = newParent
+		if err := setStackEntry(repo, child, entry); err != nil {
+			return moved, err
+		}
+		moved = append(moved, child)
+	}
+	sort.Strings(moved)
+	return moved, nil
+}
+
+func revParse(repo, ref string) (string, error) {
+	cmd := exec.Command("git", "-C", repo, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
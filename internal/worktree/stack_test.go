@@ -0,0 +1,165 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/test/helpers"
+)
+
+func TestBuildStackTrees(t *testing.T) {
+	entries := map[string]stackEntry{
+		"feature-b": {parent: "feature-a", base: "aaa"},
+		"feature-c": {parent: "feature-a", base: "aaa"},
+		"feature-a": {parent: "develop", base: "ddd"},
+		"hotfix-2":  {parent: "hotfix-1", base: "hhh"},
+	}
+	worktrees := []Worktree{
+		{Path: "/repo", Branch: "main"},
+		{Path: "/repo-worktrees/feature-a", Branch: "feature-a"},
+		{Path: "/repo-worktrees/feature-b", Branch: "feature-b"},
+	}
+
+	roots := buildStackTrees(entries, worktrees)
+
+	if len(roots) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(roots))
+	}
+	if roots[0].Branch != "develop" || roots[1].Branch != "hotfix-1" {
+		t.Fatalf("Unexpected roots: %s, %s", roots[0].Branch, roots[1].Branch)
+	}
+
+	featureA := roots[0].Children[0]
+	if featureA.Branch != "feature-a" || featureA.Path != "/repo-worktrees/feature-a" {
+		t.Errorf("Unexpected node: %+v", featureA)
+	}
+	if len(featureA.Children) != 2 {
+		t.Fatalf("Expected feature-a to have 2 children, got %d", len(featureA.Children))
+	}
+	if featureA.Children[0].Branch != "feature-b" || featureA.Children[1].Branch != "feature-c" {
+		t.Errorf("Children not sorted: %s, %s", featureA.Children[0].Branch, featureA.Children[1].Branch)
+	}
+	if featureA.Children[1].Path != "" {
+		t.Errorf("feature-c should have no worktree, got %q", featureA.Children[1].Path)
+	}
+
+	if node := findStackNode(roots, "hotfix-2"); node == nil || node.Parent != "hotfix-1" {
+		t.Errorf("findStackNode(hotfix-2) = %+v", node)
+	}
+}
+
+func commitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	if _, _, err := helpers.RunCommand(t, "git", "-C", dir, "add", "."); err != nil {
+		t.Fatalf("Failed to add changes: %v", err)
+	}
+	if _, _, err := helpers.RunCommand(t, "git", "-C", dir, "commit", "-m", message); err != nil {
+		t.Fatalf("Failed to commit changes: %v", err)
+	}
+}
+
+func TestStackRestackAndIntegrate(t *testing.T) {
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	_ = os.Chdir(repo)
+
+	pathA, err := createBranchAndWorktree("feature-a", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to create feature-a: %v", err)
+	}
+	commitFile(t, pathA, "a.txt", "a1", "feature a")
+
+	pathB, err := createBranchAndWorktree("feature-b", "feature-a", nil)
+	if err != nil {
+		t.Fatalf("Failed to create feature-b: %v", err)
+	}
+	commitFile(t, pathB, "b.txt", "b1", "feature b")
+
+	entries, err := loadStackEntries(repo)
+	if err != nil {
+		t.Fatalf("loadStackEntries() error = %v", err)
+	}
+	if entries["feature-b"].parent != "feature-a" {
+		t.Fatalf("Expected feature-b to be stacked on feature-a, got %+v", entries)
+	}
+	if _, stacked := entries["feature-a"]; stacked {
+		t.Error("feature-a was created from HEAD and should not be stacked")
+	}
+
+	// Rewrite feature-a so feature-b is left on a stale commit
+	if err := os.WriteFile(filepath.Join(pathA, "a.txt"), []byte("a2"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, _, err := helpers.RunCommand(t, "git", "-C", pathA, "commit", "-a", "--amend", "-m", "feature a v2"); err != nil {
+		t.Fatalf("Failed to amend: %v", err)
+	}
+
+	roots, err := GetStacks()
+	if err != nil {
+		t.Fatalf("GetStacks() error = %v", err)
+	}
+	if !needsRestack(repo, findStackNode(roots, "feature-b")) {
+		t.Error("feature-b should need a restack after feature-a was amended")
+	}
+
+	if err := Restack(""); err != nil {
+		t.Fatalf("Restack() error = %v", err)
+	}
+
+	if merged, _ := branchMergedInto(repo, "feature-a", "feature-b"); !merged {
+		t.Error("feature-b should be based on the new feature-a tip")
+	}
+	stdout, _, err := helpers.RunCommand(t, "git", "rev-list", "--count", "main..feature-b")
+	if err != nil {
+		t.Fatalf("Failed to count commits: %v", err)
+	}
+	if strings.TrimSpace(stdout) != "2" {
+		t.Errorf("Expected 2 commits on feature-b after restack, got %s", strings.TrimSpace(stdout))
+	}
+
+	if err := Integrate("feature-a"); err != nil {
+		t.Fatalf("Integrate() error = %v", err)
+	}
+
+	entries, err = loadStackEntries(repo)
+	if err != nil {
+		t.Fatalf("loadStackEntries() error = %v", err)
+	}
+	if entries["feature-b"].parent != "main" {
+		t.Fatalf("Expected feature-b to be re-parented onto main, got %+v", entries["feature-b"])
+	}
+
+	if err := Restack("main"); err != nil {
+		t.Fatalf("Restack(main) error = %v", err)
+	}
+
+	entries, err = loadStackEntries(repo)
+	if err != nil {
+		t.Fatalf("loadStackEntries() error = %v", err)
+	}
+	if _, stacked := entries["feature-b"]; stacked {
+		t.Error("feature-b should no longer be stacked once it sits on main")
+	}
+}
+
+func TestRestackUnknownBranch(t *testing.T) {
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	_ = os.Chdir(repo)
+
+	err := Restack("does-not-exist")
+	if err == nil || !strings.Contains(err.Error(), "not part of a stack") {
+		t.Fatalf("Expected not part of a stack error, got: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package worktree
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Sync strategies
+const (
+	SyncRebase = "rebase"
+	SyncMerge  = "merge"
+)
+
+// SyncOptions controls which worktrees are synced and how
+type SyncOptions struct {
+	All      bool     // Sync every worktree instead of the current one
+	Branches []string // Specific branches to sync
+	Strategy string   // SyncRebase (default) or SyncMerge
+	Upstream bool     // Sync onto each branch's upstream instead of the default branch
+}
+
+// syncResult records the outcome for a single worktree
+type syncResult struct {
+	branch  string
+	status  string
+	message string
+}
+
+const (
+	syncUpdated  = "updated"
+	syncUpToDate = "up-to-date"
+	syncSkipped  = "skipped"
+	syncFailed   = "failed"
+	syncPending  = "pending"
+)
+
+// Sync fetches once and then rebases (or merges) every selected clean worktree
+// onto the updated default branch. Dirty worktrees are skipped; the first
+// conflict is aborted and stops the run.
+func Sync(opts SyncOptions) error {
+	repo, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	if opts.Strategy == "" {
+		opts.Strategy = SyncRebase
+	}
+	if opts.Strategy != SyncRebase && opts.Strategy != SyncMerge {
+		return fmt.Errorf("unknown sync strategy '%s' (expected %s or %s)", opts.Strategy, SyncRebase, SyncMerge)
+	}
+
+	worktrees, err := parseWorktrees()
+	if err != nil {
+		return err
+	}
+
+	targets, err := selectSyncTargets(repo, worktrees, opts)
+	if err != nil {
+		return err
+	}
+
+	defaultBranch := detectDefaultBranch(repo)
+	remoteName := "origin"
+	useRemote := hasRemote(repo, remoteName)
+
+	if useRemote {
+		fmt.Printf("Fetching %s...\n", remoteName)
+		if err := runGitCommandStreaming(repo, "fetch", remoteName, "--prune"); err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", remoteName, err)
+		}
+		if err := updateDefaultBranch(repo, worktrees, defaultBranch, remoteName); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	defaultTarget := defaultBranch
+	if useRemote {
+		defaultTarget = fmt.Sprintf("%s/%s", remoteName, defaultBranch)
+	}
+
+	stacked, err := loadStackEntries(repo)
+	if err != nil {
+		return err
+	}
+
+	results := make([]syncResult, 0, len(targets))
+	for _, wt := range targets {
+		results = append(results, syncResult{branch: wt.Branch, status: syncPending})
+	}
+
+	var syncErr error
+	for i, wt := range targets {
+		result := &results[i]
+
+		if wt.Branch == defaultBranch {
+			result.status, result.message = syncSkipped, "default branch"
+			continue
+		}
+		if entry, ok := stacked[wt.Branch]; ok && !opts.Upstream {
+			result.status = syncSkipped
+			result.message = fmt.Sprintf("stacked on %s (use 'wt stack restack')", entry.parent)
+			continue
+		}
+		if err := ensureCleanWorktree(wt.Path); err != nil {
+			result.status, result.message = syncSkipped, "uncommitted changes"
+			continue
+		}
+
+		target := defaultTarget
+		if opts.Upstream {
+			upstream, err := branchUpstream(wt.Path, wt.Branch)
+			if err != nil {
+				result.status, result.message = syncSkipped, "no upstream configured"
+				continue
+			}
+			target = upstream
+		}
+
+		if upToDate, err := branchMergedInto(repo, target, wt.Branch); err == nil && upToDate {
+			result.status, result.message = syncUpToDate, target
+			continue
+		}
+
+		fmt.Printf("\nSyncing %s onto %s (%s)...\n", wt.Branch, target, opts.Strategy)
+		if err := runGitCommandStreaming(wt.Path, opts.Strategy, target); err != nil {
+			_ = runGitCommandStreaming(wt.Path, opts.Strategy, "--abort")
+			result.status = syncFailed
+			result.message = fmt.Sprintf("conflict with %s, %s aborted", target, opts.Strategy)
+			syncErr = fmt.Errorf("sync stopped at %s: %s onto %s failed", wt.Branch, opts.Strategy, target)
+			break
+		}
+		result.status, result.message = syncUpdated, target
+	}
+
+	printSyncSummary(results, opts.Strategy)
+	return syncErr
+}
+
+// selectSyncTargets resolves the worktrees a sync run should touch
+func selectSyncTargets(repo string, worktrees []Worktree, opts SyncOptions) ([]Worktree, error) {
+	var targets []Worktree
+
+	switch {
+	case opts.All:
+		for _, wt := range worktrees {
+			if wt.Branch != "" {
+				targets = append(targets, wt)
+			}
+		}
+	case len(opts.Branches) > 0:
+		for _, branch := range opts.Branches {
+			found := false
+			for _, wt := range worktrees {
+				if wt.Branch == branch {
+					targets = append(targets, wt)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("worktree '%s' not found", branch)
+			}
+		}
+	default:
+		for _, wt := range worktrees {
+			if samePath(wt.Path, repo) {
+				targets = append(targets, wt)
+				break
+			}
+		}
+		if len(targets) == 0 || targets[0].Branch == "" {
+			return nil, fmt.Errorf("current worktree is not on a branch")
+		}
+	}
+
+	return targets, nil
+}
+
+// updateDefaultBranch fast-forwards the local default branch to its remote counterpart
+func updateDefaultBranch(repo string, worktrees []Worktree, defaultBranch, remote string) error {
+	remoteRef := fmt.Sprintf("%s/%s", remote, defaultBranch)
+	if _, err := revParse(repo, remoteRef); err != nil {
+		return nil
+	}
+	if upToDate, err := branchMergedInto(repo, remoteRef, defaultBranch); err == nil && upToDate {
+		return nil
+	}
+
+	for _, wt := range worktrees {
+		if wt.Branch != defaultBranch {
+			continue
+		}
+		if err := ensureCleanWorktree(wt.Path); err != nil {
+			return fmt.Errorf("not updating %s: %s has uncommitted changes", defaultBranch, wt.Path)
+		}
+		if err := runGitCommandStreaming(wt.Path, "merge", "--ff-only", remoteRef); err != nil {
+			return fmt.Errorf("could not fast-forward %s to %s", defaultBranch, remoteRef)
+		}
+		return nil
+	}
+
+	// Not checked out anywhere: move the ref directly if it is a fast-forward
+	if ff, err := branchMergedInto(repo, defaultBranch, remoteRef); err != nil || !ff {
+		return fmt.Errorf("%s has diverged from %s; not updating it", defaultBranch, remoteRef)
+	}
+	cmd := exec.Command("git", "-C", repo, "update-ref", "refs/heads/"+defaultBranch, remoteRef)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to update %s: %v", defaultBranch, err)
+	}
+	return nil
+}
+
+// branchUpstream returns the upstream tracking ref of a branch
+func branchUpstream(path, branch string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--abbrev-ref", branch+"@{upstream}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func printSyncSummary(results []syncResult, strategy string) {
+	fmt.Println("\nSync summary:")
+	for _, r := range results {
+		switch r.status {
+		case syncUpdated:
+			fmt.Printf("  ✓ %s: %sd onto %s\n", r.branch, strategy, r.message)
+		case syncUpToDate:
+			fmt.Printf("  ✓ %s: already up to date with %s\n", r.branch, r.message)
+		case syncSkipped:
+			fmt.Printf("  - %s: skipped (%s)\n", r.branch, r.message)
+		case syncFailed:
+			fmt.Printf("  ✗ %s: %s\n", r.branch, r.message)
+		case syncPending:
+			fmt.Printf("  · %s: not processed\n", r.branch)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tobiase/worktree-utils/test/helpers"
+)
+
+// createClonedRepo clones a fresh test repository so syncs have an origin to fetch from
+func createClonedRepo(t *testing.T) (origin, clone string) {
+	t.Helper()
+
+	origin, cleanup := helpers.CreateTestRepo(t)
+	t.Cleanup(cleanup)
+
+	clone = filepath.Join(t.TempDir(), "clone")
+	if _, _, err := helpers.RunCommand(t, "git", "clone", "-q", origin, clone); err != nil {
+		t.Fatalf("Failed to clone repo: %v", err)
+	}
+	for _, kv := range [][]string{{"user.name", "Test User"}, {"user.email", "test@example.com"}} {
+		if _, _, err := helpers.RunCommand(t, "git", "-C", clone, "config", kv[0], kv[1]); err != nil {
+			t.Fatalf("Failed to configure clone: %v", err)
+		}
+	}
+	return origin, clone
+}
+
+func TestSyncAll(t *testing.T) {
+	origin, clone := createClonedRepo(t)
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	_ = os.Chdir(clone)
+
+	cleanPath, err := helpers.AddTestWorktree(t, clone, "feature-clean")
+	if err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+	commitFile(t, cleanPath, "clean.txt", "clean", "clean work")
+
+	dirtyPath, err := helpers.AddTestWorktree(t, clone, "feature-dirty")
+	if err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dirtyPath, "wip.txt"), []byte("wip"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	commitFile(t, origin, "upstream.txt", "upstream", "upstream work")
+
+	stdout, _, err := helpers.CaptureOutput(func() {
+		err = Sync(SyncOptions{All: true})
+	})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if merged, _ := branchMergedInto(clone, "origin/main", "feature-clean"); !merged {
+		t.Error("feature-clean should be rebased onto origin/main")
+	}
+	if merged, _ := branchMergedInto(clone, "origin/main", "feature-dirty"); merged {
+		t.Error("feature-dirty should have been skipped")
+	}
+	if merged, _ := branchMergedInto(clone, "origin/main", "main"); !merged {
+		t.Error("local main should be fast-forwarded to origin/main")
+	}
+
+	for _, want := range []string{"feature-clean: rebased onto origin/main", "feature-dirty: skipped (uncommitted changes)", "main: skipped (default branch)"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Summary missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestSyncStopsOnConflict(t *testing.T) {
+	origin, clone := createClonedRepo(t)
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	_ = os.Chdir(clone)
+
+	conflictPath, err := helpers.AddTestWorktree(t, clone, "a-conflict")
+	if err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+	commitFile(t, conflictPath, "README.md", "feature version\n", "feature readme")
+
+	if _, err := helpers.AddTestWorktree(t, clone, "b-later"); err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+
+	commitFile(t, origin, "README.md", "upstream version\n", "upstream readme")
+
+	stdout, _, _ := helpers.CaptureOutput(func() {
+		err = Sync(SyncOptions{Branches: []string{"a-conflict", "b-later"}, Strategy: SyncMerge})
+	})
+	if err == nil || !strings.Contains(err.Error(), "sync stopped at a-conflict") {
+		t.Fatalf("Expected conflict error, got: %v", err)
+	}
+
+	if err := ensureCleanWorktree(conflictPath); err != nil {
+		t.Errorf("Conflicting merge should be aborted, got: %v", err)
+	}
+	if !strings.Contains(stdout, "b-later: not processed") {
+		t.Errorf("Expected b-later to be reported as not processed:\n%s", stdout)
+	}
+}
+
+func TestSyncInvalidOptions(t *testing.T) {
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+
+	oldWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(oldWd) }()
+	_ = os.Chdir(repo)
+
+	tests := []struct {
+		name    string
+		opts    SyncOptions
+		wantErr string
+	}{
+		{"unknown strategy", SyncOptions{Strategy: "squash"}, "unknown sync strategy"},
+		{"missing worktree", SyncOptions{Branches: []string{"nope"}}, "worktree 'nope' not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Sync(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Sync() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -324,6 +324,21 @@ func GetWorktreeInfo() ([]Worktree, error) {
 	return parseWorktrees()
 }
 
+// BranchForPath returns the branch checked out in the worktree at path
+func BranchForPath(path string) (string, error) {
+	worktrees, err := parseWorktrees()
+	if err != nil {
+		return "", err
+	}
+
+	for _, wt := range worktrees {
+		if samePath(wt.Path, path) {
+			return wt.Branch, nil
+		}
+	}
+	return "", fmt.Errorf("no worktree at %s", path)
+}
+
 // Go returns the path to change to based on index or branch name
 func Go(target string) (string, error) {
 	worktrees, err := parseWorktrees()
@@ -409,6 +424,15 @@ func samePath(a, b string) bool {
 	return normalizePath(a) == normalizePath(b)
 }
 
+// GetDefaultBranch returns the repository's default branch (e.g. main)
+func GetDefaultBranch() (string, error) {
+	repo, err := GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return detectDefaultBranch(repo), nil
+}
+
 func detectDefaultBranch(repo string) string {
 	cmd := exec.Command("git", "-C", repo, "symbolic-ref", "refs/remotes/origin/HEAD")
 	if output, err := cmd.Output(); err == nil {
@@ -497,6 +521,11 @@ func checkWorktreeExists(branch string) bool {
 	return false
 }
 
+// WorktreeExists reports whether a worktree is checked out for branch
+func WorktreeExists(branch string) bool {
+	return checkWorktreeExists(branch)
+}
+
 // ResolveBranchName attempts to resolve a partial branch name to a full branch name
 // Returns the resolved name, or original input if no unique match found
 func ResolveBranchName(input string, availableBranches []string) (string, error) {
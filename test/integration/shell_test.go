@@ -279,6 +279,214 @@ eval "$($WT_BIN shell-init)"
 	}
 }
 
+// TestShellWrappersPerShell drives the wrapper from 'shell-init --shell' in each
+// installed shell against a mock binary
+func TestShellWrappersPerShell(t *testing.T) {
+	binPath := buildTestBinary(t)
+	defer os.Remove(binPath)
+
+	targetDir := t.TempDir()
+	mockBin := createMockBinary(t, map[string]mockResponse{
+		"go main": {output: "CD:" + targetDir, exitCode: 0},
+		"venv":    {output: "EXEC:echo exec-ran", exitCode: 0},
+		"list":    {output: "Regular output", exitCode: 0},
+		"error":   {output: "Error message", exitCode: 1, isError: true},
+		"envtest": {output: "SETENV:BAR=baz qux\nUNSETENV:FOO", exitCode: 0},
+	})
+	defer os.Remove(mockBin)
+
+	posixScript := `%s '%s'
+wt go main
+pwd
+wt venv
+wt list
+wt error || echo "status=$?"
+export FOO=1
+wt envtest
+echo "foo=$FOO bar=$BAR"
+`
+
+	shells := []struct {
+		binary    string
+		initShell string
+		script    string
+		wantExec  string
+	}{
+		{binary: "bash", initShell: "bash", script: posixScript, wantExec: "exec-ran"},
+		{binary: "zsh", initShell: "zsh", script: posixScript, wantExec: "exec-ran"},
+		{binary: "dash", initShell: "sh", script: posixScript, wantExec: "exec-ran"},
+		{binary: "sh", initShell: "sh", script: posixScript, wantExec: "exec-ran"},
+		{binary: "fish", initShell: "fish", script: `%s '%s'
+wt go main
+pwd
+wt venv
+wt list
+wt error; or echo "status=$status"
+set -gx FOO 1
+wt envtest
+echo "foo=$FOO bar=$BAR"
+`, wantExec: "exec-ran"},
+		{binary: "nu", initShell: "nu", script: `%s '%s'
+wt go main
+print (pwd)
+wt venv
+wt list
+try { wt error } catch { print "status=1" }
+$env.FOO = "1"
+wt envtest
+print $"foo=($env.FOO? | default '') bar=($env.BAR)"
+`, wantExec: "can't run 'echo exec-ran'"},
+	}
+
+	for _, sh := range shells {
+		t.Run(sh.binary, func(t *testing.T) {
+			shellPath, err := exec.LookPath(sh.binary)
+			if err != nil {
+				t.Skipf("%s not available", sh.binary)
+			}
+
+			wrapper, err := exec.Command(binPath, "shell-init", "--shell", sh.initShell).Output()
+			if err != nil {
+				t.Fatalf("shell-init --shell %s failed: %v", sh.initShell, err)
+			}
+
+			dir := t.TempDir()
+			wrapperFile := filepath.Join(dir, "wrapper")
+			if err := os.WriteFile(wrapperFile, wrapper, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sourceCmd := "source"
+			if sh.initShell == "sh" {
+				sourceCmd = "."
+			}
+			scriptFile := filepath.Join(dir, "test-script")
+			script := fmt.Sprintf(sh.script, sourceCmd, wrapperFile)
+			if err := os.WriteFile(scriptFile, []byte(script), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command(shellPath, scriptFile)
+			cmd.Env = append(os.Environ(), "LANG=C", "WT_BIN="+mockBin)
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s script failed: %v\nOutput: %s", sh.binary, err, output)
+			}
+
+			for _, want := range []string{targetDir, sh.wantExec, "Regular output", "Error message", "status=1", "foo= bar=baz qux"} {
+				if !strings.Contains(string(output), want) {
+					t.Errorf("Expected %s output to contain %q, got:\n%s", sh.binary, want, output)
+				}
+			}
+		})
+	}
+}
+
+// TestDirectiveFileWrapper runs the real binary through the bash wrapper: output
+// streams to the terminal and the cd arrives through the directive file
+func TestDirectiveFileWrapper(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	binPath := buildTestBinary(t)
+	defer os.Remove(binPath)
+
+	repo, cleanup := helpers.CreateTestRepo(t)
+	defer cleanup()
+	worktreePath, err := helpers.AddTestWorktree(t, repo, "feature-directive")
+	if err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+
+	home := t.TempDir()
+	projectsDir := filepath.Join(home, ".config", "wt", "projects")
+	if err := os.MkdirAll(projectsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	resolvedRepo, _ := filepath.EvalSymlinks(repo)
+	projectConfig := `name: directive-test
+match:
+  paths:
+    - ` + repo + `
+    - ` + resolvedRepo + `
+    - ` + repo + `-worktrees/*
+    - ` + resolvedRepo + `-worktrees/*
+env:
+  COMPOSE_PROJECT_NAME: "app-${WT_BRANCH}"
+on_enter:
+  virtualenv: true
+  run:
+    - echo "entered=$PWD"
+on_leave:
+  run:
+    - echo "left=$WT_BRANCH"
+`
+	if err := os.WriteFile(filepath.Join(projectsDir, "directive-test.yaml"), []byte(projectConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stand-in virtualenv whose activate script only sets VIRTUAL_ENV
+	venvBin := filepath.Join(worktreePath, ".venv", "bin")
+	if err := os.MkdirAll(venvBin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	activate := `export VIRTUAL_ENV="` + filepath.Join(worktreePath, ".venv") + `"
+deactivate() { echo "deactivated"; unset VIRTUAL_ENV; unset -f deactivate; }
+`
+	if err := os.WriteFile(filepath.Join(venvBin, "activate"), []byte(activate), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := fmt.Sprintf(`export WT_BIN=%q
+eval "$($WT_BIN shell-init)"
+cd %q
+wt list
+wt go feature-directive
+echo "pwd=$(pwd)"
+echo "env=$WT_BRANCH/$WT_PROJECT/$COMPOSE_PROJECT_NAME"
+echo "venv=${VIRTUAL_ENV##*/}"
+wt go no-such-branch-xyz || echo "status=$?"
+wt 0
+echo "venv-after=${VIRTUAL_ENV:-none}"
+`, binPath, repo)
+
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Env = append(os.Environ(), "LANG=C", "HOME="+home)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Script failed: %v\nOutput: %s", err, output)
+	}
+
+	resolved, _ := filepath.EvalSymlinks(worktreePath)
+	out := string(output)
+	if !strings.Contains(out, "Index") {
+		t.Errorf("Expected list output to stream through, got:\n%s", out)
+	}
+	if !strings.Contains(out, "pwd="+worktreePath) && !strings.Contains(out, "pwd="+resolved) {
+		t.Errorf("Expected to cd into %s, got:\n%s", worktreePath, out)
+	}
+	if !strings.Contains(out, "env=feature-directive/directive-test/app-feature-directive") {
+		t.Errorf("Expected worktree variables to be exported, got:\n%s", out)
+	}
+	if !strings.Contains(out, "entered="+worktreePath) && !strings.Contains(out, "entered="+resolved) {
+		t.Errorf("Expected on_enter to run in the worktree, got:\n%s", out)
+	}
+	if !strings.Contains(out, "venv=.venv") {
+		t.Errorf("Expected on_enter to activate the virtualenv, got:\n%s", out)
+	}
+	if !strings.Contains(out, "left=feature-directive") || !strings.Contains(out, "deactivated") ||
+		!strings.Contains(out, "venv-after=none") {
+		t.Errorf("Expected on_leave to run and the virtualenv to be deactivated, got:\n%s", out)
+	}
+	if strings.Contains(out, "CD:") {
+		t.Errorf("CD: prefix should not reach the terminal, got:\n%s", out)
+	}
+	if !strings.Contains(out, "status=1") {
+		t.Errorf("Expected failing command to return status 1, got:\n%s", out)
+	}
+}
+
 // mockResponse defines what a mock command should return
 type mockResponse struct {
 	output   string
@@ -318,6 +526,22 @@ func main() {
 	if resp, ok := responses[args]; ok {
 		if resp.isError {
 			fmt.Fprint(os.Stderr, resp.output)
+		} else if path := os.Getenv("WT_DIRECTIVE_FILE"); path != "" {
+			// Translate prefixes into directives, as the real binary does
+			var directives string
+			for _, line := range strings.Split(resp.output, "\n") {
+				translated := false
+				for prefix, verb := range map[string]string{"CD:": "cd", "EXEC:": "exec", "SETENV:": "setenv", "UNSETENV:": "unsetenv"} {
+					if strings.HasPrefix(line, prefix) {
+						directives += verb + " " + strings.TrimPrefix(line, prefix) + "\n"
+						translated = true
+					}
+				}
+				if !translated {
+					fmt.Println(line)
+				}
+			}
+			_ = os.WriteFile(path, []byte(directives), 0600)
 		} else {
 			fmt.Print(resp.output)
 		}